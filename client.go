@@ -1,17 +1,20 @@
 package elasticsearch
 
 import (
-	"fmt"
-
-	"github.com/ONSdigital/dp-elasticsearch/v4/client"
-	v710 "github.com/ONSdigital/dp-elasticsearch/v4/client/elasticsearch/v710"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	v710 "github.com/ONSdigital/dp-elasticsearch/v5/client/elasticsearch/v710"
+	v8 "github.com/ONSdigital/dp-elasticsearch/v5/client/elasticsearch/v8"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/opensearch"
 )
 
+// NewClient returns a client for the library given in cfg, defaulting to elasticsearch 7.10
 func NewClient(cfg client.Config) (client.Client, error) {
 	switch cfg.ClientLib {
+	case client.GoElasticV8:
+		return v8.NewESClientWithConfig(cfg)
 	case client.OpenSearch:
-		return nil, fmt.Errorf("the Opensearch client is currently not implemented")
+		return opensearch.NewClientWithConfig(cfg)
 	default:
-		return v710.NewESClient(cfg.Address, cfg.Transport)
+		return v710.NewESClientWithConfig(cfg)
 	}
 }
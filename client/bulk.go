@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/bulkctx"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+)
+
+const (
+	// DefaultBulkIndexerWorkers is the number of workers flushing bulk requests when BulkIndexerConfig.NumWorkers is zero
+	DefaultBulkIndexerWorkers = 5
+	// DefaultBulkIndexerFlushInterval is how often buffered items are flushed when BulkIndexerConfig.FlushInterval is zero
+	DefaultBulkIndexerFlushInterval = 30 * time.Second
+	// DefaultBulkIndexerProgressInterval is how often progress is reported when BulkIndexerConfig.ProgressInterval is zero
+	DefaultBulkIndexerProgressInterval = 10 * time.Second
+	// DefaultBulkIndexerFlushBytes is the size of a request body at which it is flushed when BulkIndexerConfig.FlushBytes is zero
+	DefaultBulkIndexerFlushBytes = 5e6
+	// DefaultBulkIndexerTargetFlushLatency is the flush duration above which an adaptive flush size is lowered when
+	// BulkIndexerConfig.TargetFlushLatency is zero
+	DefaultBulkIndexerTargetFlushLatency = time.Second
+)
+
+// BulkIndexer is a bulk indexer opened with OpenBulkIndexer. Each is independent of the others opened on the same
+// client, with its own settings, workers and stats.
+type BulkIndexer interface {
+	// Add adds an item to the indexer. It returns an error when the item cannot be added. Use the onSuccess and
+	// onFailure callbacks to get the result of the item. If the buffered items have reached MaxBufferedBytes it
+	// waits for room until ctx is done. It is safe for concurrent use, but every call must return before Close
+	// is called.
+	Add(ctx context.Context, action BulkIndexerAction, index, documentID string, document []byte, onSuccess SuccessFunc, onFailure FailureFunc) error
+	// TryAdd adds an item to the indexer as Add does, except that it returns an error matching ErrBulkIndexerFull
+	// straight away, rather than waiting, when the indexer has no room for the item or every worker is busy.
+	TryAdd(ctx context.Context, action BulkIndexerAction, index, documentID string, document []byte, onSuccess SuccessFunc, onFailure FailureFunc) error
+	// Close waits until every added item has been flushed and closes the indexer. It is safe to call more than once.
+	Close(ctx context.Context) error
+	// Stats returns the counts of items handled by the indexer since it was opened.
+	Stats() BulkIndexerStats
+}
+
+// BulkItemAttempts returns the number of times a bulk item was sent, counting the first, when called with the
+// context passed to the SuccessFunc or FailureFunc of the item. It returns zero for any other context.
+func BulkItemAttempts(ctx context.Context) int {
+	return bulkctx.Attempts(ctx)
+}
+
+var (
+	// ErrBulkIndexerOpen is matched, with errors.Is, by the error returned when opening a bulk indexer with the
+	// name of one that is still open
+	ErrBulkIndexerOpen = errors.New("bulk indexer is already open")
+	// ErrBulkIndexerClosed is matched, with errors.Is, by the error returned when adding an item to a bulk indexer
+	// that has been closed
+	ErrBulkIndexerClosed = errors.New("bulk indexer is closed")
+	// ErrBulkIndexerFull is matched, with errors.Is, by the error returned when adding an item to a bulk indexer
+	// whose buffered items have reached BulkIndexerConfig.MaxBufferedBytes, either by TryAdd or by Add once its
+	// context is done, or by TryAdd when every worker of the indexer is busy
+	ErrBulkIndexerFull = errors.New("bulk indexer is full")
+)
+
+// ErrBulkFailureRatioExceeded is matched, with errors.Is, by the error returned when closing a bulk indexer whose
+// ratio of failed items is above BulkIndexerConfig.MaxFailureRatio
+var ErrBulkFailureRatioExceeded = errors.New("bulk indexer failure ratio exceeded")
+
+// BulkIndexerConfig configures the bulk indexer created by NewBulkIndexer. A nil config, or a zero value for
+// any setting, uses the defaults, so that loaders sending large volumes and updaters needing low latency can
+// tune only what they need.
+type BulkIndexerConfig struct {
+	NumWorkers    int           // The number of workers flushing requests, defaults to DefaultBulkIndexerWorkers
+	FlushBytes    int           // The size of a request body at which it is flushed, defaults to DefaultBulkIndexerFlushBytes
+	FlushInterval time.Duration // How often buffered items are flushed, defaults to DefaultBulkIndexerFlushInterval
+
+	Index    string        // The index of items added without one
+	Pipeline string        // The ingest pipeline that items are run through
+	Refresh  Refresh       // When the changes of each request are made visible to search, defaults to the cluster refresh interval
+	Timeout  time.Duration // How long each request waits for the shards to become available
+
+	OnError      func(ctx context.Context, err error)      // Called when a request fails as a whole, rather than for any one item. Each item of the request is then failed too
+	OnFlushStart func(ctx context.Context) context.Context // Called when a flush starts, returning the context of the flush
+	OnFlushEnd   func(ctx context.Context)                 // Called when a flush ends, with the context returned by OnFlushStart
+
+	OnProgress       func(ctx context.Context, stats BulkIndexerStats) // Called with the stats every ProgressInterval while the indexer is open, and once more when it is closed
+	ProgressInterval time.Duration                                     // How often OnProgress is called, defaults to DefaultBulkIndexerProgressInterval
+	MaxFailureRatio  float64                                           // The ratio of failed items above which closing the indexer returns an error, not checked when zero
+
+	DeadLetter DeadLetterSink // Receives every item that fails before its FailureFunc is called, including the items of requests that fail as a whole, so that it can be replayed. Failures to send to it are passed to OnError
+
+	MaxAttempts     int           // The most times an item rejected with a 429 or 503 is sent, counting the first. Items are not retried when one or less
+	MinRetryBackoff time.Duration // The backoff before the first retry of an item, doubling with each retry, defaults to DefaultMinRetryBackoff
+	MaxRetryBackoff time.Duration // The upper bound of the backoff between retries of an item, defaults to DefaultMaxRetryBackoff
+
+	MaxBufferedBytes   int           // The most bytes of documents added but not yet completed, including those waiting to be retried. Add waits, and TryAdd fails, while it would be exceeded. Not limited when zero
+	MinFlushBytes      int           // Makes the flush size adaptive when set, lowering it as far as MinFlushBytes while flushes are slow or items are rejected with a 429 or 503, and raising it back towards FlushBytes while they are not. The size is reviewed every few flushes, rather than after each one
+	TargetFlushLatency time.Duration // The flush duration above which an adaptive flush size is lowered, defaults to DefaultBulkIndexerTargetFlushLatency
+}
+
+// WithDefaults returns a copy of the config with defaults applied to any unset values. It may be called on a nil config.
+func (cfg *BulkIndexerConfig) WithDefaults() BulkIndexerConfig {
+	var c BulkIndexerConfig
+	if cfg != nil {
+		c = *cfg
+	}
+
+	if c.NumWorkers <= 0 {
+		c.NumWorkers = DefaultBulkIndexerWorkers
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = DefaultBulkIndexerFlushInterval
+	}
+	if c.ProgressInterval <= 0 {
+		c.ProgressInterval = DefaultBulkIndexerProgressInterval
+	}
+	if c.MinFlushBytes > 0 && c.TargetFlushLatency <= 0 {
+		c.TargetFlushLatency = DefaultBulkIndexerTargetFlushLatency
+	}
+
+	return c
+}
+
+// BulkIndexerStats are the counts of items handled by a bulk indexer since it was created
+type BulkIndexerStats struct {
+	NumAdded    uint64 // Items added to the indexer
+	NumFlushed  uint64 // Items sent that succeeded
+	NumFailed   uint64 // Items that failed, including every item of a request that failed as a whole
+	NumIndexed  uint64 // Items indexed by index actions
+	NumCreated  uint64 // Items created by create actions
+	NumUpdated  uint64 // Items updated by update actions
+	NumDeleted  uint64 // Items deleted by delete actions
+	NumRequests uint64 // Bulk requests sent
+	NumRetried  uint64 // Items sent again after being rejected with a 429 or 503
+}
+
+// FailureRatio returns the ratio of failed items to every item that has completed, or zero if none have
+func (s BulkIndexerStats) FailureRatio() float64 {
+	completed := s.NumFlushed + s.NumFailed
+	if completed == 0 {
+		return 0
+	}
+
+	return float64(s.NumFailed) / float64(completed)
+}
+
+// CheckFailureRatio returns an error matching ErrBulkFailureRatioExceeded if the failure ratio of the stats is
+// above maxRatio. A maxRatio of zero or less is not checked.
+func (s BulkIndexerStats) CheckFailureRatio(maxRatio float64) error {
+	if maxRatio <= 0 || s.FailureRatio() <= maxRatio {
+		return nil
+	}
+
+	return esError.StatusError{
+		Err: fmt.Errorf("%w: %d of %d items failed, above the maximum ratio of %g",
+			ErrBulkFailureRatioExceeded, s.NumFailed, s.NumFlushed+s.NumFailed, maxRatio),
+	}
+}
@@ -0,0 +1,287 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+)
+
+// DefaultBulkRequestMaxBytes is the largest body of a request built by a BulkRequestBuilder when no maximum is given
+const DefaultBulkRequestMaxBytes = 5 << 20
+
+var (
+	// ErrBulkActionTooLarge is matched, with errors.Is, by the error returned when adding an action to a
+	// BulkRequestBuilder that would not fit in a request on its own
+	ErrBulkActionTooLarge = errors.New("bulk action is larger than the maximum request size")
+	// ErrBulkItemsFailed is matched, with errors.Is, by the error returned along with a BulkResponse when any of
+	// its items failed
+	ErrBulkItemsFailed = errors.New("bulk items failed")
+)
+
+// BulkOptions are the options for Bulk
+type BulkOptions struct {
+	Index    string        // The index of actions added without one
+	Refresh  Refresh       // When the changes are made visible to search, defaults to the cluster refresh interval
+	Pipeline string        // The ingest pipeline that documents are run through
+	Timeout  time.Duration // How long each request waits for the shards to become available
+}
+
+// BulkActionOptions are the metadata of a single bulk action. External versioning is not supported by updates,
+// and RetryOnConflict may only be set for updates.
+type BulkActionOptions struct {
+	Routing         string
+	RetryOnConflict *int // Times to retry an update if the document changes between being read and written. Not allowed with IfSeqNo
+	ConcurrencyControl
+}
+
+// bulkActionMetadata is the action and metadata line of a bulk action
+type bulkActionMetadata struct {
+	Index           string `json:"_index,omitempty"`
+	DocumentID      string `json:"_id,omitempty"`
+	Routing         string `json:"routing,omitempty"`
+	IfSeqNo         *int64 `json:"if_seq_no,omitempty"`
+	IfPrimaryTerm   *int64 `json:"if_primary_term,omitempty"`
+	Version         *int64 `json:"version,omitempty"`
+	VersionType     string `json:"version_type,omitempty"`
+	RetryOnConflict *int   `json:"retry_on_conflict,omitempty"`
+}
+
+// BulkRequestBuilder builds the newline delimited bodies of bulk requests, one action at a time, so that callers
+// do not have to assemble them by hand. Once adding the next action would take a body above the maximum size a
+// new body is started, so the actions may be split across several requests, which are sent in order by Bulk.
+type BulkRequestBuilder struct {
+	maxBytes int
+	requests [][]byte
+	current  bytes.Buffer
+	actions  int
+}
+
+// NewBulkRequestBuilder returns a builder of bulk requests with bodies of at most maxBytes, or
+// DefaultBulkRequestMaxBytes if maxBytes is zero or less
+func NewBulkRequestBuilder(maxBytes int) *BulkRequestBuilder {
+	if maxBytes <= 0 {
+		maxBytes = DefaultBulkRequestMaxBytes
+	}
+
+	return &BulkRequestBuilder{maxBytes: maxBytes}
+}
+
+// Index adds an action that indexes the document, replacing it if it exists. The document ID may be empty for
+// one to be generated, and the index empty to use the index given to Bulk.
+func (b *BulkRequestBuilder) Index(index, documentID string, document []byte, opts *BulkActionOptions) error {
+	return b.add("index", index, documentID, document, opts)
+}
+
+// Create adds an action that creates the document, which fails with a version conflict if it already exists
+func (b *BulkRequestBuilder) Create(index, documentID string, document []byte, opts *BulkActionOptions) error {
+	return b.add("create", index, documentID, document, opts)
+}
+
+// Update adds an action that updates the document with a partial document or a script
+func (b *BulkRequestBuilder) Update(index, documentID string, update DocumentUpdate, opts *BulkActionOptions) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return esError.StatusError{
+			Err: fmt.Errorf("failed to build update of document %s: %w", documentID, err),
+		}
+	}
+
+	return b.add("update", index, documentID, body, opts)
+}
+
+// Delete adds an action that deletes the document
+func (b *BulkRequestBuilder) Delete(index, documentID string, opts *BulkActionOptions) error {
+	return b.add("delete", index, documentID, nil, opts)
+}
+
+// Len returns the number of actions added
+func (b *BulkRequestBuilder) Len() int {
+	return b.actions
+}
+
+// Requests returns the body of each request, in the order the actions were added
+func (b *BulkRequestBuilder) Requests() [][]byte {
+	requests := append([][]byte{}, b.requests...)
+	if b.current.Len() > 0 {
+		requests = append(requests, bytes.Clone(b.current.Bytes()))
+	}
+
+	return requests
+}
+
+func (b *BulkRequestBuilder) add(action BulkIndexerAction, index, documentID string, document []byte, opts *BulkActionOptions) error {
+	if opts == nil {
+		opts = &BulkActionOptions{}
+	}
+
+	if documentID == "" && (action == "update" || action == "delete") {
+		return esError.StatusError{
+			Err: fmt.Errorf("document ID should not be empty for a %s action", action),
+		}
+	}
+
+	if action == "update" && opts.Version != nil {
+		return esError.StatusError{
+			Err: errors.New("external versioning cannot be used when updating a document, use if_seq_no and if_primary_term"),
+		}
+	}
+
+	if action != "update" && opts.RetryOnConflict != nil {
+		return esError.StatusError{
+			Err: fmt.Errorf("retry_on_conflict can only be used with update actions, not %s", action),
+		}
+	}
+
+	metadata, err := json.Marshal(map[BulkIndexerAction]bulkActionMetadata{
+		action: {
+			Index:           index,
+			DocumentID:      documentID,
+			Routing:         opts.Routing,
+			IfSeqNo:         opts.IfSeqNo,
+			IfPrimaryTerm:   opts.IfPrimaryTerm,
+			Version:         opts.Version,
+			VersionType:     opts.VersionTypeOrDefault(),
+			RetryOnConflict: opts.RetryOnConflict,
+		},
+	})
+	if err != nil {
+		return esError.StatusError{
+			Err: fmt.Errorf("failed to build %s of document %s: %w", action, documentID, err),
+		}
+	}
+
+	var line bytes.Buffer
+	line.Write(metadata)
+	line.WriteByte('\n')
+	if action != "delete" {
+		// Each document must be on a single line, so it is compacted, which also checks that it is JSON
+		if err := json.Compact(&line, document); err != nil {
+			return esError.StatusError{
+				Err: fmt.Errorf("document %s of %s action is not valid JSON: %w", documentID, action, err),
+			}
+		}
+		line.WriteByte('\n')
+	}
+
+	if line.Len() > b.maxBytes {
+		return esError.StatusError{
+			Err: fmt.Errorf("%w: %s of document %s is %d bytes, above the maximum of %d", ErrBulkActionTooLarge, action, documentID, line.Len(), b.maxBytes),
+		}
+	}
+
+	if b.current.Len()+line.Len() > b.maxBytes {
+		b.requests = append(b.requests, bytes.Clone(b.current.Bytes()))
+		b.current.Reset()
+	}
+	b.current.Write(line.Bytes())
+	b.actions++
+
+	return nil
+}
+
+// BulkResponse is the response to one or more bulk requests
+type BulkResponse struct {
+	Took   int              `json:"took"`
+	Errors bool             `json:"errors"`
+	Items  []BulkItemResult `json:"items"`
+}
+
+// BulkItemResult is the result of a single bulk action
+type BulkItemResult struct {
+	Action BulkIndexerAction
+	WriteResult
+	Status int
+	Error  *esError.ErrorCause
+}
+
+// UnmarshalJSON decodes a bulk response item, which is an object keyed by the action
+func (r *BulkItemResult) UnmarshalJSON(data []byte) error {
+	var item map[BulkIndexerAction]struct {
+		WriteResult
+		Status int                 `json:"status"`
+		Error  *esError.ErrorCause `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+
+	if len(item) != 1 {
+		return fmt.Errorf("bulk response item has %d actions, expected 1", len(item))
+	}
+
+	for action, res := range item {
+		*r = BulkItemResult{
+			Action:      action,
+			WriteResult: res.WriteResult,
+			Status:      res.Status,
+			Error:       res.Error,
+		}
+	}
+
+	return nil
+}
+
+// Failed reports whether the action failed. Deleting a document that does not exist is not a failure, as the
+// document is gone either way.
+func (r BulkItemResult) Failed() bool {
+	if r.Action == "delete" && r.Status == http.StatusNotFound && r.Result == "not_found" {
+		return false
+	}
+
+	return r.Error != nil || r.Status < 200 || r.Status > 299
+}
+
+// Failed returns the results of the actions that failed
+func (r *BulkResponse) Failed() []BulkItemResult {
+	var failed []BulkItemResult
+	for _, item := range r.Items {
+		if item.Failed() {
+			failed = append(failed, item)
+		}
+	}
+
+	return failed
+}
+
+// Err returns an error matching ErrBulkItemsFailed, describing the first failure, if any of the actions failed
+func (r *BulkResponse) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 && !r.Errors {
+		return nil
+	}
+
+	if len(failed) == 0 {
+		return esError.StatusError{
+			Err: fmt.Errorf("%w: the response has errors but no failed items", ErrBulkItemsFailed),
+		}
+	}
+
+	first := failed[0]
+	reason := fmt.Sprintf("status %d", first.Status)
+	if first.Error != nil {
+		reason = fmt.Sprintf("%s: %s", first.Error.Type, first.Error.Reason)
+	}
+
+	return esError.StatusError{
+		Err: fmt.Errorf("%w: %d of %d items failed, the first being %s of document %s in %s with %s",
+			ErrBulkItemsFailed, len(failed), len(r.Items), first.Action, first.ID, first.Index, reason),
+	}
+}
+
+// ParseBulkResponse decodes the body of a bulk response into the result of each action. If the response has
+// errors the response is returned along with the error from Err, so that failed items cannot go unnoticed.
+func ParseBulkResponse(body []byte) (*BulkResponse, error) {
+	var res BulkResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to decode bulk response: %w", err),
+		}
+	}
+
+	return &res, res.Err()
+}
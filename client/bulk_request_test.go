@@ -0,0 +1,161 @@
+package client_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBulkRequestBuilder(t *testing.T) {
+	Convey("Given a bulk request builder", t, func() {
+		bulk := client.NewBulkRequestBuilder(0)
+		seqNo, term, version, retries := int64(3), int64(1), int64(7), 2
+
+		Convey("When an action of each type is added with metadata", func() {
+			So(bulk.Index("my-index", "1", []byte("{\n  \"n\": 1\n}"), &client.BulkActionOptions{Routing: "r1"}), ShouldBeNil)
+			So(bulk.Create("", "2", []byte(`{"n":2}`), &client.BulkActionOptions{
+				ConcurrencyControl: client.ConcurrencyControl{Version: &version},
+			}), ShouldBeNil)
+			So(bulk.Update("my-index", "3", client.DocumentUpdate{Doc: []byte(`{"n":3}`)}, &client.BulkActionOptions{
+				RetryOnConflict: &retries,
+			}), ShouldBeNil)
+			So(bulk.Delete("my-index", "4", &client.BulkActionOptions{
+				ConcurrencyControl: client.ConcurrencyControl{IfSeqNo: &seqNo, IfPrimaryTerm: &term},
+			}), ShouldBeNil)
+
+			Convey("Then a single newline delimited request is built, with each document on one line", func() {
+				So(bulk.Len(), ShouldEqual, 4)
+				So(bulk.Requests(), ShouldHaveLength, 1)
+				So(string(bulk.Requests()[0]), ShouldEqual, strings.Join([]string{
+					`{"index":{"_index":"my-index","_id":"1","routing":"r1"}}`,
+					`{"n":1}`,
+					`{"create":{"_id":"2","version":7,"version_type":"external"}}`,
+					`{"n":2}`,
+					`{"update":{"_index":"my-index","_id":"3","retry_on_conflict":2}}`,
+					`{"doc":{"n":3}}`,
+					`{"delete":{"_index":"my-index","_id":"4","if_seq_no":3,"if_primary_term":1}}`,
+					``,
+				}, "\n"))
+			})
+		})
+
+		Convey("When actions that are not valid are added", func() {
+			errs := []error{
+				bulk.Index("my-index", "1", []byte(`not json`), nil),
+				bulk.Update("my-index", "", client.DocumentUpdate{Doc: []byte(`{}`)}, nil),
+				bulk.Delete("my-index", "", nil),
+				bulk.Update("my-index", "1", client.DocumentUpdate{Doc: []byte(`{}`)}, &client.BulkActionOptions{
+					ConcurrencyControl: client.ConcurrencyControl{Version: &version},
+				}),
+				bulk.Index("my-index", "1", []byte(`{}`), &client.BulkActionOptions{RetryOnConflict: &retries}),
+			}
+
+			Convey("Then each is rejected and nothing is added", func() {
+				for _, err := range errs {
+					So(err, ShouldNotBeNil)
+				}
+				So(bulk.Len(), ShouldEqual, 0)
+				So(bulk.Requests(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a bulk request builder with a small maximum size", t, func() {
+		// Each index action below is 42 bytes
+		bulk := client.NewBulkRequestBuilder(100)
+
+		Convey("When more actions are added than fit in one request", func() {
+			for _, id := range []string{"1", "2", "3", "4", "5"} {
+				So(bulk.Index("my-index", id, []byte(`{"n":1}`), nil), ShouldBeNil)
+			}
+
+			Convey("Then they are split into requests no larger than the maximum, in order", func() {
+				requests := bulk.Requests()
+				So(requests, ShouldHaveLength, 3)
+				for _, request := range requests {
+					So(len(request), ShouldBeLessThanOrEqualTo, 100)
+				}
+				So(string(requests[0]), ShouldContainSubstring, `"_id":"1"`)
+				So(string(requests[0]), ShouldContainSubstring, `"_id":"2"`)
+				So(string(requests[2]), ShouldContainSubstring, `"_id":"5"`)
+			})
+		})
+
+		Convey("When an action larger than the maximum is added", func() {
+			err := bulk.Index("my-index", "1", []byte(`{"text":"`+strings.Repeat("a", 100)+`"}`), nil)
+
+			Convey("Then an error matching ErrBulkActionTooLarge is returned", func() {
+				So(errors.Is(err, client.ErrBulkActionTooLarge), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestParseBulkResponse(t *testing.T) {
+	Convey("Given a bulk response where every item succeeded", t, func() {
+		body := `{"took":3,"errors":false,"items":[` +
+			`{"index":{"_index":"my-index","_id":"1","_version":1,"result":"created","_seq_no":0,"_primary_term":1,"status":201}},` +
+			`{"delete":{"_index":"my-index","_id":"2","result":"deleted","status":200}}]}`
+
+		Convey("When it is parsed", func() {
+			res, err := client.ParseBulkResponse([]byte(body))
+
+			Convey("Then the result of each item is returned without an error", func() {
+				So(err, ShouldBeNil)
+				So(res.Took, ShouldEqual, 3)
+				So(res.Items, ShouldHaveLength, 2)
+				So(res.Items[0].Action, ShouldEqual, client.BulkIndexerAction("index"))
+				So(res.Items[0].ID, ShouldEqual, "1")
+				So(res.Items[0].Result, ShouldEqual, "created")
+				So(res.Items[0].Status, ShouldEqual, 201)
+				So(res.Items[1].Action, ShouldEqual, client.BulkIndexerAction("delete"))
+				So(res.Failed(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a bulk response deleting a document that does not exist", t, func() {
+		body := `{"took":1,"errors":false,"items":[` +
+			`{"delete":{"_index":"my-index","_id":"missing","_version":1,"result":"not_found","status":404}}]}`
+
+		Convey("When it is parsed", func() {
+			res, err := client.ParseBulkResponse([]byte(body))
+
+			Convey("Then the delete is not reported as failed", func() {
+				So(err, ShouldBeNil)
+				So(res.Items[0].Failed(), ShouldBeFalse)
+				So(res.Failed(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a bulk response with errors", t, func() {
+		body := `{"took":3,"errors":true,"items":[` +
+			`{"index":{"_index":"my-index","_id":"1","result":"created","status":201}},` +
+			`{"create":{"_index":"my-index","_id":"2","status":409,"error":{"type":"version_conflict_engine_exception","reason":"document already exists"}}}]}`
+
+		Convey("When it is parsed", func() {
+			res, err := client.ParseBulkResponse([]byte(body))
+
+			Convey("Then the response is returned with an error describing the failed item", func() {
+				So(errors.Is(err, client.ErrBulkItemsFailed), ShouldBeTrue)
+				So(err.Error(), ShouldContainSubstring, "1 of 2 items failed")
+				So(err.Error(), ShouldContainSubstring, "version_conflict_engine_exception")
+				So(res.Items, ShouldHaveLength, 2)
+				So(res.Failed(), ShouldHaveLength, 1)
+				So(res.Failed()[0].Error.Reason, ShouldEqual, "document already exists")
+			})
+		})
+	})
+
+	Convey("Given a body that is not a bulk response", t, func() {
+		Convey("Then parsing it returns an error", func() {
+			res, err := client.ParseBulkResponse([]byte(`not json`))
+			So(err, ShouldNotBeNil)
+			So(res, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,64 @@
+package client_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBulkIndexerConfig(t *testing.T) {
+	Convey("Given a nil bulk indexer config", t, func() {
+		var cfg *client.BulkIndexerConfig
+
+		Convey("Then the defaults are applied", func() {
+			c := cfg.WithDefaults()
+			So(c.NumWorkers, ShouldEqual, client.DefaultBulkIndexerWorkers)
+			So(c.FlushInterval, ShouldEqual, client.DefaultBulkIndexerFlushInterval)
+			So(c.FlushBytes, ShouldEqual, 0)
+		})
+	})
+
+	Convey("Given a bulk indexer config with settings", t, func() {
+		cfg := &client.BulkIndexerConfig{
+			NumWorkers:       2,
+			FlushBytes:       1 << 20,
+			FlushInterval:    time.Second,
+			Index:            "my-index",
+			ProgressInterval: time.Minute,
+		}
+
+		Convey("Then they are kept", func() {
+			So(cfg.WithDefaults(), ShouldResemble, *cfg)
+		})
+	})
+}
+
+func TestBulkIndexerStats(t *testing.T) {
+	Convey("Given the stats of a bulk indexer where 1 of 4 completed items failed", t, func() {
+		stats := client.BulkIndexerStats{NumAdded: 5, NumFlushed: 3, NumFailed: 1}
+
+		Convey("Then the failure ratio is 0.25", func() {
+			So(stats.FailureRatio(), ShouldEqual, 0.25)
+		})
+
+		Convey("Then a higher maximum ratio is not exceeded", func() {
+			So(stats.CheckFailureRatio(0.5), ShouldBeNil)
+			So(stats.CheckFailureRatio(0), ShouldBeNil)
+		})
+
+		Convey("Then a lower maximum ratio is exceeded", func() {
+			err := stats.CheckFailureRatio(0.1)
+			So(errors.Is(err, client.ErrBulkFailureRatioExceeded), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "1 of 4 items failed")
+		})
+	})
+
+	Convey("Given the stats of a bulk indexer where no items have completed", t, func() {
+		Convey("Then the failure ratio is 0", func() {
+			So(client.BulkIndexerStats{NumAdded: 5}.FailureRatio(), ShouldEqual, 0)
+		})
+	})
+}
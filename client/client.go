@@ -2,32 +2,58 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
 	"github.com/elastic/go-elasticsearch/v7/esutil"
 )
 
-//go:generate moq -out ./mocks/client.go -pkg mocks . Client
+//go:generate moq -out ./mocks/client.go -pkg mocks . Client BulkIndexer
 
 // Client holds the methods for ElasticSearch clients
 type Client interface {
-	AddDocument(ctx context.Context, indexName, documentID string, document []byte, opts *AddDocumentOptions) error
+	AddDocument(ctx context.Context, indexName, documentID string, document []byte, opts *AddDocumentOptions) (*WriteResult, error)
+	Bulk(ctx context.Context, bulk *BulkRequestBuilder, opts *BulkOptions) (*BulkResponse, error)
 	BulkUpdate(ctx context.Context, indexName, url string, settings []byte) ([]byte, error)
 	BulkIndexAdd(ctx context.Context, action BulkIndexerAction, index, documentID string, document []byte, onSuccess SuccessFunc, onFailure FailureFunc) error
 	BulkIndexClose(context.Context) error
+	BulkIndexStats(context.Context) (BulkIndexerStats, error)
+	BulkIndexTryAdd(ctx context.Context, action BulkIndexerAction, index, documentID string, document []byte, onSuccess SuccessFunc, onFailure FailureFunc) error
+	CancelTask(ctx context.Context, taskID string) (*TasksResponse, error)
+	ClearScroll(ctx context.Context, scrollIDs []string) error
+	ClosePointInTime(ctx context.Context, pitID string) error
 	Checker(ctx context.Context, state *health.CheckState) error
+	ClusterHealth(ctx context.Context, indices []string, opts *ClusterHealthOptions) (*ClusterHealthResponse, error)
 	CreateIndex(ctx context.Context, indexName string, indexSettings []byte) error
-	DeleteDocument(ctx context.Context, indexName, documentID string) error
+	DeleteDocument(ctx context.Context, indexName, documentID string, opts *DeleteDocumentOptions) (*WriteResult, error)
 	DeleteDocumentByQuery(ctx context.Context, search Search) error
 	DeleteIndex(ctx context.Context, indexName string) error
 	DeleteIndices(ctx context.Context, indices []string) error
 	GetAlias(ctx context.Context) ([]byte, error)
+	GetDocument(ctx context.Context, indexName, documentID string, opts *GetDocumentOptions) (*GetResult, error)
 	GetIndices(ctx context.Context, indexPatterns []string) ([]byte, error)
-	NewBulkIndexer(context.Context) error
+	GetTask(ctx context.Context, taskID string, opts *GetTaskOptions) (*TaskResponse, error)
+	ListTasks(ctx context.Context, opts *ListTasksOptions) (*TasksResponse, error)
+	MultiGet(ctx context.Context, docs []MultiGetDocument, opts *MultiGetOptions) (*MultiGetResponse, error)
+	NewBulkIndexer(ctx context.Context, cfg *BulkIndexerConfig) error
+	OpenBulkIndexer(ctx context.Context, name string, cfg *BulkIndexerConfig) (BulkIndexer, error)
+	OpenPointInTime(ctx context.Context, indices []string, keepAlive time.Duration) (string, error)
+	RefreshIndices(ctx context.Context, indices []string) error
+	Reindex(ctx context.Context, reindex Reindex, opts *ReindexOptions) (*BulkByScrollResponse, error)
+	RethrottleTask(ctx context.Context, taskID string, requestsPerSecond int) (*TasksResponse, error)
 	UpdateAliases(ctx context.Context, alias string, removeIndices, addIndices []string) error
+	UpdateAliasActions(ctx context.Context, actions []AliasAction) error
+	UpdateDocument(ctx context.Context, indexName, documentID string, update DocumentUpdate, opts *UpdateDocumentOptions) (*WriteResult, error)
+	UpdateByQuery(ctx context.Context, indices []string, update UpdateByQuery, opts *UpdateByQueryOptions) (*BulkByScrollResponse, error)
 	MultiSearch(ctx context.Context, searches []Search, queryParams *QueryParams) ([]byte, error)
 	Search(ctx context.Context, search Search) ([]byte, error)
+	Scroll(ctx context.Context, search Search, keepAlive time.Duration) ([]byte, error)
+	ScrollNext(ctx context.Context, scrollID string, keepAlive time.Duration) ([]byte, error)
 	CountIndices(ctx context.Context, indices []string) ([]byte, error)
 	Count(ctx context.Context, count Count) ([]byte, error)
 	Explain(ctx context.Context, documentID string, search Search) ([]byte, error)
@@ -39,23 +65,425 @@ type BulkIndexerAction string
 
 const (
 	GoElasticV710 Library = "GoElastic_v710"
+	GoElasticV8   Library = "GoElastic_v8"
 	OpenSearch    Library = "OpenSearch"
 )
 
-// Config holds the configuration of search client
+// Config holds the configuration of search client.
+// MaxRetries defaults to DefaultMaxRetries when zero, and a negative value disables retries.
 type Config struct {
-	ClientLib  Library
-	MaxRetries int
-	Address    string
-	Indexes    []string
-	Transport  http.RoundTripper
+	ClientLib       Library
+	MaxRetries      int
+	MinRetryBackoff time.Duration
+	MaxRetryBackoff time.Duration
+	RetryOnStatus   []int
+	Address         string
+	Indexes         []string
+	Transport       http.RoundTripper
 }
 
+// AddDocumentOptions are the options for AddDocument. By default the document is created, failing with a
+// version conflict if it already exists.
 type AddDocumentOptions struct {
-	DocumentType string // Deprecated - not used by newer versions of elasticsearch
-	Upsert       bool
+	DocumentType string     // Deprecated - not used by newer versions of elasticsearch
+	Upsert       bool       // Add the document whether or not it already exists, as described by UpsertMode
+	UpsertMode   UpsertMode // How an existing document is upserted, defaults to UpsertReplace
+	Refresh      Refresh    // When the change is made visible to search, defaults to the cluster refresh interval
+	Routing      string     // Custom value used to route the document to a shard
+	Pipeline     string     // Ingest pipeline to pre-process the document with. Not supported by UpsertMerge
+	ConcurrencyControl
 }
 
+// DeleteDocumentOptions are the options for DeleteDocument
+type DeleteDocumentOptions struct {
+	Refresh Refresh
+	Routing string
+	ConcurrencyControl
+}
+
+// UpdateDocumentOptions are the options for UpdateDocument. External versioning is not supported by updates,
+// so only IfSeqNo and IfPrimaryTerm of the ConcurrencyControl may be set.
+type UpdateDocumentOptions struct {
+	Refresh         Refresh
+	Routing         string
+	RetryOnConflict *int // Times to retry the update if the document changes between being read and written. Not allowed with IfSeqNo
+	ConcurrencyControl
+}
+
+// DocumentUpdate is an update of a document, either merging a partial document into it or running a script
+type DocumentUpdate struct {
+	Doc         []byte  // Partial document merged into the existing document
+	DocAsUpsert bool    // Create the document from Doc if it does not exist
+	Script      *Script // Script that updates the document, used instead of Doc
+	Upsert      []byte  // Document to create, without running Script, if the document does not exist
+}
+
+// MarshalJSON returns the body of an update request
+func (u DocumentUpdate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Doc         json.RawMessage `json:"doc,omitempty"`
+		DocAsUpsert bool            `json:"doc_as_upsert,omitempty"`
+		Script      *Script         `json:"script,omitempty"`
+		Upsert      json.RawMessage `json:"upsert,omitempty"`
+	}{
+		Doc:         u.Doc,
+		DocAsUpsert: u.DocAsUpsert,
+		Script:      u.Script,
+		Upsert:      u.Upsert,
+	})
+}
+
+// Script is a script run by an update, such as a painless script that changes ctx._source
+type Script struct {
+	Source string                 `json:"source"`
+	Lang   string                 `json:"lang,omitempty"` // defaults to painless
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// UpdateByQuery is an update of every document matching a query
+type UpdateByQuery struct {
+	Query  json.RawMessage // Query clause selecting the documents, such as {"term":{"type":"bulletin"}}. All documents are updated when empty
+	Script *Script         // Script run against each document. Without one, documents are re-indexed unchanged, e.g. to pick up a mapping change
+}
+
+// MarshalJSON returns the body of an update by query request
+func (u UpdateByQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Query  json.RawMessage `json:"query,omitempty"`
+		Script *Script         `json:"script,omitempty"`
+	}{
+		Query:  u.Query,
+		Script: u.Script,
+	})
+}
+
+// UpdateByQueryOptions are the options for UpdateByQuery
+type UpdateByQueryOptions struct {
+	ProceedOnConflicts bool     // Count version conflicts rather than aborting the update (conflicts=proceed)
+	Slices             string   // Number of slices to split the update into, or "auto"
+	WaitForCompletion  *bool    // Defaults to true. When false the update runs as a task, whose ID is returned
+	Refresh            bool     // Refresh the affected indices once the update has completed
+	RequestsPerSecond  *int     // Throttles the update, defaults to no throttling
+	MaxDocs            *int     // Maximum number of documents to update
+	Routing            []string // Only update documents with these routing values
+}
+
+// Reindex is a copy of documents from a source, which may be on a remote cluster, into a destination index
+type Reindex struct {
+	Source             ReindexSource
+	Dest               ReindexDest
+	Script             *Script // Script run against each document before it is indexed into the destination
+	ProceedOnConflicts bool    // Count version conflicts rather than aborting the reindex (conflicts=proceed)
+}
+
+// ReindexSource is the source of the documents copied by a reindex
+type ReindexSource struct {
+	Index  []string        // Indices, aliases or patterns to copy documents from
+	Query  json.RawMessage // Query clause selecting the documents to copy. All documents are copied when empty
+	Size   int             // Number of documents read per batch, defaults to 1000
+	Remote *ReindexRemote  // Cluster to read the documents from, defaults to the local cluster
+}
+
+// ReindexRemote is a remote cluster a reindex reads documents from. The host must be allowed by the
+// reindex.remote.whitelist setting of the destination cluster.
+type ReindexRemote struct {
+	Host           string // e.g. https://otherhost:9200
+	Username       string
+	Password       string
+	SocketTimeout  time.Duration // defaults to 30s
+	ConnectTimeout time.Duration // defaults to 30s
+}
+
+// ReindexDest is the destination of the documents copied by a reindex
+type ReindexDest struct {
+	Index    string
+	OpType   string // "create" to only copy documents missing from the destination, defaults to "index"
+	Pipeline string // Ingest pipeline to pre-process the documents with
+}
+
+// MarshalJSON returns the body of a reindex request
+func (r Reindex) MarshalJSON() ([]byte, error) {
+	type remote struct {
+		Host           string `json:"host"`
+		Username       string `json:"username,omitempty"`
+		Password       string `json:"password,omitempty"`
+		SocketTimeout  string `json:"socket_timeout,omitempty"`
+		ConnectTimeout string `json:"connect_timeout,omitempty"`
+	}
+	type source struct {
+		Index  []string        `json:"index"`
+		Query  json.RawMessage `json:"query,omitempty"`
+		Size   int             `json:"size,omitempty"`
+		Remote *remote         `json:"remote,omitempty"`
+	}
+	type dest struct {
+		Index    string `json:"index"`
+		OpType   string `json:"op_type,omitempty"`
+		Pipeline string `json:"pipeline,omitempty"`
+	}
+
+	body := struct {
+		Conflicts string  `json:"conflicts,omitempty"`
+		Source    source  `json:"source"`
+		Dest      dest    `json:"dest"`
+		Script    *Script `json:"script,omitempty"`
+	}{
+		Source: source{Index: r.Source.Index, Query: r.Source.Query, Size: r.Source.Size},
+		Dest:   dest{Index: r.Dest.Index, OpType: r.Dest.OpType, Pipeline: r.Dest.Pipeline},
+		Script: r.Script,
+	}
+
+	if r.ProceedOnConflicts {
+		body.Conflicts = "proceed"
+	}
+
+	if r.Source.Remote != nil {
+		body.Source.Remote = &remote{
+			Host:           r.Source.Remote.Host,
+			Username:       r.Source.Remote.Username,
+			Password:       r.Source.Remote.Password,
+			SocketTimeout:  formatDuration(r.Source.Remote.SocketTimeout),
+			ConnectTimeout: formatDuration(r.Source.Remote.ConnectTimeout),
+		}
+	}
+
+	return json.Marshal(body)
+}
+
+// ReindexOptions are the options for Reindex
+type ReindexOptions struct {
+	Slices            string // Number of slices to split the reindex into, or "auto". Not supported with a remote source
+	WaitForCompletion *bool  // Defaults to true. When false the reindex runs as a task, whose ID is returned
+	Refresh           bool   // Refresh the destination index once the reindex has completed
+	RequestsPerSecond *int   // Throttles the reindex, defaults to no throttling
+	MaxDocs           *int   // Maximum number of documents to copy
+}
+
+// formatDuration formats a duration as a time value in milliseconds, or an empty string for zero
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+// GetDocumentOptions are the options for GetDocument
+type GetDocumentOptions struct {
+	SourceIncludes []string // Fields of the _source to return, defaults to all fields
+	SourceExcludes []string // Fields of the _source to leave out
+	Routing        string   // Custom value the document was routed to a shard with
+	Realtime       *bool    // Whether to get the latest version of the document rather than the last refreshed version, defaults to true
+}
+
+// MultiGetDocument identifies a document to get with MultiGet
+type MultiGetDocument struct {
+	Index          string
+	ID             string
+	Routing        string
+	SourceIncludes []string // Overrides the SourceIncludes of the MultiGetOptions for this document
+	SourceExcludes []string // Overrides the SourceExcludes of the MultiGetOptions for this document
+}
+
+// MarshalJSON returns the document as an entry of the docs in a multi get request body
+func (d MultiGetDocument) MarshalJSON() ([]byte, error) {
+	type source struct {
+		Includes []string `json:"includes,omitempty"`
+		Excludes []string `json:"excludes,omitempty"`
+	}
+
+	doc := struct {
+		Index   string  `json:"_index"`
+		ID      string  `json:"_id"`
+		Routing string  `json:"routing,omitempty"`
+		Source  *source `json:"_source,omitempty"`
+	}{
+		Index:   d.Index,
+		ID:      d.ID,
+		Routing: d.Routing,
+	}
+	if len(d.SourceIncludes) > 0 || len(d.SourceExcludes) > 0 {
+		doc.Source = &source{Includes: d.SourceIncludes, Excludes: d.SourceExcludes}
+	}
+
+	return json.Marshal(doc)
+}
+
+// MultiGetOptions are the options for MultiGet
+type MultiGetOptions struct {
+	SourceIncludes []string
+	SourceExcludes []string
+	Realtime       *bool
+}
+
+// ConcurrencyControl makes a write conditional on the current state of the document. Either set IfSeqNo and
+// IfPrimaryTerm to the values returned when the document was last read or written, or set an external Version.
+// If the document has changed the write fails with a 409 version conflict, see errors.IsVersionConflict.
+type ConcurrencyControl struct {
+	IfSeqNo       *int64
+	IfPrimaryTerm *int64
+	Version       *int64      // Version held by an external system
+	VersionType   VersionType // How Version is compared, defaults to VersionTypeExternal when Version is set
+}
+
+// VersionType is how an external version is compared with the version of the stored document
+type VersionType string
+
+const (
+	VersionTypeExternal    VersionType = "external"     // The write succeeds if the version is greater than the stored version
+	VersionTypeExternalGTE VersionType = "external_gte" // The write succeeds if the version is greater than or equal to the stored version
+)
+
+// IsSet reports whether any condition is set. A conditional write that was applied would fail with a conflict
+// if it were replayed, so it is not replayed after an ambiguous failure.
+func (c ConcurrencyControl) IsSet() bool {
+	return c.IfSeqNo != nil || c.IfPrimaryTerm != nil || c.Version != nil
+}
+
+// VersionTypeOrDefault returns the version type to send with the request, if any
+func (c ConcurrencyControl) VersionTypeOrDefault() string {
+	if c.Version == nil {
+		return ""
+	}
+	if c.VersionType == "" {
+		return string(VersionTypeExternal)
+	}
+	return string(c.VersionType)
+}
+
+// UpsertMode is how an existing document is upserted by AddDocument
+type UpsertMode string
+
+const (
+	// UpsertReplace indexes the document, replacing any existing document with the same ID
+	UpsertReplace UpsertMode = "replace"
+	// UpsertMerge merges the document into any existing document with the same ID, or creates it (doc_as_upsert)
+	UpsertMerge UpsertMode = "merge"
+)
+
+// Refresh is the refresh policy of a write request
+type Refresh string
+
+const (
+	RefreshTrue    Refresh = "true"     // Refresh the affected shards straight away
+	RefreshFalse   Refresh = "false"    // Do not refresh, the change is visible after the next scheduled refresh
+	RefreshWaitFor Refresh = "wait_for" // Wait for the next scheduled refresh before returning
+)
+
+// AliasActionType is the type of an AliasAction
+type AliasActionType string
+
+const (
+	AliasAdd         AliasActionType = "add"          // Add indices to aliases
+	AliasRemove      AliasActionType = "remove"       // Remove indices from aliases
+	AliasRemoveIndex AliasActionType = "remove_index" // Delete indices, as part of the same atomic request
+)
+
+// AliasAction is a single action of an UpdateAliasActions request
+type AliasAction struct {
+	Type          AliasActionType
+	Indices       []string        // Indices the action applies to, which may include wildcards
+	Aliases       []string        // Aliases the action applies to. Not allowed for AliasRemoveIndex
+	Filter        json.RawMessage // Query clause limiting the documents visible through the alias. AliasAdd only
+	Routing       string          // Routing used for both indexing and searching through the alias. AliasAdd only
+	IndexRouting  string          // Routing used for indexing through the alias, overriding Routing. AliasAdd only
+	SearchRouting string          // Routing used for searching through the alias, overriding Routing. AliasAdd only
+	IsWriteIndex  *bool           // Whether the index is the one written to through the alias. AliasAdd only
+}
+
+// Validate checks that the action is complete and only has the fields allowed for its type
+func (a AliasAction) Validate() error {
+	switch a.Type {
+	case AliasAdd, AliasRemove:
+		if len(a.Aliases) == 0 {
+			return fmt.Errorf("%s alias action must have at least one alias", a.Type)
+		}
+	case AliasRemoveIndex:
+		if len(a.Aliases) > 0 {
+			return errors.New("remove_index alias action cannot have aliases")
+		}
+	default:
+		return fmt.Errorf("unknown alias action type %q", a.Type)
+	}
+
+	if len(a.Indices) == 0 {
+		return fmt.Errorf("%s alias action must have at least one index", a.Type)
+	}
+
+	if a.Type != AliasAdd && (len(a.Filter) > 0 || a.Routing != "" || a.IndexRouting != "" || a.SearchRouting != "" || a.IsWriteIndex != nil) {
+		return fmt.Errorf("%s alias action can only have indices and aliases", a.Type)
+	}
+
+	return nil
+}
+
+// MarshalJSON returns the action as an element of the actions array of an update aliases request
+func (a AliasAction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[AliasActionType]interface{}{
+		a.Type: struct {
+			Indices       []string        `json:"indices"`
+			Aliases       []string        `json:"aliases,omitempty"`
+			Filter        json.RawMessage `json:"filter,omitempty"`
+			Routing       string          `json:"routing,omitempty"`
+			IndexRouting  string          `json:"index_routing,omitempty"`
+			SearchRouting string          `json:"search_routing,omitempty"`
+			IsWriteIndex  *bool           `json:"is_write_index,omitempty"`
+		}{
+			Indices:       a.Indices,
+			Aliases:       a.Aliases,
+			Filter:        a.Filter,
+			Routing:       a.Routing,
+			IndexRouting:  a.IndexRouting,
+			SearchRouting: a.SearchRouting,
+			IsWriteIndex:  a.IsWriteIndex,
+		},
+	})
+}
+
+// AliasActions returns the actions that atomically move an alias from the indices in removeIndices to those in
+// addIndices, as used by UpdateAliases
+func AliasActions(alias string, removeIndices, addIndices []string) []AliasAction {
+	var actions []AliasAction
+
+	if len(removeIndices) > 0 {
+		actions = append(actions, AliasAction{Type: AliasRemove, Indices: removeIndices, Aliases: []string{alias}})
+	}
+
+	if len(addIndices) > 0 {
+		actions = append(actions, AliasAction{Type: AliasAdd, Indices: addIndices, Aliases: []string{alias}})
+	}
+
+	return actions
+}
+
+// GetTaskOptions are the options for GetTask
+type GetTaskOptions struct {
+	WaitForCompletion bool          // Wait for the task to complete before returning
+	Timeout           time.Duration // How long to wait for the task to complete, defaults to 30s on the cluster
+}
+
+// ListTasksOptions are the options for ListTasks
+type ListTasksOptions struct {
+	Actions      []string // Actions of the tasks to list, which may include wildcards, e.g. "*reindex" or "indices:data/write/update/byquery"
+	Detailed     bool     // Include the description and status of each task
+	ParentTaskID string   // Only list the child tasks of this task, e.g. the slices of a sliced reindex
+}
+
+// ClusterHealthOptions are the options for ClusterHealth
+type ClusterHealthOptions struct {
+	WaitForStatus ClusterStatus // Wait until the cluster, or the given indices, reach at least this status
+	Timeout       time.Duration // How long to wait for the status, defaults to 30s on the cluster
+}
+
+// ClusterStatus is the health status of a cluster or index
+type ClusterStatus string
+
+const (
+	ClusterStatusGreen  ClusterStatus = "green"  // All shards are allocated
+	ClusterStatusYellow ClusterStatus = "yellow" // All primary shards are allocated, but some replicas are not
+	ClusterStatusRed    ClusterStatus = "red"    // Some primary shards are not allocated
+)
+
 type Header struct {
 	Index string `json:"index"`
 }
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"sync"
+	"time"
+
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+)
+
+// DeadLetter is a bulk indexer item that failed, with everything needed to submit it again
+type DeadLetter struct {
+	Action     BulkIndexerAction
+	Index      string
+	DocumentID string
+	Body       []byte
+	Status     int                 // The status of the item in the bulk response, or zero if the request failed as a whole
+	Error      *esError.ErrorCause // The error of the item or, if the request failed as a whole, the error the cluster responded with or why no response was received
+	Time       time.Time           // When the item failed
+	Attempts   int                 // The number of times the item was sent
+}
+
+// deadLetterJSON is the encoding of a dead letter. A body that is JSON, as every document is, is written as is
+// so that the file can be read and edited. Any other body is base64 encoded so that it is kept exactly.
+type deadLetterJSON struct {
+	Action     BulkIndexerAction   `json:"action"`
+	Index      string              `json:"index"`
+	DocumentID string              `json:"id,omitempty"`
+	Body       json.RawMessage     `json:"body,omitempty"`
+	BodyBase64 []byte              `json:"body_base64,omitempty"`
+	Status     int                 `json:"status,omitempty"`
+	Error      *esError.ErrorCause `json:"error,omitempty"`
+	Time       time.Time           `json:"time"`
+	Attempts   int                 `json:"attempts,omitempty"`
+}
+
+// MarshalJSON encodes the dead letter with its body as JSON, or base64 if the body is not JSON
+func (l DeadLetter) MarshalJSON() ([]byte, error) {
+	j := deadLetterJSON{
+		Action:     l.Action,
+		Index:      l.Index,
+		DocumentID: l.DocumentID,
+		Status:     l.Status,
+		Error:      l.Error,
+		Time:       l.Time,
+		Attempts:   l.Attempts,
+	}
+
+	if json.Valid(l.Body) {
+		j.Body = l.Body
+	} else {
+		j.BodyBase64 = l.Body
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes a dead letter encoded by MarshalJSON
+func (l *DeadLetter) UnmarshalJSON(data []byte) error {
+	var j deadLetterJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*l = DeadLetter{
+		Action:     j.Action,
+		Index:      j.Index,
+		DocumentID: j.DocumentID,
+		Body:       j.BodyBase64,
+		Status:     j.Status,
+		Error:      j.Error,
+		Time:       j.Time,
+		Attempts:   j.Attempts,
+	}
+	if len(j.Body) > 0 {
+		l.Body = j.Body
+	}
+
+	return nil
+}
+
+// DeadLetterSink receives the items of a bulk indexer that fail, so that they are not lost. It must be safe for
+// concurrent use.
+type DeadLetterSink interface {
+	Send(ctx context.Context, letter DeadLetter) error
+}
+
+// DeadLetterSinkFunc is a function that is a DeadLetterSink
+type DeadLetterSinkFunc func(ctx context.Context, letter DeadLetter) error
+
+// Send calls f
+func (f DeadLetterSinkFunc) Send(ctx context.Context, letter DeadLetter) error {
+	return f(ctx, letter)
+}
+
+// FileDeadLetterSink writes dead letters to a file as newline delimited JSON, one dead letter per line, which can
+// be read with ReadDeadLetters and re-submitted with ReplayDeadLetters
+type FileDeadLetterSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileDeadLetterSink opens the file at path for dead letters, creating it if it does not exist. Dead letters
+// are appended to any already in the file.
+func NewFileDeadLetterSink(path string) (*FileDeadLetterSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead letter file: %w", err)
+	}
+
+	return &FileDeadLetterSink{file: file}, nil
+}
+
+// Send appends the dead letter to the file as a single line
+func (s *FileDeadLetterSink) Send(_ context.Context, letter DeadLetter) error {
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the file. It must not be called until every bulk indexer sending to the sink has been closed.
+func (s *FileDeadLetterSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// ReadDeadLetters returns an iterator over the dead letters in r, as written by FileDeadLetterSink. Blank lines
+// are skipped. The loop ends after yielding an error for a line that cannot be read or decoded.
+func ReadDeadLetters(r io.Reader) iter.Seq2[DeadLetter, error] {
+	return func(yield func(DeadLetter, error) bool) {
+		reader := bufio.NewReader(r)
+		for lineNumber := 1; ; lineNumber++ {
+			line, err := reader.ReadBytes('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				yield(DeadLetter{}, fmt.Errorf("failed to read dead letter on line %d: %w", lineNumber, err))
+				return
+			}
+
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				var letter DeadLetter
+				if decodeErr := json.Unmarshal(line, &letter); decodeErr != nil {
+					yield(DeadLetter{}, fmt.Errorf("failed to decode dead letter on line %d: %w", lineNumber, decodeErr))
+					return
+				}
+				if !yield(letter, nil) {
+					return
+				}
+			}
+
+			if errors.Is(err, io.EOF) {
+				return
+			}
+		}
+	}
+}
+
+// ReplayDeadLetters adds every dead letter read from r to indexer, with the same action, index, ID and body it
+// failed with, and returns the number added. The results of the items are passed to onSuccess and onFailure,
+// either of which may be nil, and the indexer must be closed by the caller to flush them. Items that fail again
+// are sent to the dead letter sink of the indexer, if it has one, so it should not write to the file being read.
+func ReplayDeadLetters(ctx context.Context, indexer BulkIndexer, r io.Reader, onSuccess SuccessFunc, onFailure FailureFunc) (int, error) {
+	replayed := 0
+	for letter, err := range ReadDeadLetters(r) {
+		if err != nil {
+			return replayed, esError.StatusError{Err: err}
+		}
+
+		if err := indexer.Add(ctx, letter.Action, letter.Index, letter.DocumentID, letter.Body, onSuccess, onFailure); err != nil {
+			return replayed, esError.StatusError{
+				Err:  fmt.Errorf("failed to replay %s of document %s: %w", letter.Action, letter.DocumentID, err),
+				Code: esError.ErrorStatus(err),
+			}
+		}
+		replayed++
+	}
+
+	return replayed, nil
+}
@@ -0,0 +1,134 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/fake"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDeadLetterJSON(t *testing.T) {
+	Convey("Given a dead letter with a JSON body", t, func() {
+		letter := client.DeadLetter{Action: "index", Index: "my-index", DocumentID: "1", Body: []byte(`{"n":1}`), Status: 400}
+
+		Convey("When it is encoded and decoded", func() {
+			line, err := json.Marshal(letter)
+			So(err, ShouldBeNil)
+			var decoded client.DeadLetter
+			So(json.Unmarshal(line, &decoded), ShouldBeNil)
+
+			Convey("Then the body is written as JSON and read back unchanged", func() {
+				So(string(line), ShouldContainSubstring, `"body":{"n":1}`)
+				So(decoded, ShouldResemble, letter)
+			})
+		})
+	})
+
+	Convey("Given a dead letter with a body that is not JSON", t, func() {
+		letter := client.DeadLetter{Action: "index", Index: "my-index", Body: []byte(`not json`)}
+
+		Convey("When it is encoded and decoded", func() {
+			line, err := json.Marshal(letter)
+			So(err, ShouldBeNil)
+			var decoded client.DeadLetter
+			So(json.Unmarshal(line, &decoded), ShouldBeNil)
+
+			Convey("Then the body is base64 encoded and read back unchanged", func() {
+				So(string(line), ShouldContainSubstring, `"body_base64":"bm90IGpzb24="`)
+				So(string(decoded.Body), ShouldEqual, "not json")
+			})
+		})
+	})
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a bulk indexer that writes failed items to a dead letter file", t, func() {
+		path := filepath.Join(t.TempDir(), "dead-letters.ndjson")
+		sink, err := client.NewFileDeadLetterSink(path)
+		So(err, ShouldBeNil)
+
+		esClient := fake.NewClient()
+		indexer, err := esClient.OpenBulkIndexer(ctx, "loader", &client.BulkIndexerConfig{DeadLetter: sink})
+		So(err, ShouldBeNil)
+		So(indexer.Add(ctx, "create", "docs", "1", []byte(`{"n":1}`), nil, nil), ShouldBeNil)
+		So(indexer.Add(ctx, "update", "docs", "2", []byte(`{"doc":{"n":2}}`), nil, nil), ShouldBeNil)
+		So(indexer.Close(ctx), ShouldBeNil)
+		So(sink.Close(), ShouldBeNil)
+
+		Convey("Then only the failed item is in the file", func() {
+			contents, err := os.ReadFile(path)
+			So(err, ShouldBeNil)
+			So(strings.Count(string(contents), "\n"), ShouldEqual, 1)
+			So(string(contents), ShouldContainSubstring, `"document_missing_exception"`)
+		})
+
+		Convey("When the missing document is created and the file replayed", func() {
+			_, err := esClient.AddDocument(ctx, "docs", "2", []byte(`{"n":0}`), nil)
+			So(err, ShouldBeNil)
+
+			file, err := os.Open(path)
+			So(err, ShouldBeNil)
+			defer file.Close()
+
+			replay, err := esClient.OpenBulkIndexer(ctx, "replay", nil)
+			So(err, ShouldBeNil)
+			replayed, err := client.ReplayDeadLetters(ctx, replay, file, nil, nil)
+			So(err, ShouldBeNil)
+			So(replay.Close(ctx), ShouldBeNil)
+
+			Convey("Then the item is re-submitted and succeeds", func() {
+				So(replayed, ShouldEqual, 1)
+				So(replay.Stats().NumUpdated, ShouldEqual, 1)
+				So(string(esClient.Documents("docs")["2"]), ShouldEqual, `{"n":2}`)
+			})
+		})
+	})
+
+	Convey("Given a dead letter file with a line that is not a dead letter", t, func() {
+		input := strings.NewReader(`{"action":"index","index":"docs","id":"1","body":{}}` + "\n\nnot json\n")
+		indexer, err := fake.NewClient().OpenBulkIndexer(ctx, "replay", nil)
+		So(err, ShouldBeNil)
+
+		Convey("When it is replayed", func() {
+			replayed, err := client.ReplayDeadLetters(ctx, indexer, input, nil, nil)
+
+			Convey("Then the lines before it are replayed and a StatusError is returned naming the line", func() {
+				So(replayed, ShouldEqual, 1)
+				var statusErr esError.StatusError
+				So(errors.As(err, &statusErr), ShouldBeTrue)
+				So(err.Error(), ShouldContainSubstring, "line 3")
+			})
+		})
+	})
+
+	Convey("Given a dead letter file and a bulk indexer that has been closed", t, func() {
+		input := strings.NewReader(`{"action":"index","index":"docs","id":"1","body":{}}` + "\n")
+		indexer, err := fake.NewClient().OpenBulkIndexer(ctx, "replay", nil)
+		So(err, ShouldBeNil)
+		So(indexer.Close(ctx), ShouldBeNil)
+
+		Convey("When it is replayed", func() {
+			replayed, err := client.ReplayDeadLetters(ctx, indexer, input, nil, nil)
+
+			Convey("Then a StatusError is returned naming the item and wrapping the error of the add", func() {
+				So(replayed, ShouldEqual, 0)
+				var statusErr esError.StatusError
+				So(errors.As(err, &statusErr), ShouldBeTrue)
+				So(statusErr.Status(), ShouldEqual, http.StatusInternalServerError)
+				So(errors.Is(err, client.ErrBulkIndexerClosed), ShouldBeTrue)
+				So(err.Error(), ShouldContainSubstring, "failed to replay index of document 1")
+			})
+		})
+	})
+}
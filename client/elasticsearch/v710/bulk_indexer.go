@@ -1,18 +1,15 @@
 package v710
 
 import (
-	"bytes"
 	"context"
 	"errors"
-	"time"
 
-	"github.com/ONSdigital/dp-elasticsearch/v4/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
 	es710 "github.com/elastic/go-elasticsearch/v7"
 	"github.com/elastic/go-elasticsearch/v7/esutil"
 )
 
-const numWorkers = 5
-
 const (
 	Create = client.BulkIndexerAction("create")
 	Delete = client.BulkIndexerAction("delete")
@@ -20,53 +17,51 @@ const (
 	Update = client.BulkIndexerAction("update")
 )
 
+// bulkIndexer adds items to a bulk indexer of the client library. It is wrapped by clientutil.WrapBulkIndexer,
+// which retries items, sends them to the dead letter sink and reports progress.
 type bulkIndexer struct {
 	bi esutil.BulkIndexer
 }
 
 // NewBulkIndexer creates a new bulk indexer.
-func newBulkIndexer(es *es710.Client) (*bulkIndexer, error) {
+func newBulkIndexer(es *es710.Client, cfg *client.BulkIndexerConfig) (*bulkIndexer, error) {
 	if es == nil {
 		return nil, errors.New("elastic client should not be nil")
 	}
 
+	c := cfg.WithDefaults()
 	bi, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
 		Client:        es,
-		FlushInterval: 30 * time.Second,
-		NumWorkers:    numWorkers,
+		NumWorkers:    c.NumWorkers,
+		FlushBytes:    c.FlushBytes,
+		FlushInterval: c.FlushInterval,
+		Index:         c.Index,
+		Pipeline:      c.Pipeline,
+		Refresh:       string(c.Refresh),
+		Timeout:       c.Timeout,
+		OnError:       c.OnError,
+		OnFlushStart:  c.OnFlushStart,
+		OnFlushEnd:    c.OnFlushEnd,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &bulkIndexer{
-		bi: bi,
-	}, nil
+	return &bulkIndexer{bi: bi}, nil
 }
 
-// Add adds an item to the indexer. It returns an error when the item cannot be added.
-// Use the OnSuccess and OnFailure callbacks to get the operation result for the item.
+// Add adds an item to the indexer, waiting until its queue has room for it. It returns an error when the item cannot
+// be added. The callbacks of the item get its result.
 //
 // You must call the Close() method after you're done adding items.
-//
-// It is safe for concurrent use. When it's called from goroutines,
-// they must finish before the call to Close, eg. using sync.WaitGroup.
-func (b *bulkIndexer) Add(
-	ctx context.Context,
-	action client.BulkIndexerAction,
-	index,
-	documentID string,
-	document []byte,
-	onSuccess client.SuccessFunc,
-	onFailure client.FailureFunc,
-) error {
+func (b *bulkIndexer) Add(ctx context.Context, item clientutil.Item) error {
 	bulkIndexerItem := esutil.BulkIndexerItem{
-		Action:     string(action),
-		Body:       bytes.NewReader(document),
-		DocumentID: documentID,
-		Index:      index,
-		OnSuccess:  onSuccess,
-		OnFailure:  onFailure,
+		Action:     string(item.Action),
+		Body:       item.Body,
+		DocumentID: item.DocumentID,
+		Index:      item.Index,
+		OnSuccess:  item.OnSuccess,
+		OnFailure:  item.OnFailure,
 	}
 
 	return b.bi.Add(ctx, bulkIndexerItem)
@@ -76,3 +71,19 @@ func (b *bulkIndexer) Add(
 func (b *bulkIndexer) Close(ctx context.Context) error {
 	return b.bi.Close(ctx)
 }
+
+// Stats returns the counts of items handled by the indexer since it was created.
+func (b *bulkIndexer) Stats() client.BulkIndexerStats {
+	stats := b.bi.Stats()
+
+	return client.BulkIndexerStats{
+		NumAdded:    stats.NumAdded,
+		NumFlushed:  stats.NumFlushed,
+		NumFailed:   stats.NumFailed,
+		NumIndexed:  stats.NumIndexed,
+		NumCreated:  stats.NumCreated,
+		NumUpdated:  stats.NumUpdated,
+		NumDeleted:  stats.NumDeleted,
+		NumRequests: stats.NumRequests,
+	}
+}
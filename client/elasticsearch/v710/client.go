@@ -9,10 +9,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
+	"time"
 
-	"github.com/ONSdigital/dp-elasticsearch/v4/client"
-	esError "github.com/ONSdigital/dp-elasticsearch/v4/errors"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
 	es710 "github.com/elastic/go-elasticsearch/v7"
 	"github.com/elastic/go-elasticsearch/v7/esapi"
 )
@@ -22,28 +23,63 @@ const (
 )
 
 type ESClient struct {
-	bulkIndexer *bulkIndexer
-	esClient    *es710.Client
-	indexes     []string
+	bulkIndexers  clientutil.BulkIndexerRegistry
+	esClient      *es710.Client
+	noRetryClient *es710.Client
+	retryPolicy   client.RetryPolicy
+	indexes       []string
+	address       string
 }
 
 // NewESClient returns a new elastic search client version 7.10
 func NewESClient(esURL string, transport http.RoundTripper) (*ESClient, error) {
-	parsedURL, err := url.ParseRequestURI(esURL)
+	return NewESClientWithConfig(client.Config{
+		Address:   esURL,
+		Transport: transport,
+	})
+}
+
+// NewESClientWithConfig returns a new elastic search client version 7.10, retrying failed requests
+// according to the retry settings in cfg. The Checker reports the cluster as critical if any of
+// cfg.Indexes do not exist.
+func NewESClientWithConfig(cfg client.Config) (*ESClient, error) {
+	parsedURL, err := url.ParseRequestURI(cfg.Address)
 	if err != nil {
 		return nil, errors.New("failed to specify valid elasticsearch url")
 	}
 
+	retryPolicy := client.NewRetryPolicy(cfg)
+
+	// The 7.10 transport counts the first attempt as one of its MaxRetries
 	newESClient, err := es710.NewClient(es710.Config{
-		Addresses: []string{parsedURL.String()},
-		Transport: transport,
+		Addresses:     []string{parsedURL.String()},
+		Transport:     cfg.Transport,
+		MaxRetries:    retryPolicy.MaxRetries + 1,
+		DisableRetry:  retryPolicy.Disabled(),
+		RetryOnStatus: retryPolicy.RetryOnStatus,
+		RetryBackoff:  retryPolicy.Backoff,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests which are not safe to replay are sent without the transport retries, see doWithoutReplay. Its
+	// transport records why bulk requests fail as a whole, which the bulk indexers report for their items.
+	noRetryClient, err := es710.NewClient(es710.Config{
+		Addresses:    []string{parsedURL.String()},
+		Transport:    clientutil.NewFlushTransport(cfg.Transport, "elasticsearch"),
+		DisableRetry: true,
 	})
 	if err != nil {
 		return nil, err
 	}
 
 	return &ESClient{
-		esClient: newESClient,
+		esClient:      newESClient,
+		noRetryClient: noRetryClient,
+		retryPolicy:   retryPolicy,
+		indexes:       cfg.Indexes,
+		address:       parsedURL.String(),
 	}, nil
 }
 
@@ -217,53 +253,118 @@ func (cli *ESClient) CountIndices(_ context.Context, indices []string) ([]byte,
 	return data, nil
 }
 
-// AddDocument adds a document to the index specified. Upsert option not implemented.
-// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-update.html.
-func (cli *ESClient) AddDocument(ctx context.Context, indexName, documentID string, document []byte, options *client.AddDocumentOptions) error {
-	req := esapi.CreateRequest{
-		Index:      indexName,
-		DocumentID: documentID,
-		Body:       bytes.NewReader(document),
+// AddDocument adds a document to the index specified. By default the document is created, failing if it
+// already exists. With the Upsert option any existing document is replaced, or with UpsertMerge the document
+// is merged into the existing document.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-index_.html.
+func (cli *ESClient) AddDocument(ctx context.Context, indexName, documentID string, document []byte, options *client.AddDocumentOptions) (*client.WriteResult, error) {
+	if options == nil {
+		options = &client.AddDocumentOptions{}
 	}
 
-	if options != nil {
-		if options.DocumentType != "" {
-			req.DocumentType = options.DocumentType
+	var res *esapi.Response
+	var err error
+	switch {
+	case options.Upsert && options.UpsertMode == client.UpsertMerge:
+		if options.Pipeline != "" {
+			return nil, esError.StatusError{
+				Err: errors.New("pipeline option cannot be used with a merge upsert"),
+			}
+		}
+
+		return cli.UpdateDocument(ctx, indexName, documentID, client.DocumentUpdate{Doc: document, DocAsUpsert: true}, &client.UpdateDocumentOptions{
+			Refresh:            options.Refresh,
+			Routing:            options.Routing,
+			ConcurrencyControl: options.ConcurrencyControl,
+		})
+	case options.Upsert:
+		req := esapi.IndexRequest{
+			Index:         indexName,
+			DocumentID:    documentID,
+			DocumentType:  options.DocumentType,
+			OpType:        "index",
+			Refresh:       string(options.Refresh),
+			Routing:       options.Routing,
+			Pipeline:      options.Pipeline,
+			IfSeqNo:       intPtr(options.IfSeqNo),
+			IfPrimaryTerm: intPtr(options.IfPrimaryTerm),
+			Version:       intPtr(options.Version),
+			VersionType:   options.VersionTypeOrDefault(),
+		}
+		newRequest := func() esapi.Request {
+			req.Body = bytes.NewReader(document)
+			return req
 		}
 
-		if options.Upsert {
-			return esError.StatusError{
-				Err: errors.New("es710 client currently cannot handle upsert option when creating a document"),
+		if options.ConcurrencyControl.IsSet() {
+			res, err = cli.doWithoutReplay(ctx, newRequest)
+		} else {
+			res, err = newRequest().Do(ctx, cli.esClient)
+		}
+	default:
+		if options.IfSeqNo != nil || options.IfPrimaryTerm != nil {
+			return nil, esError.StatusError{
+				Err: errors.New("if_seq_no and if_primary_term cannot be used when creating a document, use the upsert option"),
 			}
 		}
-	}
 
-	res, err := req.Do(ctx, cli.esClient)
+		req := esapi.CreateRequest{
+			Index:        indexName,
+			DocumentID:   documentID,
+			DocumentType: options.DocumentType,
+			Refresh:      string(options.Refresh),
+			Routing:      options.Routing,
+			Pipeline:     options.Pipeline,
+			Version:      intPtr(options.Version),
+			VersionType:  options.VersionTypeOrDefault(),
+		}
+		res, err = cli.doWithoutReplay(ctx, func() esapi.Request {
+			req.Body = bytes.NewReader(document)
+			return req
+		})
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer res.Body.Close()
 
 	if err := checkForError(res); err != nil {
-		return esError.StatusError{
+		return nil, esError.StatusError{
 			Err:  fmt.Errorf("error occured while trying to add document: %w", err),
 			Code: getStatusCode(res),
 		}
 	}
 
-	return nil
+	return decodeWriteResult(res)
 }
 
 // DeleteDocument deletes a document from the given index using the document ID (e.g. URI).
-func (cli *ESClient) DeleteDocument(ctx context.Context, indexName, documentID string) error {
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-delete.html.
+func (cli *ESClient) DeleteDocument(ctx context.Context, indexName, documentID string, options *client.DeleteDocumentOptions) (*client.WriteResult, error) {
+	if options == nil {
+		options = &client.DeleteDocumentOptions{}
+	}
+
 	req := esapi.DeleteRequest{
-		Index:      indexName,
-		DocumentID: documentID,
+		Index:         indexName,
+		DocumentID:    documentID,
+		Refresh:       string(options.Refresh),
+		Routing:       options.Routing,
+		IfSeqNo:       intPtr(options.IfSeqNo),
+		IfPrimaryTerm: intPtr(options.IfPrimaryTerm),
+		Version:       intPtr(options.Version),
+		VersionType:   options.VersionTypeOrDefault(),
 	}
 
-	res, err := req.Do(ctx, cli.esClient)
+	var res *esapi.Response
+	var err error
+	if options.ConcurrencyControl.IsSet() {
+		res, err = cli.doWithoutReplay(ctx, func() esapi.Request { return req })
+	} else {
+		res, err = req.Do(ctx, cli.esClient)
+	}
 	if err != nil {
-		return esError.StatusError{
+		return nil, esError.StatusError{
 			Err:  fmt.Errorf("failed to send delete request: %w", err),
 			Code: getStatusCode(res),
 		}
@@ -271,13 +372,237 @@ func (cli *ESClient) DeleteDocument(ctx context.Context, indexName, documentID s
 	defer res.Body.Close()
 
 	if err := checkForError(res); err != nil {
-		return esError.StatusError{
+		return nil, esError.StatusError{
 			Err:  fmt.Errorf("delete request failed: %w", err),
 			Code: getStatusCode(res),
 		}
 	}
 
-	return nil
+	return decodeWriteResult(res)
+}
+
+// UpdateDocument updates a document, either merging update.Doc into it or running update.Script against it.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-update.html.
+func (cli *ESClient) UpdateDocument(ctx context.Context, indexName, documentID string, update client.DocumentUpdate, options *client.UpdateDocumentOptions) (*client.WriteResult, error) {
+	if options == nil {
+		options = &client.UpdateDocumentOptions{}
+	}
+
+	if options.Version != nil {
+		return nil, esError.StatusError{
+			Err: errors.New("external versioning cannot be used when updating a document, use if_seq_no and if_primary_term"),
+		}
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build update request: %w", err),
+		}
+	}
+
+	req := esapi.UpdateRequest{
+		Index:           indexName,
+		DocumentID:      documentID,
+		Refresh:         string(options.Refresh),
+		Routing:         options.Routing,
+		RetryOnConflict: options.RetryOnConflict,
+		IfSeqNo:         intPtr(options.IfSeqNo),
+		IfPrimaryTerm:   intPtr(options.IfPrimaryTerm),
+	}
+	newRequest := func() esapi.Request {
+		req.Body = bytes.NewReader(body)
+		return req
+	}
+
+	// A script may not be idempotent, such as one incrementing a counter, and a conditional update that was
+	// applied would fail with a conflict if replayed, so neither is replayed after an ambiguous failure
+	var res *esapi.Response
+	if update.Script != nil || options.IfSeqNo != nil || options.IfPrimaryTerm != nil {
+		res, err = cli.doWithoutReplay(ctx, newRequest)
+	} else {
+		res, err = newRequest().Do(ctx, cli.esClient)
+	}
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to update document: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return decodeWriteResult(res)
+}
+
+// UpdateByQuery updates every document in the given indices that matches a query, running the script of the update
+// against each one. With WaitForCompletion set to false the update runs as a task and only its ID is returned.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-update-by-query.html.
+func (cli *ESClient) UpdateByQuery(ctx context.Context, indices []string, update client.UpdateByQuery, options *client.UpdateByQueryOptions) (*client.BulkByScrollResponse, error) {
+	if options == nil {
+		options = &client.UpdateByQueryOptions{}
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build update by query request: %w", err),
+		}
+	}
+
+	req := esapi.UpdateByQueryRequest{
+		Index:             indices,
+		WaitForCompletion: options.WaitForCompletion,
+		RequestsPerSecond: options.RequestsPerSecond,
+		MaxDocs:           options.MaxDocs,
+		Routing:           options.Routing,
+	}
+	if options.ProceedOnConflicts {
+		req.Conflicts = "proceed"
+	}
+	if options.Slices != "" {
+		req.Slices = options.Slices
+	}
+	if options.Refresh {
+		req.Refresh = &options.Refresh
+	}
+
+	// Every matching document is updated again if the request is replayed, running the script twice, so it is not
+	// replayed after an ambiguous failure
+	res, err := cli.doWithoutReplay(ctx, func() esapi.Request {
+		req.Body = bytes.NewReader(body)
+		return req
+	})
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("update-by-query failed: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var result client.BulkByScrollResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode update by query response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &result, nil
+}
+
+// GetDocument gets a document by ID. If the document does not exist the result has Found set to false.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-get.html.
+func (cli *ESClient) GetDocument(ctx context.Context, indexName, documentID string, options *client.GetDocumentOptions) (*client.GetResult, error) {
+	if options == nil {
+		options = &client.GetDocumentOptions{}
+	}
+
+	res, err := esapi.GetRequest{
+		Index:          indexName,
+		DocumentID:     documentID,
+		Routing:        options.Routing,
+		Realtime:       options.Realtime,
+		SourceIncludes: options.SourceIncludes,
+		SourceExcludes: options.SourceExcludes,
+	}.Do(ctx, cli.esClient)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	// A missing document is a 404 with the found flag unset, whereas a missing index is a 404 error response
+	if res.IsError() {
+		if esErr := esError.NewESError("elasticsearch", res.StatusCode, data); res.StatusCode != http.StatusNotFound || esErr.Type != "" {
+			return nil, esError.StatusError{
+				Err:  fmt.Errorf("error occured while trying to get document: %w", esErr),
+				Code: getStatusCode(res),
+			}
+		}
+	}
+
+	var result client.GetResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode get response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &result, nil
+}
+
+// MultiGet gets several documents by ID, which may be in different indices, in a single request.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-multi-get.html.
+func (cli *ESClient) MultiGet(ctx context.Context, docs []client.MultiGetDocument, options *client.MultiGetOptions) (*client.MultiGetResponse, error) {
+	if options == nil {
+		options = &client.MultiGetOptions{}
+	}
+
+	body, err := json.Marshal(struct {
+		Docs []client.MultiGetDocument `json:"docs"`
+	}{Docs: docs})
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build multi get request: %w", err),
+		}
+	}
+
+	res, err := esapi.MgetRequest{
+		Body:           bytes.NewReader(body),
+		Realtime:       options.Realtime,
+		SourceIncludes: options.SourceIncludes,
+		SourceExcludes: options.SourceExcludes,
+	}.Do(ctx, cli.esClient)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to multi get documents: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var result client.MultiGetResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode multi get response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &result, nil
 }
 
 // DeleteDocumentByQuery deletes documents from the given index using the provided search query.
@@ -340,8 +665,11 @@ func (cli *ESClient) Explain(ctx context.Context, documentID string, search clie
 // See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/master/search-search.html.
 func (cli *ESClient) Search(ctx context.Context, search client.Search) ([]byte, error) {
 	req := esapi.SearchRequest{
-		Index: []string{search.Header.Index},
-		Body:  bytes.NewReader(search.Query),
+		Body: bytes.NewReader(search.Query),
+	}
+	// A search of a point in time must not name an index
+	if search.Header.Index != "" {
+		req.Index = []string{search.Header.Index}
 	}
 
 	res, err := req.Do(ctx, cli.esClient)
@@ -406,49 +734,77 @@ func (cli *ESClient) MultiSearch(ctx context.Context, searches []client.Search,
 	return data, nil
 }
 
-// UpdateAliases removes and adds an alias to indexes.
-func (cli *ESClient) UpdateAliases(_ context.Context, alias string, removeIndices, addIndices []string) error {
-	var actions []string
-
-	if len(removeIndices) > 0 {
-		removeAction := fmt.Sprintf(
-			`{"remove": {"indices": %q,"alias": %q}}`,
-			strings.Join(removeIndices, `","`),
-			alias)
-		actions = append(actions, removeAction)
-	}
+// UpdateAliases atomically removes an alias from removeIndices and adds it to addIndices.
+func (cli *ESClient) UpdateAliases(ctx context.Context, alias string, removeIndices, addIndices []string) error {
+	return cli.UpdateAliasActions(ctx, client.AliasActions(alias, removeIndices, addIndices))
+}
 
-	if len(addIndices) > 0 {
-		addAction := fmt.Sprintf(
-			`{"add": {"indices": %q,"alias": %q}}`,
-			strings.Join(addIndices, `","`),
-			alias)
-		actions = append(actions, addAction)
+// UpdateAliasActions performs the alias actions in a single atomic request, so that either all or none of them
+// are applied. See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/indices-aliases.html.
+func (cli *ESClient) UpdateAliasActions(ctx context.Context, actions []client.AliasAction) error {
+	update, err := clientutil.NewAliasActionsBody(actions)
+	if err != nil {
+		return esError.StatusError{
+			Err: fmt.Errorf("failed to build alias actions: %w", err),
+		}
 	}
 
-	update := fmt.Sprintf(
-		`{"actions": [%s]}`,
-		strings.Join(actions, ","))
-	res, err := cli.esClient.Indices.UpdateAliases(strings.NewReader(update))
+	res, err := cli.esClient.Indices.UpdateAliases(bytes.NewReader(update), cli.esClient.Indices.UpdateAliases.WithContext(ctx))
 	if err != nil {
 		return esError.StatusError{
 			Err:  err,
 			Code: getStatusCode(res),
 		}
 	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to update aliases: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var result client.UpdateAliasesResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("failed to decode update aliases response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	if err := result.Err(); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to update aliases: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
 
 	return nil
 }
 
 // BulkUpdate allows to perform multiple index/update/delete operations in a single request.
+// The esURL parameter is not used to send the request, which always goes to the address the client was created with,
+// and an error is returned if it is set to a different address.
 // See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-bulk.html.
 //
+// Deprecated: the payload must be assembled by hand and the raw response is returned even if items failed.
+// Use Bulk with actions added to a client.BulkRequestBuilder, which splits them across requests and returns the
+// result of every item, instead.
+//
 //nolint:revive // context of esURL is important here.
 func (cli *ESClient) BulkUpdate(ctx context.Context, indexName, esURL string, payload []byte) ([]byte, error) {
-	res, err := esapi.BulkRequest{
-		Index: indexName,
-		Body:  bytes.NewReader(payload),
-	}.Do(ctx, cli.esClient)
+	if err := clientutil.CheckAddress(cli.address, esURL); err != nil {
+		return nil, err
+	}
+
+	// The payload may hold create actions, so it is not replayed after an ambiguous failure
+	res, err := cli.doWithoutReplay(ctx, func() esapi.Request {
+		return esapi.BulkRequest{
+			Index: indexName,
+			Body:  bytes.NewReader(payload),
+		}
+	})
 	if err != nil {
 		return nil, esError.StatusError{
 			Err:  err,
@@ -475,59 +831,584 @@ func (cli *ESClient) BulkUpdate(ctx context.Context, indexName, esURL string, pa
 	return data, nil
 }
 
-// NewBulkIndexer creates a bulkIndexer for use of the client.
-func (cli *ESClient) NewBulkIndexer(_ context.Context) error {
-	bulkIndexer, err := newBulkIndexer(cli.esClient)
+// Bulk sends the actions added to bulk, in one or more requests as split by the builder, and returns the result of
+// every action. If any action failed the response is returned along with an error matching
+// client.ErrBulkItemsFailed, so failures cannot be missed. If a request fails the results of the requests already
+// sent are returned with its error.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-bulk.html.
+func (cli *ESClient) Bulk(ctx context.Context, bulk *client.BulkRequestBuilder, options *client.BulkOptions) (*client.BulkResponse, error) {
+	if options == nil {
+		options = &client.BulkOptions{}
+	}
+
+	return clientutil.SendBulkRequests(ctx, bulk, func(ctx context.Context, body []byte) ([]byte, error) {
+		// The actions may include creates, so a request is not replayed after an ambiguous failure
+		res, err := cli.doWithoutReplay(ctx, func() esapi.Request {
+			return esapi.BulkRequest{
+				Index:    options.Index,
+				Body:     bytes.NewReader(body),
+				Refresh:  string(options.Refresh),
+				Pipeline: options.Pipeline,
+				Timeout:  options.Timeout,
+			}
+		})
+		if err != nil {
+			return nil, esError.StatusError{
+				Err:  err,
+				Code: getStatusCode(res),
+			}
+		}
+		defer res.Body.Close()
+
+		if err := checkForError(res); err != nil {
+			return nil, esError.StatusError{
+				Err:  fmt.Errorf("error occured while trying to send bulk request: %w", err),
+				Code: getStatusCode(res),
+			}
+		}
+
+		data, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, esError.StatusError{
+				Err:  err,
+				Code: getStatusCode(res),
+			}
+		}
+
+		return data, nil
+	})
+}
+
+// ClusterHealth returns the health of the cluster, or of the given indices, optionally waiting for a status.
+// If the status is not reached before the timeout the health is returned with TimedOut set, rather than an error.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/cluster-health.html.
+func (cli *ESClient) ClusterHealth(ctx context.Context, indices []string, options *client.ClusterHealthOptions) (*client.ClusterHealthResponse, error) {
+	if options == nil {
+		options = &client.ClusterHealthOptions{}
+	}
+
+	res, err := esapi.ClusterHealthRequest{
+		Index:         indices,
+		WaitForStatus: string(options.WaitForStatus),
+		Timeout:       options.Timeout,
+	}.Do(ctx, cli.esClient)
 	if err != nil {
-		return esError.StatusError{
+		return nil, esError.StatusError{
 			Err:  err,
-			Code: http.StatusInternalServerError,
+			Code: getStatusCode(res),
 		}
 	}
+	defer res.Body.Close()
 
-	cli.bulkIndexer = bulkIndexer
-
-	return nil
-}
+	// A request timeout is how the cluster reports that the status was not reached
+	if res.StatusCode != http.StatusRequestTimeout {
+		if err := checkForError(res); err != nil {
+			return nil, esError.StatusError{
+				Err:  fmt.Errorf("error occured while trying to get cluster health: %w", err),
+				Code: getStatusCode(res),
+			}
+		}
+	}
 
-// BulkIndexAdd Add adds an item to the indexer. It returns an error when the item cannot be added.
-// Use the OnSuccess and OnFailure callbacks to get the operation result for the item.
-//
-// You must call the Close() method after you're done adding items.
-//
-// It is safe for concurrent use. When it's called from goroutines,
-// they must finish before the call to Close, eg. using sync.WaitGroup.
-func (cli *ESClient) BulkIndexAdd(
-	ctx context.Context,
-	action client.BulkIndexerAction,
-	index,
-	documentID string,
-	document []byte,
-	onSuccess client.SuccessFunc,
-	onFailure client.FailureFunc,
-) error {
-	if cli.bulkIndexer == nil {
-		return esError.StatusError{
-			Err:  errors.New(bulkIndexerClientShouldNotBeNilErrMsg),
-			Code: http.StatusInternalServerError,
+	var health client.ClusterHealthResponse
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode cluster health response: %w", err),
+			Code: getStatusCode(res),
 		}
 	}
 
-	return cli.bulkIndexer.Add(ctx, action, index, documentID, document, onSuccess, onFailure)
+	return &health, nil
 }
 
-// Close waits until all added items are flushed and closes the indexer.
-func (cli *ESClient) BulkIndexClose(ctx context.Context) error {
-	if cli.bulkIndexer == nil {
+// RefreshIndices refreshes the given indices, making every change to them visible to search.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/indices-refresh.html.
+func (cli *ESClient) RefreshIndices(ctx context.Context, indices []string) error {
+	res, err := esapi.IndicesRefreshRequest{Index: indices}.Do(ctx, cli.esClient)
+	if err != nil {
 		return esError.StatusError{
-			Err:  errors.New(bulkIndexerClientShouldNotBeNilErrMsg),
-			Code: http.StatusInternalServerError,
+			Err:  err,
+			Code: getStatusCode(res),
 		}
 	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to refresh indices: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return nil
+}
+
+// Reindex copies documents from the source of reindex into its destination index on the cluster, which is much
+// faster than reading them through Search and writing them back with the bulk indexer. With WaitForCompletion set
+// to false the reindex runs as a task and only its ID is returned.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-reindex.html.
+func (cli *ESClient) Reindex(ctx context.Context, reindex client.Reindex, options *client.ReindexOptions) (*client.BulkByScrollResponse, error) {
+	if options == nil {
+		options = &client.ReindexOptions{}
+	}
+
+	body, err := json.Marshal(reindex)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build reindex request: %w", err),
+		}
+	}
+
+	req := esapi.ReindexRequest{
+		WaitForCompletion: options.WaitForCompletion,
+		RequestsPerSecond: options.RequestsPerSecond,
+		MaxDocs:           options.MaxDocs,
+	}
+	if options.Slices != "" {
+		req.Slices = options.Slices
+	}
+	if options.Refresh {
+		req.Refresh = &options.Refresh
+	}
+
+	// A replayed reindex would run alongside the first into the same destination, such as when a proxy times out
+	// a long synchronous reindex with a 504, so it is not replayed after an ambiguous failure
+	res, err := cli.doWithoutReplay(ctx, func() esapi.Request {
+		req.Body = bytes.NewReader(body)
+		return req
+	})
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to reindex: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var result client.BulkByScrollResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode reindex response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &result, nil
+}
+
+// GetTask returns the status of a task and, once it has completed, its result.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/tasks.html.
+func (cli *ESClient) GetTask(ctx context.Context, taskID string, options *client.GetTaskOptions) (*client.TaskResponse, error) {
+	if options == nil {
+		options = &client.GetTaskOptions{}
+	}
+
+	req := esapi.TasksGetRequest{
+		TaskID:  taskID,
+		Timeout: options.Timeout,
+	}
+	if options.WaitForCompletion {
+		req.WaitForCompletion = &options.WaitForCompletion
+	}
+
+	res, err := req.Do(ctx, cli.esClient)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to get task: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var task client.TaskResponse
+	if err := json.NewDecoder(res.Body).Decode(&task); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode get task response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &task, nil
+}
+
+// ListTasks lists the tasks running on the cluster.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/tasks.html.
+func (cli *ESClient) ListTasks(ctx context.Context, options *client.ListTasksOptions) (*client.TasksResponse, error) {
+	if options == nil {
+		options = &client.ListTasksOptions{}
+	}
+
+	req := esapi.TasksListRequest{
+		Actions:      options.Actions,
+		ParentTaskID: options.ParentTaskID,
+		GroupBy:      "none",
+	}
+	if options.Detailed {
+		req.Detailed = &options.Detailed
+	}
+
+	res, err := req.Do(ctx, cli.esClient)
+	return cli.decodeTasksResponse(res, err, "list tasks")
+}
+
+// CancelTask cancels a task. Cancellation is asynchronous: the task may still be running when this returns.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/tasks.html.
+func (cli *ESClient) CancelTask(ctx context.Context, taskID string) (*client.TasksResponse, error) {
+	res, err := esapi.TasksCancelRequest{TaskID: taskID}.Do(ctx, cli.esClient)
+	return cli.decodeTasksResponse(res, err, "cancel task")
+}
+
+// RethrottleTask changes the requests per second of a running reindex, update by query or delete by query task.
+// A value of -1 removes the throttling. Speeding a task up takes effect straight away, while slowing it down takes
+// effect after the current batch completes.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/docs-reindex.html#docs-reindex-rethrottle.
+func (cli *ESClient) RethrottleTask(ctx context.Context, taskID string, requestsPerSecond int) (*client.TasksResponse, error) {
+	// The reindex, update by query and delete by query rethrottle endpoints share one action, which rethrottles
+	// any of the three types of task
+	res, err := esapi.ReindexRethrottleRequest{
+		TaskID:            taskID,
+		RequestsPerSecond: &requestsPerSecond,
+	}.Do(ctx, cli.esClient)
+	return cli.decodeTasksResponse(res, err, "rethrottle task")
+}
+
+// decodeTasksResponse checks and decodes the response to a tasks request, returning any node or task failures
+// as an error
+func (cli *ESClient) decodeTasksResponse(res *esapi.Response, err error, operation string) (*client.TasksResponse, error) {
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to %s: %w", operation, err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var tasks client.TasksResponse
+	if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode %s response: %w", operation, err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	if err := tasks.Err(); err != nil {
+		return &tasks, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to %s: %w", operation, err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &tasks, nil
+}
+
+// Scroll runs a search, keeping a scroll context alive for keepAlive so that the following pages of hits can be
+// read with ScrollNext. The response holds the first page of hits and the ID of the scroll, which must be cleared
+// with ClearScroll once it is no longer needed.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/paginate-search-results.html#scroll-search-results.
+func (cli *ESClient) Scroll(ctx context.Context, search client.Search, keepAlive time.Duration) ([]byte, error) {
+	res, err := esapi.SearchRequest{
+		Index:  []string{search.Header.Index},
+		Body:   bytes.NewReader(search.Query),
+		Scroll: keepAlive,
+	}.Do(ctx, cli.esClient)
+	return cli.readScrollResponse(res, err, "open scroll")
+}
+
+// ScrollNext returns the next page of hits of a scroll, extending its keepalive by keepAlive. The page is empty
+// once every hit has been returned.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/paginate-search-results.html#scroll-search-results.
+func (cli *ESClient) ScrollNext(ctx context.Context, scrollID string, keepAlive time.Duration) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"scroll_id": scrollID})
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build scroll request: %w", err),
+		}
+	}
+
+	res, err := esapi.ScrollRequest{
+		Body:   bytes.NewReader(body),
+		Scroll: keepAlive,
+	}.Do(ctx, cli.esClient)
+	return cli.readScrollResponse(res, err, "scroll")
+}
+
+// ClearScroll clears scroll contexts, freeing the resources held by them on the cluster. Scrolls that have
+// already expired are ignored.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/clear-scroll-api.html.
+func (cli *ESClient) ClearScroll(ctx context.Context, scrollIDs []string) error {
+	body, err := json.Marshal(map[string][]string{"scroll_id": scrollIDs})
+	if err != nil {
+		return esError.StatusError{
+			Err: fmt.Errorf("failed to build clear scroll request: %w", err),
+		}
+	}
+
+	res, err := esapi.ClearScrollRequest{Body: bytes.NewReader(body)}.Do(ctx, cli.esClient)
+	if err != nil {
+		return esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	// Not found is returned when none of the scrolls were found, because they have already expired
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to clear scroll: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return nil
+}
+
+// readScrollResponse checks and reads the response to a scroll request
+func (cli *ESClient) readScrollResponse(res *esapi.Response, err error, operation string) ([]byte, error) {
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to %s: %w", operation, err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return data, nil
+}
+
+// OpenPointInTime opens a point in time on the given indices, keeping it alive for keepAlive, and returns its ID.
+// Searches using the point in time see the indices as they were when it was opened. It must be closed with
+// ClosePointInTime once it is no longer needed.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/point-in-time-api.html.
+func (cli *ESClient) OpenPointInTime(ctx context.Context, indices []string, keepAlive time.Duration) (string, error) {
+	res, err := esapi.OpenPointInTimeRequest{
+		Index:     indices,
+		KeepAlive: fmt.Sprintf("%dms", keepAlive.Milliseconds()),
+	}.Do(ctx, cli.esClient)
+	if err != nil {
+		return "", esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return "", esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to open point in time: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var pit struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&pit); err != nil {
+		return "", esError.StatusError{
+			Err:  fmt.Errorf("failed to decode open point in time response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return pit.ID, nil
+}
+
+// ClosePointInTime closes a point in time, freeing the resources held by it on the cluster. A point in time that
+// has already expired is ignored.
+// See full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/7.10/point-in-time-api.html.
+func (cli *ESClient) ClosePointInTime(ctx context.Context, pitID string) error {
+	body, err := json.Marshal(map[string]string{"id": pitID})
+	if err != nil {
+		return esError.StatusError{
+			Err: fmt.Errorf("failed to build close point in time request: %w", err),
+		}
+	}
+
+	res, err := esapi.ClosePointInTimeRequest{Body: bytes.NewReader(body)}.Do(ctx, cli.esClient)
+	if err != nil {
+		return esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	// Not found is returned when the point in time has already expired
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to close point in time: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return nil
+}
+
+// NewBulkIndexer creates the default bulk indexer of the client, used by BulkIndexAdd, BulkIndexClose and
+// BulkIndexStats, configured by cfg. A nil cfg uses the defaults. An error is returned if the default bulk
+// indexer is still open.
+func (cli *ESClient) NewBulkIndexer(ctx context.Context, cfg *client.BulkIndexerConfig) error {
+	_, err := cli.openBulkIndexer(ctx, "", cfg)
+	return err
+}
+
+// OpenBulkIndexer creates a bulk indexer configured by cfg and registers it under name, returning it. A nil cfg
+// uses the defaults. Each indexer opened is independent, so that separate indexers can be run at once, for
+// example one per target index with different settings. An error is returned if name is empty, as that is the
+// default bulk indexer created by NewBulkIndexer, or if the indexer registered under name is still open.
+func (cli *ESClient) OpenBulkIndexer(ctx context.Context, name string, cfg *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+	if name == "" {
+		return nil, esError.StatusError{
+			Err: errors.New("bulk indexer name should not be empty"),
+		}
+	}
+
+	return cli.openBulkIndexer(ctx, name, cfg)
+}
+
+func (cli *ESClient) openBulkIndexer(ctx context.Context, name string, cfg *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+	return cli.bulkIndexers.Open(name, func() (client.BulkIndexer, error) {
+		bulkIndexer, err := clientutil.WrapBulkIndexer(ctx, cfg, func(cfg client.BulkIndexerConfig) (clientutil.Indexer, error) {
+			// A replayed request would apply its items twice, reporting creates that succeeded as conflicts, so the
+			// requests are not retried by the transport. Items rejected with a 429 or 503 are retried by the wrapper.
+			bulkIndexer, err := newBulkIndexer(cli.noRetryClient, &cfg)
+			if err != nil {
+				return nil, err
+			}
+
+			return bulkIndexer, nil
+		})
+		if err != nil {
+			return nil, esError.StatusError{
+				Err:  err,
+				Code: http.StatusInternalServerError,
+			}
+		}
+
+		return bulkIndexer, nil
+	})
+}
+
+// defaultBulkIndexer returns the bulk indexer created by NewBulkIndexer
+func (cli *ESClient) defaultBulkIndexer() (client.BulkIndexer, error) {
+	bulkIndexer, ok := cli.bulkIndexers.Get("")
+	if !ok {
+		return nil, esError.StatusError{
+			Err:  errors.New(bulkIndexerClientShouldNotBeNilErrMsg),
+			Code: http.StatusInternalServerError,
+		}
+	}
+
+	return bulkIndexer, nil
+}
+
+// BulkIndexAdd Add adds an item to the default indexer. It returns an error when the item cannot be added.
+// Use the OnSuccess and OnFailure callbacks to get the operation result for the item.
+//
+// You must call the Close() method after you're done adding items.
+//
+// It is safe for concurrent use. When it's called from goroutines,
+// they must finish before the call to Close, eg. using sync.WaitGroup.
+func (cli *ESClient) BulkIndexAdd(
+	ctx context.Context,
+	action client.BulkIndexerAction,
+	index,
+	documentID string,
+	document []byte,
+	onSuccess client.SuccessFunc,
+	onFailure client.FailureFunc,
+) error {
+	bulkIndexer, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return err
+	}
+
+	return bulkIndexer.Add(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+// BulkIndexTryAdd adds an item to the default indexer as BulkIndexAdd does, except that it returns an error
+// matching client.ErrBulkIndexerFull straight away, rather than waiting, when the buffer of the indexer is full.
+func (cli *ESClient) BulkIndexTryAdd(
+	ctx context.Context,
+	action client.BulkIndexerAction,
+	index,
+	documentID string,
+	document []byte,
+	onSuccess client.SuccessFunc,
+	onFailure client.FailureFunc,
+) error {
+	bulkIndexer, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return err
+	}
+
+	return bulkIndexer.TryAdd(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+// Close waits until all added items are flushed and closes the default indexer.
+func (cli *ESClient) BulkIndexClose(ctx context.Context) error {
+	bulkIndexer, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return err
+	}
+
+	return bulkIndexer.Close(ctx)
+}
+
+// BulkIndexStats returns the counts of items handled by the default bulk indexer since it was created.
+func (cli *ESClient) BulkIndexStats(_ context.Context) (client.BulkIndexerStats, error) {
+	bulkIndexer, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return client.BulkIndexerStats{}, err
+	}
+
+	return bulkIndexer.Stats(), nil
+}
 
-	return cli.bulkIndexer.Close(ctx)
-}
-
 func convertToMultilineSearches(searches []client.Search) (body []byte, err error) {
 	for _, search := range searches {
 		headerByte, err := json.Marshal(search.Header)
@@ -550,8 +1431,31 @@ func getStatusCode(res *esapi.Response) int {
 	return res.StatusCode
 }
 
+// decodeWriteResult decodes the response to a single document write
+func decodeWriteResult(res *esapi.Response) (*client.WriteResult, error) {
+	result, err := clientutil.DecodeWriteResult(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return result, nil
+}
+
+// intPtr converts an optional int64 option into the optional int used by the request structs
+func intPtr(i *int64) *int {
+	if i == nil {
+		return nil
+	}
+
+	v := int(*i)
+	return &v
+}
+
 // checkForError checks if the provided elasticsearch response contains an error.
-// if it does, it is read and returned as a string error
+// if it does, it is read and returned as an *esError.ESError
 func checkForError(res *esapi.Response) error {
 	if res == nil {
 		return errors.New("nil elasticsearch api response")
@@ -566,5 +1470,5 @@ func checkForError(res *esapi.Response) error {
 		return fmt.Errorf("failed to ready elasticsearch response body for an error case: %w", err)
 	}
 
-	return fmt.Errorf("error response from elasticsearch: %s", string(resBody))
+	return esError.NewESError("elasticsearch", res.StatusCode, resBody)
 }
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
 	"github.com/ONSdigital/log.go/v2/log"
@@ -135,11 +137,13 @@ func (cli *ESClient) healthcheck(ctx context.Context) (code int, err error) {
 	return resp.StatusCode, ErrorInvalidHealthStatus
 }
 
-// indexcheck calls elasticsearch to check if the required indexes from the client exist
+// indexcheck calls elasticsearch to check if the required indexes, or aliases, from the client exist.
+// Cluster health for a missing index blocks until the request times out and returns a 408, so the
+// index exists API is used instead.
 func (cli *ESClient) indexcheck(ctx context.Context) (int, error) {
 	// Check handles each index, making sure the response body is always closed
 	check := func(index string) (int, error) {
-		resp, err := cli.esClient.Cluster.Health(cli.esClient.Cluster.Health.WithIndex(index))
+		resp, err := cli.esClient.Indices.Exists([]string{index}, cli.esClient.Indices.Exists.WithContext(ctx))
 		if err != nil {
 			log.Error(ctx, "failed to call elasticsearch", err)
 			return 500, err
@@ -150,7 +154,7 @@ func (cli *ESClient) indexcheck(ctx context.Context) (int, error) {
 		case 200:
 			return 200, nil
 		case 404:
-			log.Error(ctx, "index does not exist", ErrorIndexDoesNotExist)
+			log.Error(ctx, "index does not exist", ErrorIndexDoesNotExist, log.Data{"index": index})
 			return resp.StatusCode, ErrorIndexDoesNotExist
 		default:
 			log.Error(ctx, "unexpected status code returned in response", ErrorUnexpectedStatusCode)
@@ -158,14 +162,23 @@ func (cli *ESClient) indexcheck(ctx context.Context) (int, error) {
 		}
 	}
 
-	// Check all indexes, if any fails, return the code and error
+	// Check all indexes so that every missing index is reported. Any other failure is returned straight away.
+	var missing []string
 	for _, index := range cli.indexes {
 		code, err := check(index)
+		if errors.Is(err, ErrorIndexDoesNotExist) {
+			missing = append(missing, index)
+			continue
+		}
 		if err != nil {
 			return code, err
 		}
 	}
 
+	if len(missing) > 0 {
+		return http.StatusNotFound, fmt.Errorf("%w: %s", ErrorIndexDoesNotExist, strings.Join(missing, ", "))
+	}
+
 	// if all indexes are successful, return 200 and no error
 	return 200, nil
 }
@@ -0,0 +1,29 @@
+package v710
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+// doWithoutReplay performs a request that is not safe to replay, such as creating a document. A request
+// that failed in transit or with a server error may already have been applied, so it is only retried when
+// the cluster has rejected it with a 429. newRequest is called for each attempt so the body can be re-read.
+func (cli *ESClient) doWithoutReplay(ctx context.Context, newRequest func() esapi.Request) (*esapi.Response, error) {
+	for attempt := 1; ; attempt++ {
+		res, err := newRequest().Do(ctx, cli.noRetryClient)
+		if err != nil || res.StatusCode != http.StatusTooManyRequests ||
+			attempt > cli.retryPolicy.MaxRetries || !cli.retryPolicy.ShouldRetryStatus(res.StatusCode) {
+			return res, err
+		}
+
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+
+		if err := cli.retryPolicy.Wait(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+}
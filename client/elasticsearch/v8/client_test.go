@@ -0,0 +1,1223 @@
+package v8
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	es8 "github.com/elastic/go-elasticsearch/v8"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type mockRoundTripper struct {
+	roundTripFunc func(req *http.Request) *http.Response
+}
+
+func (m *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return m.roundTripFunc(req), nil
+}
+
+func newMockClient(statusCode int, body string, assert func(req *http.Request)) *es8.Client {
+	rt := &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			if assert != nil {
+				assert(req)
+			}
+			return &http.Response{
+				StatusCode: statusCode,
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+				Header:     http.Header{"X-Elastic-Product": []string{"Elasticsearch"}},
+			}
+		},
+	}
+	esClient, _ := es8.NewClient(es8.Config{
+		Addresses: []string{"http://localhost:9200"},
+		Transport: rt,
+	})
+	return esClient
+}
+
+func TestNewESClient(t *testing.T) {
+	Convey("Given a valid elasticsearch url", t, func() {
+		Convey("When NewESClient is called", func() {
+			cli, err := NewESClient("http://localhost:9200", nil)
+
+			Convey("Then a client is returned", func() {
+				So(err, ShouldBeNil)
+				So(cli, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given an invalid elasticsearch url", t, func() {
+		Convey("When NewESClient is called", func() {
+			cli, err := NewESClient("invalid-url", nil)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(cli, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestMultiSearch(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given convert a slice of searches to multiline searches", t, func() {
+		expectedMultiLintStringCount := 5
+		searches := []client.Search{
+			{
+				Header: client.Header{
+					Index: "ons_test",
+				},
+				Query: []byte(`{"query" : {"match" : { "message": "this is a test"}}}`),
+			},
+			{
+				Header: client.Header{
+					Index: "ons_test_2",
+				},
+				Query: []byte(`{"query" : {"match_all" : {}}}`),
+			},
+		}
+
+		body, err := convertToMultilineSearches(searches)
+
+		So(err, ShouldEqual, nil)
+		splitQuery := strings.Split(string(body), "\n")
+		So(len(splitQuery), ShouldEqual, expectedMultiLintStringCount)
+		So(splitQuery[0], ShouldEqual, "{\"index\":\"ons_test\"}")
+		So(splitQuery[1], ShouldEqual, "{\"query\" : {\"match\" : { \"message\": \"this is a test\"}}}")
+		So(splitQuery[2], ShouldEqual, "{\"index\":\"ons_test_2\"}")
+		So(splitQuery[3], ShouldEqual, "{\"query\" : {\"match_all\" : {}}}")
+	})
+}
+
+func TestSearch(t *testing.T) {
+	Convey("Given a valid ESClient", t, func() {
+		var receivedPath string
+		assertPath := func(req *http.Request) {
+			receivedPath = req.URL.Path
+		}
+
+		esClient := newMockClient(http.StatusOK, `{"hits":{"hits":[]}}`, assertPath)
+		testClient := &ESClient{esClient: esClient}
+
+		Convey("When Search returns 200", func() {
+			search := client.Search{
+				Header: client.Header{Index: "my-index"},
+				Query:  []byte(`{"query":{"match_all":{}}}`),
+			}
+
+			data, err := testClient.Search(context.Background(), search)
+
+			Convey("Then the response body is returned", func() {
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"hits":{"hits":[]}}`)
+				So(receivedPath, ShouldEqual, "/my-index/_search")
+			})
+		})
+
+		Convey("When Search returns 500", func() {
+			errorClient := newMockClient(http.StatusInternalServerError, `{"error":"server error"}`, nil)
+			testClient := &ESClient{esClient: errorClient}
+
+			_, err := testClient.Search(context.Background(), client.Search{Header: client.Header{Index: "my-index"}})
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "error response from elasticsearch")
+			})
+		})
+	})
+}
+
+func TestDeleteDocument(t *testing.T) {
+	Convey("Given a valid ESClient", t, func() {
+		esClient := newMockClient(http.StatusOK, `{}`, nil)
+		testClient := &ESClient{esClient: esClient}
+
+		Convey("When DeleteDocument returns 200", func() {
+			_, err := testClient.DeleteDocument(context.Background(), "my-index", "my-id", nil)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("When DeleteDocument returns 500", func() {
+			esClient := newMockClient(http.StatusInternalServerError, `{"error":"server error"}`, nil)
+			testClient := &ESClient{esClient: esClient}
+			_, err := testClient.DeleteDocument(context.Background(), "my-index", "my-id", nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "delete request failed")
+		})
+	})
+}
+
+func TestUpdateAliases(t *testing.T) {
+	Convey("Given a valid ESClient", t, func() {
+		var receivedBody string
+		assertBody := func(req *http.Request) {
+			bodyBytes, _ := io.ReadAll(req.Body)
+			receivedBody = string(bodyBytes)
+		}
+
+		esClient := newMockClient(http.StatusOK, `{"acknowledged":true}`, assertBody)
+		testClient := &ESClient{esClient: esClient}
+
+		Convey("When UpdateAliases is called with multiple indices", func() {
+			err := testClient.UpdateAliases(context.Background(), "my-alias", []string{"old-1", "old-2"}, []string{"new-1"})
+
+			Convey("Then each index is sent as a separate array element", func() {
+				So(err, ShouldBeNil)
+				So(receivedBody, ShouldEqual,
+					`{"actions":[{"remove":{"indices":["old-1","old-2"],"aliases":["my-alias"]}},{"add":{"indices":["new-1"],"aliases":["my-alias"]}}]}`)
+			})
+		})
+
+		Convey("When UpdateAliases returns 404", func() {
+			errorClient := newMockClient(http.StatusNotFound, `{"error":"index not found"}`, nil)
+			testClient := &ESClient{esClient: errorClient}
+
+			err := testClient.UpdateAliases(context.Background(), "my-alias", nil, []string{"missing"})
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "error occured while trying to update aliases")
+			})
+		})
+	})
+}
+
+func TestChecker(t *testing.T) {
+	Convey("Given a green elasticsearch cluster", t, func() {
+		esClient := newMockClient(http.StatusOK, `{"status":"green"}`, nil)
+		testClient := &ESClient{esClient: esClient}
+
+		Convey("When Checker is called", func() {
+			state := health.NewCheckState("elasticsearch")
+			err := testClient.Checker(context.Background(), state)
+
+			Convey("Then the check state is OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldEqual, MsgHealthy)
+			})
+		})
+	})
+
+	Convey("Given a red elasticsearch cluster", t, func() {
+		esClient := newMockClient(http.StatusOK, `{"status":"red"}`, nil)
+		testClient := &ESClient{esClient: esClient}
+
+		Convey("When Checker is called", func() {
+			state := health.NewCheckState("elasticsearch")
+			err := testClient.Checker(context.Background(), state)
+
+			Convey("Then the check state is critical", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, ErrorUnhealthyClusterStatus.Error())
+			})
+		})
+	})
+}
+
+func TestCheckerRequiredIndexes(t *testing.T) {
+	Convey("Given a green elasticsearch cluster and a missing required index", t, func() {
+		var indexPaths []string
+		rt := &mockRoundTripper{
+			roundTripFunc: func(req *http.Request) *http.Response {
+				statusCode, body := http.StatusOK, `{"status":"green"}`
+				if req.Method == http.MethodHead {
+					indexPaths = append(indexPaths, req.URL.Path)
+					statusCode, body = http.StatusNotFound, ``
+				}
+				return &http.Response{
+					StatusCode: statusCode,
+					Body:       io.NopCloser(bytes.NewBufferString(body)),
+					Header:     http.Header{"X-Elastic-Product": []string{"Elasticsearch"}},
+				}
+			},
+		}
+		esClient, _ := es8.NewClient(es8.Config{
+			Addresses: []string{"http://localhost:9200"},
+			Transport: rt,
+		})
+		testClient := &ESClient{esClient: esClient, indexes: []string{"my-index"}}
+
+		Convey("When Checker is called", func() {
+			state := health.NewCheckState("elasticsearch")
+			err := testClient.Checker(context.Background(), state)
+
+			Convey("Then the index exists API is used and the check state is critical", func() {
+				So(err, ShouldBeNil)
+				So(indexPaths, ShouldResemble, []string{"/my-index"})
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, ErrorIndexDoesNotExist.Error()+": my-index")
+			})
+		})
+	})
+}
+
+func TestStructuredErrors(t *testing.T) {
+	Convey("Given a valid ESClient where the index already exists", t, func() {
+		body := `{"error":{"root_cause":[{"type":"resource_already_exists_exception","reason":"index [my-index/abc] already exists","index":"my-index"}],` +
+			`"type":"resource_already_exists_exception","reason":"index [my-index/abc] already exists","index":"my-index"},"status":400}`
+		testClient := &ESClient{esClient: newMockClient(http.StatusBadRequest, body, nil)}
+
+		Convey("When CreateIndex is called", func() {
+			err := testClient.CreateIndex(context.Background(), "my-index", nil)
+
+			Convey("Then the returned error carries the parsed error response", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, http.StatusBadRequest)
+				So(esError.IsResourceAlreadyExists(err), ShouldBeTrue)
+				So(esError.IsIndexNotFound(err), ShouldBeFalse)
+
+				var esErr *esError.ESError
+				So(errors.As(err, &esErr), ShouldBeTrue)
+				So(esErr.Index, ShouldEqual, "my-index")
+				So(err.Error(), ShouldContainSubstring, "error response from elasticsearch: "+body)
+			})
+		})
+	})
+}
+
+func TestAddDocument(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a valid ESClient", t, func() {
+		var method, path, body string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			method, path, query = req.Method, req.URL.Path, req.URL.Query()
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				body = string(data)
+			}
+		}
+		testClient := &ESClient{esClient: newMockClient(http.StatusCreated, `{"result":"created"}`, recordRequest), noRetryClient: newMockClient(http.StatusCreated, `{"result":"created"}`, recordRequest)}
+		options := &client.AddDocumentOptions{
+			Refresh:  client.RefreshWaitFor,
+			Routing:  "my-routing",
+			Pipeline: "my-pipeline",
+		}
+
+		Convey("When AddDocument is called without the upsert option", func() {
+			_, err := testClient.AddDocument(ctx, "my-index", "my-id", []byte(`{"a":1}`), options)
+
+			Convey("Then the document is created with the given options", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodPut)
+				So(path, ShouldEqual, "/my-index/_create/my-id")
+				So(query.Get("refresh"), ShouldEqual, "wait_for")
+				So(query.Get("routing"), ShouldEqual, "my-routing")
+				So(query.Get("pipeline"), ShouldEqual, "my-pipeline")
+				So(body, ShouldEqual, `{"a":1}`)
+			})
+		})
+
+		Convey("When AddDocument is called with the upsert option", func() {
+			options.Upsert = true
+			_, err := testClient.AddDocument(ctx, "my-index", "my-id", []byte(`{"a":1}`), options)
+
+			Convey("Then the document is indexed, replacing any existing document", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodPut)
+				So(path, ShouldEqual, "/my-index/_doc/my-id")
+				So(query.Get("op_type"), ShouldEqual, "index")
+				So(query.Get("refresh"), ShouldEqual, "wait_for")
+				So(query.Get("routing"), ShouldEqual, "my-routing")
+				So(query.Get("pipeline"), ShouldEqual, "my-pipeline")
+				So(body, ShouldEqual, `{"a":1}`)
+			})
+		})
+
+		Convey("When AddDocument is called with the merge upsert mode", func() {
+			options.Upsert, options.UpsertMode, options.Pipeline = true, client.UpsertMerge, ""
+			_, err := testClient.AddDocument(ctx, "my-index", "my-id", []byte(`{"a":1}`), options)
+
+			Convey("Then the document is partially updated with doc_as_upsert", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodPost)
+				So(path, ShouldEqual, "/my-index/_update/my-id")
+				So(query.Get("refresh"), ShouldEqual, "wait_for")
+				So(query.Get("routing"), ShouldEqual, "my-routing")
+				So(body, ShouldEqual, `{"doc":{"a":1},"doc_as_upsert":true}`)
+			})
+		})
+
+		Convey("When AddDocument is called with the merge upsert mode and a pipeline", func() {
+			options.Upsert, options.UpsertMode = true, client.UpsertMerge
+			_, err := testClient.AddDocument(ctx, "my-index", "my-id", []byte(`{"a":1}`), options)
+
+			Convey("Then an error is returned without calling the cluster", func() {
+				So(err, ShouldNotBeNil)
+				So(path, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestConcurrencyControl(t *testing.T) {
+	ctx := context.Background()
+	seqNo, primaryTerm, version := int64(7), int64(2), int64(42)
+
+	Convey("Given a valid ESClient", t, func() {
+		var method, path, body string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			method, path, query = req.Method, req.URL.Path, req.URL.Query()
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				body = string(data)
+			}
+		}
+		resBody := `{"_index":"my-index","_id":"my-id","_version":3,"_seq_no":8,"_primary_term":2,"result":"updated"}`
+		mockClient := newMockClient(http.StatusOK, resBody, recordRequest)
+		testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient}
+
+		Convey("When UpdateDocument is called with if_seq_no and if_primary_term", func() {
+			res, err := testClient.UpdateDocument(ctx, "my-index", "my-id", client.DocumentUpdate{Doc: []byte(`{"a":1}`)},
+				&client.UpdateDocumentOptions{ConcurrencyControl: client.ConcurrencyControl{IfSeqNo: &seqNo, IfPrimaryTerm: &primaryTerm}})
+
+			Convey("Then the update is conditional on the sequence number and primary term", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodPost)
+				So(path, ShouldEqual, "/my-index/_update/my-id")
+				So(query.Get("if_seq_no"), ShouldEqual, "7")
+				So(query.Get("if_primary_term"), ShouldEqual, "2")
+				So(body, ShouldEqual, `{"doc":{"a":1}}`)
+			})
+
+			Convey("Then the new sequence number, primary term and version are returned", func() {
+				So(res, ShouldResemble, &client.WriteResult{
+					Index: "my-index", ID: "my-id", Version: 3, SeqNo: 8, PrimaryTerm: 2, Result: "updated",
+				})
+			})
+		})
+
+		Convey("When UpdateDocument is called with an external version", func() {
+			_, err := testClient.UpdateDocument(ctx, "my-index", "my-id", client.DocumentUpdate{Doc: []byte(`{"a":1}`)},
+				&client.UpdateDocumentOptions{ConcurrencyControl: client.ConcurrencyControl{Version: &version}})
+
+			Convey("Then an error is returned without calling the cluster", func() {
+				So(err, ShouldNotBeNil)
+				So(path, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When DeleteDocument is called with an external version", func() {
+			_, err := testClient.DeleteDocument(ctx, "my-index", "my-id",
+				&client.DeleteDocumentOptions{ConcurrencyControl: client.ConcurrencyControl{Version: &version}})
+
+			Convey("Then the version is sent with the external version type", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodDelete)
+				So(query.Get("version"), ShouldEqual, "42")
+				So(query.Get("version_type"), ShouldEqual, "external")
+			})
+		})
+
+		Convey("When AddDocument upserts with if_seq_no and if_primary_term", func() {
+			_, err := testClient.AddDocument(ctx, "my-index", "my-id", []byte(`{"a":1}`), &client.AddDocumentOptions{
+				Upsert:             true,
+				ConcurrencyControl: client.ConcurrencyControl{IfSeqNo: &seqNo, IfPrimaryTerm: &primaryTerm},
+			})
+
+			Convey("Then the index request is conditional on the sequence number and primary term", func() {
+				So(err, ShouldBeNil)
+				So(query.Get("if_seq_no"), ShouldEqual, "7")
+				So(query.Get("if_primary_term"), ShouldEqual, "2")
+			})
+		})
+
+		Convey("When AddDocument creates a document with if_seq_no", func() {
+			_, err := testClient.AddDocument(ctx, "my-index", "my-id", []byte(`{"a":1}`), &client.AddDocumentOptions{
+				ConcurrencyControl: client.ConcurrencyControl{IfSeqNo: &seqNo, IfPrimaryTerm: &primaryTerm},
+			})
+
+			Convey("Then an error is returned without calling the cluster", func() {
+				So(err, ShouldNotBeNil)
+				So(path, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a valid ESClient where the document has changed", t, func() {
+		resBody := `{"error":{"root_cause":[{"type":"version_conflict_engine_exception","reason":"[my-id]: version conflict"}],` +
+			`"type":"version_conflict_engine_exception","reason":"[my-id]: version conflict","index":"my-index"},"status":409}`
+		mockClient := newMockClient(http.StatusConflict, resBody, nil)
+		testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient}
+
+		Convey("When UpdateDocument is called", func() {
+			res, err := testClient.UpdateDocument(ctx, "my-index", "my-id", client.DocumentUpdate{Doc: []byte(`{"a":1}`)},
+				&client.UpdateDocumentOptions{ConcurrencyControl: client.ConcurrencyControl{IfSeqNo: &seqNo, IfPrimaryTerm: &primaryTerm}})
+
+			Convey("Then a version conflict error is returned", func() {
+				So(res, ShouldBeNil)
+				So(esError.ErrorStatus(err), ShouldEqual, http.StatusConflict)
+				So(esError.IsVersionConflict(err), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestGetDocument(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a valid ESClient with a document", t, func() {
+		var path string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			path, query = req.URL.Path, req.URL.Query()
+		}
+		resBody := `{"_index":"my-index","_id":"my-id","_version":2,"_seq_no":5,"_primary_term":1,"found":true,"_source":{"title":"Hello"}}`
+		testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, recordRequest)}
+
+		Convey("When GetDocument is called with options", func() {
+			realtime := false
+			res, err := testClient.GetDocument(ctx, "my-index", "my-id", &client.GetDocumentOptions{
+				SourceIncludes: []string{"title", "meta.*"},
+				SourceExcludes: []string{"meta.secret"},
+				Routing:        "my-routing",
+				Realtime:       &realtime,
+			})
+
+			Convey("Then the options are sent with the request", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/my-index/_doc/my-id")
+				So(query.Get("_source_includes"), ShouldEqual, "title,meta.*")
+				So(query.Get("_source_excludes"), ShouldEqual, "meta.secret")
+				So(query.Get("routing"), ShouldEqual, "my-routing")
+				So(query.Get("realtime"), ShouldEqual, "false")
+			})
+
+			Convey("Then the document is returned", func() {
+				So(res.Found, ShouldBeTrue)
+				So(res.Version, ShouldEqual, 2)
+				So(res.SeqNo, ShouldEqual, 5)
+				So(string(res.Source), ShouldEqual, `{"title":"Hello"}`)
+			})
+		})
+	})
+
+	Convey("Given a valid ESClient without the document", t, func() {
+		testClient := &ESClient{esClient: newMockClient(http.StatusNotFound, `{"_index":"my-index","_id":"my-id","found":false}`, nil)}
+
+		Convey("When GetDocument is called", func() {
+			res, err := testClient.GetDocument(ctx, "my-index", "my-id", nil)
+
+			Convey("Then a result that is not found is returned", func() {
+				So(err, ShouldBeNil)
+				So(res.Found, ShouldBeFalse)
+				So(res.ID, ShouldEqual, "my-id")
+			})
+		})
+	})
+
+	Convey("Given a valid ESClient without the index", t, func() {
+		resBody := `{"error":{"type":"index_not_found_exception","reason":"no such index [my-index]","index":"my-index"},"status":404}`
+		testClient := &ESClient{esClient: newMockClient(http.StatusNotFound, resBody, nil)}
+
+		Convey("When GetDocument is called", func() {
+			res, err := testClient.GetDocument(ctx, "my-index", "my-id", nil)
+
+			Convey("Then an index not found error is returned", func() {
+				So(res, ShouldBeNil)
+				So(esError.ErrorStatus(err), ShouldEqual, http.StatusNotFound)
+				So(esError.IsIndexNotFound(err), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestMultiGet(t *testing.T) {
+	Convey("Given a valid ESClient", t, func() {
+		var path, body string
+		recordRequest := func(req *http.Request) {
+			path = req.URL.Path
+			data, _ := io.ReadAll(req.Body)
+			body = string(data)
+		}
+		resBody := `{"docs":[` +
+			`{"_index":"index-1","_id":"1","_version":1,"_seq_no":0,"_primary_term":1,"found":true,"_source":{"title":"One"}},` +
+			`{"_index":"index-1","_id":"2","found":false},` +
+			`{"_index":"missing","_id":"3","error":{"type":"index_not_found_exception","reason":"no such index [missing]","index":"missing"}}]}`
+		testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, recordRequest)}
+
+		Convey("When MultiGet is called for documents across indices", func() {
+			res, err := testClient.MultiGet(context.Background(), []client.MultiGetDocument{
+				{Index: "index-1", ID: "1", SourceIncludes: []string{"title"}},
+				{Index: "index-1", ID: "2", Routing: "my-routing"},
+				{Index: "missing", ID: "3"},
+			}, nil)
+
+			Convey("Then the documents are requested in the body", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/_mget")
+				So(body, ShouldEqual, `{"docs":[{"_index":"index-1","_id":"1","_source":{"includes":["title"]}},`+
+					`{"_index":"index-1","_id":"2","routing":"my-routing"},{"_index":"missing","_id":"3"}]}`)
+			})
+
+			Convey("Then a result is returned for each document", func() {
+				So(res.Docs, ShouldHaveLength, 3)
+				So(res.Docs[0].Found, ShouldBeTrue)
+				So(string(res.Docs[0].Source), ShouldEqual, `{"title":"One"}`)
+				So(res.Docs[1].Found, ShouldBeFalse)
+				So(res.Docs[1].Error, ShouldBeNil)
+				So(res.Docs[2].Error.Type, ShouldEqual, "index_not_found_exception")
+			})
+		})
+	})
+}
+
+func TestUpdateDocument(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a valid ESClient", t, func() {
+		var path, body string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			path, query = req.URL.Path, req.URL.Query()
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				body = string(data)
+			}
+		}
+		resBody := `{"_index":"my-index","_id":"my-id","_version":2,"_seq_no":5,"_primary_term":1,"result":"updated"}`
+		mockClient := newMockClient(http.StatusOK, resBody, recordRequest)
+		testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient}
+
+		Convey("When UpdateDocument is called with a script and retry_on_conflict", func() {
+			retries := 3
+			res, err := testClient.UpdateDocument(ctx, "my-index", "my-id", client.DocumentUpdate{
+				Script: &client.Script{Source: "ctx._source.count += params.by", Lang: "painless", Params: map[string]interface{}{"by": 2}},
+				Upsert: []byte(`{"count":2}`),
+			}, &client.UpdateDocumentOptions{RetryOnConflict: &retries})
+
+			Convey("Then the script and its params are sent with the upsert document", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/my-index/_update/my-id")
+				So(query.Get("retry_on_conflict"), ShouldEqual, "3")
+				So(body, ShouldEqual, `{"script":{"source":"ctx._source.count += params.by","lang":"painless","params":{"by":2}},"upsert":{"count":2}}`)
+				So(res.Result, ShouldEqual, "updated")
+			})
+		})
+	})
+}
+
+func TestBulk(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a valid ESClient", t, func() {
+		var paths []string
+		var query url.Values
+		var bodies []string
+		recordRequest := func(req *http.Request) {
+			paths, query = append(paths, req.URL.Path), req.URL.Query()
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(data))
+			}
+		}
+		resBody := `{"took":2,"errors":true,"items":[` +
+			`{"index":{"_index":"my-index","_id":"1","_version":1,"result":"created","status":201}},` +
+			`{"delete":{"_index":"my-index","_id":"2","status":409,"error":{"type":"version_conflict_engine_exception","reason":"conflict"}}}]}`
+		mockClient := newMockClient(http.StatusOK, resBody, recordRequest)
+		testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient}
+
+		Convey("When Bulk is called with actions split across two requests", func() {
+			bulk := client.NewBulkRequestBuilder(60)
+			So(bulk.Index("", "1", []byte(`{"title":"one"}`), &client.BulkActionOptions{Routing: "r1"}), ShouldBeNil)
+			So(bulk.Delete("", "2", nil), ShouldBeNil)
+
+			res, err := testClient.Bulk(ctx, bulk, &client.BulkOptions{Index: "my-index", Refresh: client.RefreshWaitFor, Pipeline: "my-pipeline"})
+
+			Convey("Then a request is sent for each body with the options", func() {
+				So(paths, ShouldResemble, []string{"/my-index/_bulk", "/my-index/_bulk"})
+				So(query.Get("refresh"), ShouldEqual, "wait_for")
+				So(query.Get("pipeline"), ShouldEqual, "my-pipeline")
+				So(bodies, ShouldResemble, []string{
+					"{\"index\":{\"_id\":\"1\",\"routing\":\"r1\"}}\n{\"title\":\"one\"}\n",
+					"{\"delete\":{\"_id\":\"2\"}}\n",
+				})
+			})
+
+			Convey("Then the items of both responses are returned with an error for the failed items", func() {
+				So(errors.Is(err, client.ErrBulkItemsFailed), ShouldBeTrue)
+				So(res.Items, ShouldHaveLength, 4)
+				So(res.Failed(), ShouldHaveLength, 2)
+				So(res.Failed()[0].Status, ShouldEqual, 409)
+			})
+		})
+	})
+
+	Convey("Given a ESClient that returns an error", t, func() {
+		mockClient := newMockClient(http.StatusBadRequest, `{"error":{"type":"illegal_argument_exception","reason":"bad"},"status":400}`, nil)
+		testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient}
+
+		Convey("When Bulk is called", func() {
+			bulk := client.NewBulkRequestBuilder(0)
+			So(bulk.Index("my-index", "1", []byte(`{}`), nil), ShouldBeNil)
+
+			_, err := testClient.Bulk(ctx, bulk, nil)
+
+			Convey("Then the error is returned with its status", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, http.StatusBadRequest)
+				So(err.Error(), ShouldContainSubstring, "error occured while trying to send bulk request")
+			})
+		})
+	})
+}
+
+func TestBulkUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a valid ESClient", t, func() {
+		var paths []string
+		mockClient := newMockClient(http.StatusOK, `{"took":1,"errors":false,"items":[]}`, func(req *http.Request) {
+			paths = append(paths, req.URL.Path)
+		})
+		testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient, address: "http://localhost:9200"}
+
+		Convey("When BulkUpdate is called with the address of the client", func() {
+			_, err := testClient.BulkUpdate(ctx, "my-index", "http://localhost:9200/", []byte("{\"delete\":{\"_id\":\"1\"}}\n"))
+
+			Convey("Then the request is sent", func() {
+				So(err, ShouldBeNil)
+				So(paths, ShouldResemble, []string{"/my-index/_bulk"})
+			})
+		})
+
+		Convey("When BulkUpdate is called with a different address", func() {
+			_, err := testClient.BulkUpdate(ctx, "my-index", "http://other-cluster:9200", []byte("{\"delete\":{\"_id\":\"1\"}}\n"))
+
+			Convey("Then an error is returned without the request being sent", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, http.StatusBadRequest)
+				So(err.Error(), ShouldContainSubstring, "http://other-cluster:9200")
+				So(paths, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestUpdateByQuery(t *testing.T) {
+	ctx := context.Background()
+	update := client.UpdateByQuery{
+		Query:  []byte(`{"term":{"status":"draft"}}`),
+		Script: &client.Script{Source: "ctx._source.status = params.status", Params: map[string]interface{}{"status": "published"}},
+	}
+
+	Convey("Given a valid ESClient", t, func() {
+		var path, body string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			path, query = req.URL.Path, req.URL.Query()
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				body = string(data)
+			}
+		}
+
+		Convey("When UpdateByQuery is called and waits for completion", func() {
+			resBody := `{"took":12,"timed_out":false,"total":5,"updated":3,"deleted":0,"batches":1,"version_conflicts":2,` +
+				`"noops":0,"retries":{"bulk":0,"search":0},"throttled_millis":0,"failures":[]}`
+			mockClient := newMockClient(http.StatusOK, resBody, recordRequest)
+			testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient}
+
+			res, err := testClient.UpdateByQuery(ctx, []string{"index-a", "index-b"}, update, &client.UpdateByQueryOptions{
+				ProceedOnConflicts: true,
+				Slices:             "auto",
+			})
+
+			Convey("Then the query and script are sent with the conflicts and slices parameters", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/index-a,index-b/_update_by_query")
+				So(query.Get("conflicts"), ShouldEqual, "proceed")
+				So(query.Get("slices"), ShouldEqual, "auto")
+				So(body, ShouldEqual, `{"query":{"term":{"status":"draft"}},"script":{"source":"ctx._source.status = params.status","params":{"status":"published"}}}`)
+			})
+
+			Convey("Then the updated and version conflict counts are returned", func() {
+				So(res.Total, ShouldEqual, 5)
+				So(res.Updated, ShouldEqual, 3)
+				So(res.VersionConflicts, ShouldEqual, 2)
+				So(res.Failures, ShouldBeEmpty)
+				So(res.Task, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When UpdateByQuery is called without waiting for completion", func() {
+			mockClient := newMockClient(http.StatusOK, `{"task":"node-1:1234"}`, recordRequest)
+			testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient}
+
+			wait := false
+			res, err := testClient.UpdateByQuery(ctx, []string{"my-index"}, update, &client.UpdateByQueryOptions{WaitForCompletion: &wait})
+
+			Convey("Then the ID of the task running the update is returned", func() {
+				So(err, ShouldBeNil)
+				So(query.Get("wait_for_completion"), ShouldEqual, "false")
+				So(res.Task, ShouldEqual, "node-1:1234")
+			})
+		})
+
+		Convey("When UpdateByQuery fails on some documents", func() {
+			resBody := `{"took":3,"total":2,"updated":1,"version_conflicts":1,"failures":[{"index":"my-index","id":"2","status":409,` +
+				`"cause":{"type":"version_conflict_engine_exception","reason":"[2]: version conflict"}}]}`
+			mockClient := newMockClient(http.StatusConflict, resBody, recordRequest)
+			testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient}
+
+			_, err := testClient.UpdateByQuery(ctx, []string{"my-index"}, update, nil)
+
+			Convey("Then the conflict is returned as an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestClusterHealth(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a valid ESClient", t, func() {
+		var path string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			path, query = req.URL.Path, req.URL.Query()
+		}
+
+		Convey("When ClusterHealth waits for an index to become green", func() {
+			resBody := `{"cluster_name":"search","status":"green","timed_out":false,"number_of_nodes":3,"active_shards":2}`
+			testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, recordRequest)}
+
+			res, err := testClient.ClusterHealth(ctx, []string{"my-index"}, &client.ClusterHealthOptions{
+				WaitForStatus: client.ClusterStatusGreen,
+				Timeout:       30 * time.Second,
+			})
+
+			Convey("Then the health of the index is returned", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/_cluster/health/my-index")
+				So(query.Get("wait_for_status"), ShouldEqual, "green")
+				So(query.Get("timeout"), ShouldEqual, "30000ms")
+				So(res.Status, ShouldEqual, client.ClusterStatusGreen)
+				So(res.ActiveShards, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When the status is not reached before the timeout", func() {
+			resBody := `{"cluster_name":"search","status":"yellow","timed_out":true}`
+			testClient := &ESClient{esClient: newMockClient(http.StatusRequestTimeout, resBody, recordRequest)}
+
+			res, err := testClient.ClusterHealth(ctx, []string{"my-index"}, &client.ClusterHealthOptions{WaitForStatus: client.ClusterStatusGreen})
+
+			Convey("Then the health is returned as timed out rather than as an error", func() {
+				So(err, ShouldBeNil)
+				So(res.TimedOut, ShouldBeTrue)
+				So(res.Status, ShouldEqual, client.ClusterStatusYellow)
+			})
+		})
+	})
+}
+
+func TestRefreshIndices(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a valid ESClient", t, func() {
+		var method, path string
+		recordRequest := func(req *http.Request) {
+			method, path = req.Method, req.URL.Path
+		}
+		testClient := &ESClient{esClient: newMockClient(http.StatusOK, `{"_shards":{"total":2,"successful":2,"failed":0}}`, recordRequest)}
+
+		Convey("When RefreshIndices is called", func() {
+			err := testClient.RefreshIndices(ctx, []string{"index-a", "index-b"})
+
+			Convey("Then the indices are refreshed", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodPost)
+				So(path, ShouldEqual, "/index-a,index-b/_refresh")
+			})
+		})
+	})
+}
+
+func TestUpdateAliasActions(t *testing.T) {
+	ctx := context.Background()
+	isWriteIndex := true
+
+	Convey("Given a valid ESClient", t, func() {
+		var path, body string
+		recordRequest := func(req *http.Request) {
+			path = req.URL.Path
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				body = string(data)
+			}
+		}
+		testClient := &ESClient{esClient: newMockClient(http.StatusOK, `{"acknowledged":true}`, recordRequest)}
+
+		Convey("When UpdateAliasActions is called with filtered, routed and write index actions", func() {
+			err := testClient.UpdateAliasActions(ctx, []client.AliasAction{
+				{Type: client.AliasAdd, Indices: []string{"new"}, Aliases: []string{"search"}, IsWriteIndex: &isWriteIndex},
+				{Type: client.AliasAdd, Indices: []string{"new"}, Aliases: []string{"bulletins"}, Filter: []byte(`{"term":{"type":"bulletin"}}`), Routing: "1"},
+				{Type: client.AliasRemoveIndex, Indices: []string{"old"}},
+			})
+
+			Convey("Then every action is sent in a single request", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/_aliases")
+				So(body, ShouldEqual, `{"actions":[`+
+					`{"add":{"indices":["new"],"aliases":["search"],"is_write_index":true}},`+
+					`{"add":{"indices":["new"],"aliases":["bulletins"],"filter":{"term":{"type":"bulletin"}},"routing":"1"}},`+
+					`{"remove_index":{"indices":["old"]}}]}`)
+			})
+		})
+
+		Convey("When UpdateAliasActions is called with an invalid action", func() {
+			err := testClient.UpdateAliasActions(ctx, []client.AliasAction{
+				{Type: client.AliasRemoveIndex, Indices: []string{"old"}, Aliases: []string{"search"}},
+			})
+
+			Convey("Then an error is returned without calling the cluster", func() {
+				So(err, ShouldNotBeNil)
+				So(path, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a ESClient where one of the indices does not exist", t, func() {
+		resBody := `{"error":{"root_cause":[{"type":"index_not_found_exception","reason":"no such index [missing]","index":"missing"}],` +
+			`"type":"index_not_found_exception","reason":"no such index [missing]","index":"missing"},"status":404}`
+		testClient := &ESClient{esClient: newMockClient(http.StatusNotFound, resBody, nil)}
+
+		Convey("When UpdateAliasActions is called", func() {
+			err := testClient.UpdateAliasActions(ctx, client.AliasActions("search", []string{"old"}, []string{"missing"}))
+
+			Convey("Then the parsed error is returned", func() {
+				So(esError.IsIndexNotFound(err), ShouldBeTrue)
+				So(esError.ErrorStatus(err), ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+
+	Convey("Given a ESClient where an action fails without failing the request", t, func() {
+		resBody := `{"acknowledged":true,"errors":true,"action_results":[` +
+			`{"action":{"type":"remove","indices":["old"],"aliases":["search"]},"status":404,` +
+			`"error":{"type":"aliases_not_found_exception","reason":"aliases [search] missing"}}]}`
+		testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, nil)}
+
+		Convey("When UpdateAliasActions is called", func() {
+			err := testClient.UpdateAliasActions(ctx, client.AliasActions("search", []string{"old"}, nil))
+
+			Convey("Then the failed action is returned as an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "aliases_not_found_exception")
+			})
+		})
+	})
+}
+
+func TestReindex(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a valid ESClient", t, func() {
+		var method, path, body string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			method, path, query = req.Method, req.URL.Path, req.URL.Query()
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				body = string(data)
+			}
+		}
+
+		Convey("When Reindex is called with a query, script and throttling", func() {
+			resBody := `{"took":40,"timed_out":false,"total":10,"created":8,"updated":2,"batches":1,"version_conflicts":0,"failures":[]}`
+			mockClient := newMockClient(http.StatusOK, resBody, recordRequest)
+			testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient}
+
+			requestsPerSecond := 500
+			res, err := testClient.Reindex(ctx, client.Reindex{
+				Source: client.ReindexSource{Index: []string{"old"}, Query: []byte(`{"term":{"type":"bulletin"}}`), Size: 500},
+				Dest:   client.ReindexDest{Index: "new", OpType: "create"},
+				Script: &client.Script{Source: "ctx._source.remove('legacy')"},
+			}, &client.ReindexOptions{Slices: "auto", RequestsPerSecond: &requestsPerSecond})
+
+			Convey("Then the source, destination and script are sent with the slices and throttling parameters", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodPost)
+				So(path, ShouldEqual, "/_reindex")
+				So(query.Get("slices"), ShouldEqual, "auto")
+				So(query.Get("requests_per_second"), ShouldEqual, "500")
+				So(body, ShouldEqual, `{"source":{"index":["old"],"query":{"term":{"type":"bulletin"}},"size":500},`+
+					`"dest":{"index":"new","op_type":"create"},"script":{"source":"ctx._source.remove('legacy')"}}`)
+			})
+
+			Convey("Then the created and updated counts are returned", func() {
+				So(res.Total, ShouldEqual, 10)
+				So(res.Created, ShouldEqual, 8)
+				So(res.Updated, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When Reindex is called with a remote source without waiting for completion", func() {
+			mockClient := newMockClient(http.StatusOK, `{"task":"node-1:99"}`, recordRequest)
+			testClient := &ESClient{esClient: mockClient, noRetryClient: mockClient}
+
+			wait := false
+			res, err := testClient.Reindex(ctx, client.Reindex{
+				Source: client.ReindexSource{
+					Index:  []string{"old"},
+					Remote: &client.ReindexRemote{Host: "https://other:9200", Username: "user", Password: "pass", SocketTimeout: time.Minute},
+				},
+				Dest:               client.ReindexDest{Index: "new"},
+				ProceedOnConflicts: true,
+			}, &client.ReindexOptions{WaitForCompletion: &wait})
+
+			Convey("Then the remote cluster is sent and the task ID is returned", func() {
+				So(err, ShouldBeNil)
+				So(query.Get("wait_for_completion"), ShouldEqual, "false")
+				So(body, ShouldEqual, `{"conflicts":"proceed","source":{"index":["old"],"remote":{"host":"https://other:9200",`+
+					`"username":"user","password":"pass","socket_timeout":"60000ms"}},"dest":{"index":"new"}}`)
+				So(res.Task, ShouldEqual, "node-1:99")
+			})
+		})
+	})
+}
+
+func TestTasks(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a valid ESClient", t, func() {
+		var method, path string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			method, path, query = req.Method, req.URL.Path, req.URL.Query()
+		}
+
+		Convey("When GetTask is called for a running reindex", func() {
+			resBody := `{"completed":false,"task":{"node":"node-1","id":42,"type":"transport","action":"indices:data/write/reindex",` +
+				`"status":{"total":100,"created":40,"updated":5,"deleted":0,"batches":1,"requests_per_second":-1.0},"cancellable":true}}`
+			testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, recordRequest)}
+
+			res, err := testClient.GetTask(ctx, "node-1:42", nil)
+
+			Convey("Then the progress of the task is returned", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodGet)
+				So(path, ShouldEqual, "/_tasks/node-1:42")
+				So(res.Completed, ShouldBeFalse)
+				So(res.Task.TaskID(), ShouldEqual, "node-1:42")
+				So(res.Task.Status.Total, ShouldEqual, 100)
+				So(res.Task.Status.Created, ShouldEqual, 40)
+			})
+		})
+
+		Convey("When GetTask is called for a completed update by query", func() {
+			resBody := `{"completed":true,"task":{"node":"node-1","id":42,"action":"indices:data/write/update/byquery"},` +
+				`"response":{"total":3,"updated":2,"version_conflicts":1,"failures":[]}}`
+			testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, recordRequest)}
+
+			res, err := testClient.GetTask(ctx, "node-1:42", &client.GetTaskOptions{WaitForCompletion: true})
+
+			Convey("Then the result of the update is returned", func() {
+				So(err, ShouldBeNil)
+				So(query.Get("wait_for_completion"), ShouldEqual, "true")
+				So(res.Completed, ShouldBeTrue)
+				So(res.Response.Updated, ShouldEqual, 2)
+				So(res.Response.VersionConflicts, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When ListTasks is called for an action", func() {
+			resBody := `{"tasks":[{"node":"node-1","id":42,"action":"indices:data/write/reindex"}]}`
+			testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, recordRequest)}
+
+			res, err := testClient.ListTasks(ctx, &client.ListTasksOptions{Actions: []string{"*reindex"}, Detailed: true})
+
+			Convey("Then the matching tasks are listed", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/_tasks")
+				So(query.Get("actions"), ShouldEqual, "*reindex")
+				So(query.Get("detailed"), ShouldEqual, "true")
+				So(query.Get("group_by"), ShouldEqual, "none")
+				So(res.Tasks, ShouldHaveLength, 1)
+				So(res.Tasks[0].Action, ShouldEqual, "indices:data/write/reindex")
+			})
+		})
+
+		Convey("When CancelTask is called", func() {
+			resBody := `{"nodes":{"node-1":{"tasks":{"node-1:42":{"node":"node-1","id":42,"cancelled":true}}}}}`
+			testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, recordRequest)}
+
+			res, err := testClient.CancelTask(ctx, "node-1:42")
+
+			Convey("Then the task is cancelled", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodPost)
+				So(path, ShouldEqual, "/_tasks/node-1:42/_cancel")
+				So(res.Tasks[0].TaskID(), ShouldEqual, "node-1:42")
+			})
+		})
+
+		Convey("When RethrottleTask is called", func() {
+			resBody := `{"nodes":{"node-1":{"tasks":{"node-1:42":{"node":"node-1","id":42,"status":{"requests_per_second":100.0}}}}}}`
+			testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, recordRequest)}
+
+			res, err := testClient.RethrottleTask(ctx, "node-1:42", 100)
+
+			Convey("Then the new requests per second are sent", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/_reindex/node-1:42/_rethrottle")
+				So(query.Get("requests_per_second"), ShouldEqual, "100")
+				So(res.Tasks[0].Status.RequestsPerSecond, ShouldEqual, 100)
+			})
+		})
+
+		Convey("When a tasks request fails on a node", func() {
+			resBody := `{"node_failures":[{"type":"failed_node_exception","reason":"Failed node [node-2]"}],"nodes":{}}`
+			testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, recordRequest)}
+
+			_, err := testClient.CancelTask(ctx, "node-2:1")
+
+			Convey("Then the failure is returned as an error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "failed_node_exception")
+			})
+		})
+	})
+}
+
+func TestScroll(t *testing.T) {
+	ctx := context.Background()
+	resBody := `{"_scroll_id":"scroll-1","hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_id":"1","_source":{}}]}}`
+
+	Convey("Given a valid ESClient", t, func() {
+		var method, path, body string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			method, path, query = req.Method, req.URL.Path, req.URL.Query()
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				body = string(data)
+			}
+		}
+		testClient := &ESClient{esClient: newMockClient(http.StatusOK, resBody, recordRequest)}
+
+		Convey("When Scroll is called", func() {
+			res, err := testClient.Scroll(ctx, client.Search{Header: client.Header{Index: "my-index"}, Query: []byte(`{"size":100}`)}, time.Minute)
+
+			Convey("Then the search is run with a scroll keepalive", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/my-index/_search")
+				So(query.Get("scroll"), ShouldEqual, "60000ms")
+				So(body, ShouldEqual, `{"size":100}`)
+				So(string(res), ShouldEqual, resBody)
+			})
+		})
+
+		Convey("When ScrollNext is called", func() {
+			_, err := testClient.ScrollNext(ctx, "scroll-1", time.Minute)
+
+			Convey("Then the scroll ID is sent in the body", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/_search/scroll")
+				So(query.Get("scroll"), ShouldEqual, "60000ms")
+				So(body, ShouldEqual, `{"scroll_id":"scroll-1"}`)
+			})
+		})
+
+		Convey("When ClearScroll is called", func() {
+			err := testClient.ClearScroll(ctx, []string{"scroll-1", "scroll-2"})
+
+			Convey("Then the scroll IDs are sent in the body", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodDelete)
+				So(path, ShouldEqual, "/_search/scroll")
+				So(body, ShouldEqual, `{"scroll_id":["scroll-1","scroll-2"]}`)
+			})
+		})
+	})
+
+	Convey("Given a ESClient where the scroll has expired", t, func() {
+		testClient := &ESClient{esClient: newMockClient(http.StatusNotFound, `{"succeeded":true,"num_freed":0}`, nil)}
+
+		Convey("When ClearScroll is called", func() {
+			err := testClient.ClearScroll(ctx, []string{"scroll-1"})
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestPointInTime(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a valid Client", t, func() {
+		var method, path, body string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			method, path, query = req.Method, req.URL.Path, req.URL.Query()
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				body = string(data)
+			}
+		}
+		testClient := &ESClient{esClient: newMockClient(http.StatusOK, `{"id":"pit-1"}`, recordRequest)}
+
+		Convey("When OpenPointInTime is called", func() {
+			pitID, err := testClient.OpenPointInTime(ctx, []string{"index-1", "index-2"}, time.Minute)
+
+			Convey("Then the point in time is opened with a keepalive and its ID is returned", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodPost)
+				So(path, ShouldEqual, "/index-1,index-2/_pit")
+				So(query.Get("keep_alive"), ShouldEqual, "60000ms")
+				So(pitID, ShouldEqual, "pit-1")
+			})
+		})
+
+		Convey("When ClosePointInTime is called", func() {
+			err := testClient.ClosePointInTime(ctx, "pit-1")
+
+			Convey("Then the point in time ID is sent in the body", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodDelete)
+				So(path, ShouldEqual, "/_pit")
+				So(body, ShouldEqual, `{"id":"pit-1"}`)
+			})
+		})
+
+		Convey("When Search is called without an index", func() {
+			_, err := testClient.Search(ctx, client.Search{Query: []byte(`{"pit":{"id":"pit-1"}}`)})
+
+			Convey("Then no index is named in the path", func() {
+				So(err, ShouldBeNil)
+				So(path, ShouldEqual, "/_search")
+			})
+		})
+	})
+
+	Convey("Given a Client where the point in time has expired", t, func() {
+		testClient := &ESClient{esClient: newMockClient(http.StatusNotFound, `{"succeeded":true,"num_freed":0}`, nil)}
+
+		Convey("When ClosePointInTime is called", func() {
+			err := testClient.ClosePointInTime(ctx, "pit-1")
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
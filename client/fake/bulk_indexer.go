@@ -0,0 +1,366 @@
+package fake
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+)
+
+const (
+	Create = client.BulkIndexerAction("create")
+	Delete = client.BulkIndexerAction("delete")
+	Index  = client.BulkIndexerAction("index")
+	Update = client.BulkIndexerAction("update")
+)
+
+// bulkAction is a single action from a bulk request
+type bulkAction struct {
+	Action     string
+	Index      string
+	DocumentID string
+	Body       []byte
+	client.ConcurrencyControl
+}
+
+type bulkResponseItem struct {
+	Index       string         `json:"_index"`
+	DocumentID  string         `json:"_id"`
+	Version     int64          `json:"_version,omitempty"`
+	SeqNo       int64          `json:"_seq_no"`
+	PrimaryTerm int64          `json:"_primary_term"`
+	Result      string         `json:"result,omitempty"`
+	Status      int            `json:"status"`
+	Error       *bulkItemError `json:"error,omitempty"`
+}
+
+type bulkItemError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// bulkIndexer buffers the items added to it until it is closed or, if it has a flush size, until their documents
+// reach it. As with the real clients, it is wrapped by clientutil.WrapBulkIndexer, which retries items, sends them to
+// the dead letter sink, limits the bytes buffered and reports progress.
+type bulkIndexer struct {
+	client *Client
+	cfg    client.BulkIndexerConfig
+	mu     sync.Mutex
+	items  []esutil.BulkIndexerItem
+	bodies [][]byte
+	bytes  int
+	closed bool
+	stats  client.BulkIndexerStats
+}
+
+// newBulkIndexer creates a bulk indexer for the client
+func newBulkIndexer(cli *Client, cfg client.BulkIndexerConfig) *bulkIndexer {
+	return &bulkIndexer{client: cli, cfg: cfg}
+}
+
+// Add buffers an item until the indexer is closed, applying the buffered items straight away if their documents
+// have reached the flush size. The body of the item is read straight away, as the fake has no queue.
+func (b *bulkIndexer) Add(ctx context.Context, item clientutil.Item) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return errors.New("bulk indexer is closed")
+	}
+
+	document, err := io.ReadAll(item.Body)
+	if err != nil {
+		b.mu.Unlock()
+		return fmt.Errorf("failed to read body of document %s: %w", item.DocumentID, err)
+	}
+
+	index := item.Index
+	if index == "" {
+		index = b.cfg.Index
+	}
+
+	b.items = append(b.items, esutil.BulkIndexerItem{
+		Action:     string(item.Action),
+		Index:      index,
+		DocumentID: item.DocumentID,
+		Body:       bytes.NewReader(document),
+		OnSuccess:  item.OnSuccess,
+		OnFailure:  item.OnFailure,
+	})
+	b.bodies = append(b.bodies, document)
+	b.bytes += len(document)
+	b.stats.NumAdded++
+	full := b.cfg.FlushBytes > 0 && b.bytes >= b.cfg.FlushBytes
+	b.mu.Unlock()
+
+	if full {
+		b.flush(ctx, false)
+	}
+
+	return nil
+}
+
+// Close applies every buffered item in the order it was added, calling its success or failure callback.
+func (b *bulkIndexer) Close(ctx context.Context) error {
+	b.flush(ctx, true)
+
+	return nil
+}
+
+// Stats returns the counts of items handled by the indexer since it was created.
+func (b *bulkIndexer) Stats() client.BulkIndexerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.stats
+}
+
+// flush applies the buffered items as a single request, closing the indexer if closing is set. As with the client
+// libraries, the flush is started before the items are taken from the buffer.
+func (b *bulkIndexer) flush(ctx context.Context, closing bool) {
+	b.mu.Lock()
+	b.closed = b.closed || closing
+	empty := len(b.items) == 0
+	b.mu.Unlock()
+
+	if empty {
+		return
+	}
+
+	if b.cfg.OnFlushStart != nil {
+		ctx = b.cfg.OnFlushStart(ctx)
+	}
+	if b.cfg.OnFlushEnd != nil {
+		defer b.cfg.OnFlushEnd(ctx)
+	}
+
+	b.mu.Lock()
+	items, bodies := b.items, b.bodies
+	b.items, b.bodies, b.bytes = nil, nil, 0
+	if len(items) > 0 {
+		b.stats.NumRequests++
+	}
+	b.mu.Unlock()
+
+	for i, item := range items {
+		b.client.mu.Lock()
+		res := b.client.applyBulkAction(bulkAction{
+			Action:     item.Action,
+			Index:      item.Index,
+			DocumentID: item.DocumentID,
+			Body:       bodies[i],
+		})
+		b.client.mu.Unlock()
+
+		resItem := esutil.BulkIndexerResponseItem{
+			Index:      res.Index,
+			DocumentID: res.DocumentID,
+			Version:    res.Version,
+			Result:     res.Result,
+			Status:     res.Status,
+			SeqNo:      res.SeqNo,
+			PrimTerm:   res.PrimaryTerm,
+		}
+
+		// As with esutil, any status above 201 is reported as a failure
+		if res.Error != nil || res.Status > http.StatusCreated {
+			if res.Error != nil {
+				resItem.Error.Type = res.Error.Type
+				resItem.Error.Reason = res.Error.Reason
+			}
+			b.count(func(stats *client.BulkIndexerStats) { stats.NumFailed++ })
+			if item.OnFailure != nil {
+				item.OnFailure(ctx, item, resItem, nil)
+			}
+			continue
+		}
+
+		b.count(func(stats *client.BulkIndexerStats) {
+			stats.NumFlushed++
+			switch client.BulkIndexerAction(item.Action) {
+			case Index:
+				stats.NumIndexed++
+			case Create:
+				stats.NumCreated++
+			case Update:
+				stats.NumUpdated++
+			case Delete:
+				stats.NumDeleted++
+			}
+		})
+		if item.OnSuccess != nil {
+			item.OnSuccess(ctx, item, resItem)
+		}
+	}
+}
+
+// count updates the stats of the indexer
+func (b *bulkIndexer) count(update func(stats *client.BulkIndexerStats)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	update(&b.stats)
+}
+
+// parseBulkPayload parses a newline delimited bulk request body into its actions
+func parseBulkPayload(defaultIndex string, payload []byte) ([]bulkAction, error) {
+	var actions []bulkAction
+
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(payload)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var meta map[string]struct {
+			Index         string             `json:"_index"`
+			DocumentID    string             `json:"_id"`
+			IfSeqNo       *int64             `json:"if_seq_no"`
+			IfPrimaryTerm *int64             `json:"if_primary_term"`
+			Version       *int64             `json:"version"`
+			VersionType   client.VersionType `json:"version_type"`
+		}
+		if err := json.Unmarshal(line, &meta); err != nil {
+			return nil, fmt.Errorf("malformed action/metadata line: %w", err)
+		}
+		if len(meta) != 1 {
+			return nil, errors.New("malformed action/metadata line, expected exactly one action")
+		}
+
+		for name, m := range meta {
+			action := bulkAction{
+				Action:     name,
+				Index:      m.Index,
+				DocumentID: m.DocumentID,
+				ConcurrencyControl: client.ConcurrencyControl{
+					IfSeqNo:       m.IfSeqNo,
+					IfPrimaryTerm: m.IfPrimaryTerm,
+					Version:       m.Version,
+					VersionType:   m.VersionType,
+				},
+			}
+			if action.Index == "" {
+				action.Index = defaultIndex
+			}
+
+			if name != string(Delete) {
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("missing source for %s action", name)
+				}
+				action.Body = append([]byte{}, scanner.Bytes()...)
+			}
+			actions = append(actions, action)
+		}
+	}
+
+	return actions, scanner.Err()
+}
+
+// applyBulkAction applies a single bulk action. It must be called with the write lock held.
+func (cli *Client) applyBulkAction(action bulkAction) bulkResponseItem {
+	res := bulkResponseItem{Index: action.Index, DocumentID: action.DocumentID}
+	fail := func(status int, errType, reason string) bulkResponseItem {
+		res.Status = status
+		res.Error = &bulkItemError{Type: errType, Reason: reason}
+		return res
+	}
+
+	if action.Index == "" {
+		return fail(http.StatusBadRequest, "action_request_validation_exception", "index is missing")
+	}
+
+	switch client.BulkIndexerAction(action.Action) {
+	case Create, Index:
+		idx, err := cli.writeIndex(action.Index)
+		if err != nil {
+			return fail(http.StatusBadRequest, "illegal_argument_exception", err.Error())
+		}
+
+		if action.DocumentID == "" {
+			action.DocumentID = idx.nextID()
+			res.DocumentID = action.DocumentID
+		}
+
+		existing, exists := idx.docs[action.DocumentID]
+		if exists && action.Action == string(Create) {
+			return fail(http.StatusConflict, "version_conflict_engine_exception",
+				fmt.Sprintf("[%s]: version conflict, document already exists", action.DocumentID))
+		}
+
+		if reason, ok := checkConcurrency(action.DocumentID, existing, action.ConcurrencyControl); !ok {
+			return fail(http.StatusConflict, "version_conflict_engine_exception", reason)
+		}
+
+		doc, err := idx.put(action.DocumentID, action.Body)
+		if err != nil {
+			return fail(http.StatusBadRequest, "mapper_parsing_exception", "failed to parse")
+		}
+		if action.Version != nil {
+			doc.version = *action.Version
+		}
+
+		res.Status, res.Result = http.StatusCreated, "created"
+		if exists {
+			res.Status, res.Result = http.StatusOK, "updated"
+		}
+		res.Version, res.SeqNo, res.PrimaryTerm = doc.version, doc.seqNo, primaryTerm
+	case Update:
+		idx, ok := cli.lookupIndex(action.Index)
+		if !ok {
+			return fail(http.StatusNotFound, "index_not_found_exception", "no such index ["+action.Index+"]")
+		}
+
+		doc, ok := idx.docs[action.DocumentID]
+		if !ok {
+			return fail(http.StatusNotFound, "document_missing_exception",
+				fmt.Sprintf("[%s]: document missing", action.DocumentID))
+		}
+
+		if reason, ok := checkConcurrency(action.DocumentID, doc, action.ConcurrencyControl); !ok {
+			return fail(http.StatusConflict, "version_conflict_engine_exception", reason)
+		}
+
+		var update struct {
+			Doc map[string]interface{} `json:"doc"`
+		}
+		if err := json.Unmarshal(action.Body, &update); err != nil || update.Doc == nil {
+			return fail(http.StatusBadRequest, "action_request_validation_exception", "doc is missing")
+		}
+
+		doc, err := idx.put(action.DocumentID, mustMarshal(mergeFields(doc.fields, update.Doc)))
+		if err != nil {
+			return fail(http.StatusBadRequest, "mapper_parsing_exception", "failed to parse")
+		}
+
+		res.Status, res.Result = http.StatusOK, "updated"
+		res.Version, res.SeqNo, res.PrimaryTerm = doc.version, doc.seqNo, primaryTerm
+	case Delete:
+		idx, ok := cli.lookupIndex(action.Index)
+		if ok {
+			if reason, ok := checkConcurrency(action.DocumentID, idx.docs[action.DocumentID], action.ConcurrencyControl); !ok {
+				return fail(http.StatusConflict, "version_conflict_engine_exception", reason)
+			}
+		}
+		if !ok || !idx.remove(action.DocumentID) {
+			res.Status, res.Result = http.StatusNotFound, "not_found"
+			return res
+		}
+
+		res.Status, res.Result = http.StatusOK, "deleted"
+	default:
+		return fail(http.StatusBadRequest, "illegal_argument_exception",
+			fmt.Sprintf("Malformed action/metadata line, expected one of [create, delete, index, update] but found [%s]", action.Action))
+	}
+
+	return res
+}
@@ -0,0 +1,1548 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// MsgHealthy Check message returned by the fake client Checker
+const MsgHealthy = "fake elasticsearch client is healthy"
+
+// Ensure, that Client does implement client.Client.
+var _ client.Client = &Client{}
+
+// Client is a stateful in-memory implementation of client.Client, intended for use in tests
+// that need to assert on the behaviour of a service rather than on scripted responses.
+//
+// Documents are stored per index in insertion order and searches are evaluated against a
+// subset of the query DSL (see Search). It is safe for concurrent use.
+type Client struct {
+	mu           sync.RWMutex
+	indices      map[string]*index
+	aliases      map[string]map[string]aliasProperties
+	bulkIndexers clientutil.BulkIndexerRegistry
+	tasks        map[string]*client.TaskResponse
+	taskSeq      int64
+	scrolls      map[string]*scroll
+	scrollSeq    int
+	pits         map[string][]string
+	pitSeq       int
+}
+
+type index struct {
+	name     string
+	settings json.RawMessage
+	docs     map[string]*document
+	order    []string
+	idSeq    int
+	seqNo    int64
+}
+
+// aliasProperties are the properties of an alias for one of its indices, in the shape used by the index APIs
+type aliasProperties struct {
+	Filter        json.RawMessage `json:"filter,omitempty"`
+	IndexRouting  string          `json:"index_routing,omitempty"`
+	SearchRouting string          `json:"search_routing,omitempty"`
+	IsWriteIndex  *bool           `json:"is_write_index,omitempty"`
+}
+
+type document struct {
+	id      string
+	source  json.RawMessage
+	fields  map[string]interface{}
+	version int64
+	seqNo   int64
+}
+
+// primaryTerm is the primary term of every document, as the fake has no shards to fail over
+const primaryTerm = 1
+
+// NewClient returns a new, empty, fake client
+func NewClient() *Client {
+	return &Client{
+		indices: make(map[string]*index),
+		aliases: make(map[string]map[string]aliasProperties),
+		tasks:   make(map[string]*client.TaskResponse),
+		scrolls: make(map[string]*scroll),
+		pits:    make(map[string][]string),
+	}
+}
+
+// Checker always reports the fake client as healthy
+func (cli *Client) Checker(_ context.Context, state *health.CheckState) error {
+	if state == nil {
+		state = &health.CheckState{}
+	}
+
+	return state.Update(health.StatusOK, MsgHealthy, http.StatusOK)
+}
+
+// CreateIndex creates an empty index, failing if the index or an alias of the same name already exists.
+func (cli *Client) CreateIndex(_ context.Context, indexName string, indexSettings []byte) error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	if _, ok := cli.indices[indexName]; ok {
+		return newStatusError("error occured while trying to create index", http.StatusBadRequest,
+			"resource_already_exists_exception", fmt.Sprintf("index [%s] already exists", indexName), indexName)
+	}
+
+	if _, ok := cli.aliases[indexName]; ok {
+		return newStatusError("error occured while trying to create index", http.StatusBadRequest,
+			"invalid_index_name_exception", fmt.Sprintf("Invalid index name [%s], already exists as alias", indexName), indexName)
+	}
+
+	cli.indices[indexName] = newIndex(indexName, indexSettings)
+
+	return nil
+}
+
+// DeleteIndex deletes an index.
+func (cli *Client) DeleteIndex(ctx context.Context, indexName string) error {
+	return cli.DeleteIndices(ctx, []string{indexName})
+}
+
+// DeleteIndices deletes the given indices and any aliases pointing to them.
+func (cli *Client) DeleteIndices(_ context.Context, indices []string) error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	names, err := cli.resolve(indices, false)
+	if err != nil {
+		return newStatusError("error occured while trying to delete index", http.StatusNotFound,
+			"index_not_found_exception", "no such index ["+err.Error()+"]", err.Error())
+	}
+
+	cli.removeIndices(names)
+
+	return nil
+}
+
+// removeIndices deletes the indices and removes them from their aliases. It must be called with the write lock held.
+func (cli *Client) removeIndices(names []string) {
+	for _, name := range names {
+		delete(cli.indices, name)
+		for alias, members := range cli.aliases {
+			delete(members, name)
+			if len(members) == 0 {
+				delete(cli.aliases, alias)
+			}
+		}
+	}
+}
+
+// GetAlias returns the aliases of every index, in the same shape as the get alias API.
+func (cli *Client) GetAlias(_ context.Context) ([]byte, error) {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	result := make(map[string]interface{}, len(cli.indices))
+	for name := range cli.indices {
+		result[name] = map[string]interface{}{"aliases": cli.aliasesOf(name)}
+	}
+
+	return json.Marshal(result)
+}
+
+// GetIndices returns information about the indices matching the given patterns.
+func (cli *Client) GetIndices(_ context.Context, indexPatterns []string) ([]byte, error) {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	names, err := cli.resolve(indexPatterns, false)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to retrieve indices", http.StatusNotFound,
+			"index_not_found_exception", "no such index ["+err.Error()+"]", err.Error())
+	}
+
+	result := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		settings := cli.indices[name].settings
+		if len(settings) == 0 {
+			settings = json.RawMessage(`{}`)
+		}
+		result[name] = map[string]interface{}{
+			"aliases":  cli.aliasesOf(name),
+			"settings": settings,
+		}
+	}
+
+	return json.Marshal(result)
+}
+
+// UpdateAliases atomically removes an alias from removeIndices and adds it to addIndices.
+func (cli *Client) UpdateAliases(ctx context.Context, alias string, removeIndices, addIndices []string) error {
+	return cli.UpdateAliasActions(ctx, client.AliasActions(alias, removeIndices, addIndices))
+}
+
+// UpdateAliasActions applies the alias actions atomically: if any action fails, none of them are applied.
+// Alias filters and routing are recorded, and returned by GetAlias and GetIndices, but are not applied to searches.
+func (cli *Client) UpdateAliasActions(_ context.Context, actions []client.AliasAction) error {
+	const msg = "error occured while trying to update aliases"
+
+	if _, err := clientutil.NewAliasActionsBody(actions); err != nil {
+		return newStatusError(msg, http.StatusBadRequest, "action_request_validation_exception", err.Error(), "")
+	}
+
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	// The actions are applied to copies, which only replace the current state once every action has succeeded
+	indices, aliases := cli.indices, cli.aliases
+	cli.indices = make(map[string]*index, len(indices))
+	for name, idx := range indices {
+		cli.indices[name] = idx
+	}
+	cli.aliases = make(map[string]map[string]aliasProperties, len(aliases))
+	for alias, members := range aliases {
+		cli.aliases[alias] = make(map[string]aliasProperties, len(members))
+		for name, props := range members {
+			cli.aliases[alias][name] = props
+		}
+	}
+
+	for _, action := range actions {
+		if err := cli.applyAliasAction(action); err != nil {
+			cli.indices, cli.aliases = indices, aliases
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyAliasAction applies a single, valid, alias action. It must be called with the write lock held.
+func (cli *Client) applyAliasAction(action client.AliasAction) error {
+	const msg = "error occured while trying to update aliases"
+
+	names, err := cli.resolve(action.Indices, false)
+	if err != nil {
+		return newStatusError(msg, http.StatusNotFound, "index_not_found_exception", "no such index ["+err.Error()+"]", err.Error())
+	}
+
+	switch action.Type {
+	case client.AliasAdd:
+		props := aliasProperties{
+			Filter:        action.Filter,
+			IndexRouting:  action.IndexRouting,
+			SearchRouting: action.SearchRouting,
+			IsWriteIndex:  action.IsWriteIndex,
+		}
+		if props.IndexRouting == "" {
+			props.IndexRouting = action.Routing
+		}
+		if props.SearchRouting == "" {
+			props.SearchRouting = action.Routing
+		}
+
+		for _, alias := range action.Aliases {
+			if _, ok := cli.indices[alias]; ok {
+				return newStatusError(msg, http.StatusBadRequest, "invalid_alias_name_exception",
+					fmt.Sprintf("Invalid alias name [%s]: an index or data stream exists with the same name as the alias", alias), alias)
+			}
+			if cli.aliases[alias] == nil {
+				cli.aliases[alias] = make(map[string]aliasProperties)
+			}
+			for _, name := range names {
+				cli.aliases[alias][name] = props
+			}
+		}
+	case client.AliasRemove:
+		for _, alias := range action.Aliases {
+			for _, name := range names {
+				if _, ok := cli.aliases[alias][name]; !ok {
+					return newStatusError(msg, http.StatusNotFound, "aliases_not_found_exception", "aliases ["+alias+"] missing", name)
+				}
+				delete(cli.aliases[alias], name)
+			}
+			if len(cli.aliases[alias]) == 0 {
+				delete(cli.aliases, alias)
+			}
+		}
+	case client.AliasRemoveIndex:
+		for _, name := range action.Indices {
+			if cli.isAlias(name) {
+				return newStatusError(msg, http.StatusBadRequest, "illegal_argument_exception",
+					"The provided expression ["+name+"] matches an alias, specify the corresponding concrete indices instead.", name)
+			}
+		}
+		cli.removeIndices(names)
+	}
+
+	return nil
+}
+
+// AddDocument adds a document to the index specified, creating the index if it does not exist.
+// The upsert and concurrency control options behave as with the real clients, while the refresh, routing and
+// pipeline options are ignored.
+func (cli *Client) AddDocument(ctx context.Context, indexName, documentID string, document []byte, options *client.AddDocumentOptions) (*client.WriteResult, error) {
+	if options == nil {
+		options = &client.AddDocumentOptions{}
+	}
+
+	if options.Upsert && options.UpsertMode == client.UpsertMerge {
+		if options.Pipeline != "" {
+			return nil, esError.StatusError{
+				Err: errors.New("pipeline option cannot be used with a merge upsert"),
+			}
+		}
+
+		return cli.UpdateDocument(ctx, indexName, documentID, client.DocumentUpdate{Doc: document, DocAsUpsert: true}, &client.UpdateDocumentOptions{
+			Refresh:            options.Refresh,
+			Routing:            options.Routing,
+			ConcurrencyControl: options.ConcurrencyControl,
+		})
+	}
+
+	if !options.Upsert && (options.IfSeqNo != nil || options.IfPrimaryTerm != nil) {
+		return nil, esError.StatusError{
+			Err: errors.New("if_seq_no and if_primary_term cannot be used when creating a document, use the upsert option"),
+		}
+	}
+
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	idx, err := cli.writeIndex(indexName)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to add document", http.StatusBadRequest,
+			"illegal_argument_exception", err.Error(), indexName)
+	}
+
+	if documentID == "" {
+		documentID = idx.nextID()
+	}
+
+	existing, exists := idx.docs[documentID]
+	if exists && !options.Upsert {
+		return nil, newStatusError("error occured while trying to add document", http.StatusConflict,
+			"version_conflict_engine_exception", fmt.Sprintf("[%s]: version conflict, document already exists", documentID), indexName)
+	}
+
+	if reason, ok := checkConcurrency(documentID, existing, options.ConcurrencyControl); !ok {
+		return nil, newStatusError("error occured while trying to add document", http.StatusConflict,
+			"version_conflict_engine_exception", reason, indexName)
+	}
+
+	doc, err := idx.put(documentID, document)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to add document", http.StatusBadRequest,
+			"mapper_parsing_exception", "failed to parse", indexName)
+	}
+
+	if options.Version != nil {
+		doc.version = *options.Version
+	}
+
+	if exists {
+		return writeResult(idx.name, doc, "updated"), nil
+	}
+	return writeResult(idx.name, doc, "created"), nil
+}
+
+// DeleteDocument deletes a document from the given index using the document ID (e.g. URI).
+func (cli *Client) DeleteDocument(_ context.Context, indexName, documentID string, options *client.DeleteDocumentOptions) (*client.WriteResult, error) {
+	if options == nil {
+		options = &client.DeleteDocumentOptions{}
+	}
+
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	idx, ok := cli.lookupIndex(indexName)
+	if !ok {
+		return nil, newStatusError("delete request failed", http.StatusNotFound,
+			"index_not_found_exception", "no such index ["+indexName+"]", indexName)
+	}
+
+	doc := idx.docs[documentID]
+	if reason, ok := checkConcurrency(documentID, doc, options.ConcurrencyControl); !ok {
+		return nil, newStatusError("delete request failed", http.StatusConflict,
+			"version_conflict_engine_exception", reason, indexName)
+	}
+
+	if doc == nil {
+		body := fmt.Sprintf(`{"_index":%q,"_id":%q,"result":"not_found"}`, indexName, documentID)
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("delete request failed: %w", esError.NewESError("elasticsearch", http.StatusNotFound, []byte(body))),
+			Code: http.StatusNotFound,
+		}
+	}
+
+	idx.remove(documentID)
+
+	deleted := &document{id: documentID, version: doc.version + 1, seqNo: idx.nextSeqNo()}
+	if options.Version != nil {
+		deleted.version = *options.Version
+	}
+
+	return writeResult(idx.name, deleted, "deleted"), nil
+}
+
+// UpdateDocument partially updates a document with the fields of update.Doc. As with elasticsearch, objects are
+// merged recursively and an update that does not change the document has the "noop" result. If the document does
+// not exist, update.Upsert is indexed in its place. Scripts cannot be run by the fake and are rejected.
+func (cli *Client) UpdateDocument(_ context.Context, indexName, documentID string, update client.DocumentUpdate, options *client.UpdateDocumentOptions) (*client.WriteResult, error) {
+	if options == nil {
+		options = &client.UpdateDocumentOptions{}
+	}
+
+	if options.Version != nil {
+		return nil, esError.StatusError{
+			Err: errors.New("external versioning cannot be used when updating a document, use if_seq_no and if_primary_term"),
+		}
+	}
+
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	const msg = "error occured while trying to update document"
+
+	if update.Script != nil {
+		return nil, newStatusError(msg, http.StatusBadRequest, "illegal_argument_exception",
+			"scripts are not supported by the fake client", indexName)
+	}
+
+	var idx *index
+	if update.DocAsUpsert || update.Upsert != nil {
+		var err error
+		if idx, err = cli.writeIndex(indexName); err != nil {
+			return nil, newStatusError(msg, http.StatusBadRequest, "illegal_argument_exception", err.Error(), indexName)
+		}
+	} else {
+		var ok bool
+		if idx, ok = cli.lookupIndex(indexName); !ok {
+			return nil, newStatusError(msg, http.StatusNotFound, "index_not_found_exception", "no such index ["+indexName+"]", indexName)
+		}
+	}
+
+	existing, exists := idx.docs[documentID]
+	if reason, ok := checkConcurrency(documentID, existing, options.ConcurrencyControl); !ok {
+		return nil, newStatusError(msg, http.StatusConflict, "version_conflict_engine_exception", reason, indexName)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(update.Doc, &fields); err != nil || fields == nil {
+		return nil, newStatusError(msg, http.StatusBadRequest, "action_request_validation_exception",
+			"Validation Failed: 1: script or doc is missing;", indexName)
+	}
+
+	if !exists {
+		source := update.Upsert
+		if update.DocAsUpsert {
+			source = update.Doc
+		}
+		if source == nil {
+			return nil, newStatusError(msg, http.StatusNotFound, "document_missing_exception",
+				fmt.Sprintf("[_doc][%s]: document missing", documentID), indexName)
+		}
+
+		doc, err := idx.put(documentID, source)
+		if err != nil {
+			return nil, newStatusError(msg, http.StatusBadRequest, "mapper_parsing_exception", "failed to parse", indexName)
+		}
+		return writeResult(idx.name, doc, "created"), nil
+	}
+
+	merged := mergeFields(existing.fields, fields)
+	if reflect.DeepEqual(merged, existing.fields) {
+		return writeResult(idx.name, existing, "noop"), nil
+	}
+
+	doc, err := idx.put(documentID, mustMarshal(merged))
+	if err != nil {
+		return nil, newStatusError(msg, http.StatusBadRequest, "mapper_parsing_exception", "failed to parse", indexName)
+	}
+
+	return writeResult(idx.name, doc, "updated"), nil
+}
+
+// UpdateByQuery reindexes every document matching update.Query in place, incrementing its version. As the fake
+// cannot run scripts an update with a script is rejected. Updates always run synchronously; when WaitForCompletion
+// is false only a task ID is returned, as elasticsearch would.
+func (cli *Client) UpdateByQuery(_ context.Context, indices []string, update client.UpdateByQuery, options *client.UpdateByQueryOptions) (*client.BulkByScrollResponse, error) {
+	if options == nil {
+		options = &client.UpdateByQueryOptions{}
+	}
+
+	const msg = "update-by-query failed"
+
+	if update.Script != nil {
+		return nil, newStatusError(msg, http.StatusBadRequest, "illegal_argument_exception",
+			"scripts are not supported by the fake client", "")
+	}
+
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return nil, newStatusError(msg, http.StatusBadRequest, "parsing_exception", err.Error(), "")
+	}
+	req, err := parseSearchRequest(body)
+	if err != nil {
+		return nil, newStatusError(msg, http.StatusBadRequest, "parsing_exception", err.Error(), "")
+	}
+
+	names, err := cli.resolve(indices, true)
+	if err != nil {
+		return nil, newStatusError(msg, http.StatusNotFound,
+			"index_not_found_exception", "no such index ["+err.Error()+"]", err.Error())
+	}
+
+	res := &client.BulkByScrollResponse{Failures: []client.ByQueryFailure{}}
+	for _, name := range names {
+		idx := cli.indices[name]
+		for _, id := range append([]string{}, idx.order...) {
+			if options.MaxDocs != nil && res.Total >= int64(*options.MaxDocs) {
+				break
+			}
+
+			matched, err := matches(req.Query, idx.docs[id])
+			if err != nil {
+				return nil, newStatusError(msg, http.StatusBadRequest, "parsing_exception", err.Error(), name)
+			}
+			if !matched {
+				continue
+			}
+
+			if _, err := idx.put(id, idx.docs[id].source); err != nil {
+				return nil, newStatusError(msg, http.StatusBadRequest, "mapper_parsing_exception", "failed to parse", name)
+			}
+			res.Total++
+			res.Updated++
+		}
+	}
+	if res.Total > 0 {
+		res.Batches = 1
+	}
+
+	if options.WaitForCompletion != nil && !*options.WaitForCompletion {
+		return cli.newTaskResponse("indices:data/write/reindex", res), nil
+	}
+
+	return res, nil
+}
+
+// GetDocument gets a document by ID. If the document does not exist the result has Found set to false.
+// The routing and realtime options are ignored, as every write is immediately visible.
+func (cli *Client) GetDocument(_ context.Context, indexName, documentID string, options *client.GetDocumentOptions) (*client.GetResult, error) {
+	if options == nil {
+		options = &client.GetDocumentOptions{}
+	}
+
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	idx, ok := cli.lookupIndex(indexName)
+	if !ok {
+		return nil, newStatusError("error occured while trying to get document", http.StatusNotFound,
+			"index_not_found_exception", "no such index ["+indexName+"]", indexName)
+	}
+
+	return idx.get(documentID, options.SourceIncludes, options.SourceExcludes), nil
+}
+
+// MultiGet gets several documents by ID, which may be in different indices, in a single request.
+func (cli *Client) MultiGet(_ context.Context, docs []client.MultiGetDocument, options *client.MultiGetOptions) (*client.MultiGetResponse, error) {
+	if options == nil {
+		options = &client.MultiGetOptions{}
+	}
+
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	res := &client.MultiGetResponse{Docs: make([]client.MultiGetItem, 0, len(docs))}
+	for _, doc := range docs {
+		idx, ok := cli.lookupIndex(doc.Index)
+		if !ok {
+			res.Docs = append(res.Docs, client.MultiGetItem{
+				GetResult: client.GetResult{Index: doc.Index, ID: doc.ID},
+				Error: &esError.ErrorCause{
+					Type:   "index_not_found_exception",
+					Reason: "no such index [" + doc.Index + "]",
+					Index:  doc.Index,
+				},
+			})
+			continue
+		}
+
+		includes, excludes := options.SourceIncludes, options.SourceExcludes
+		if len(doc.SourceIncludes) > 0 || len(doc.SourceExcludes) > 0 {
+			includes, excludes = doc.SourceIncludes, doc.SourceExcludes
+		}
+
+		res.Docs = append(res.Docs, client.MultiGetItem{GetResult: *idx.get(doc.ID, includes, excludes)})
+	}
+
+	return res, nil
+}
+
+// DeleteDocumentByQuery deletes documents from the given index using the provided search query.
+func (cli *Client) DeleteDocumentByQuery(_ context.Context, search client.Search) error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	req, err := parseSearchRequest(search.Query)
+	if err != nil {
+		return newStatusError("delete-by-query failed", http.StatusBadRequest, "parsing_exception", err.Error(), "")
+	}
+
+	names, err := cli.resolve(splitIndices(search.Header.Index), true)
+	if err != nil {
+		return newStatusError("delete-by-query failed", http.StatusNotFound,
+			"index_not_found_exception", "no such index ["+err.Error()+"]", err.Error())
+	}
+
+	for _, name := range names {
+		idx := cli.indices[name]
+		for _, id := range append([]string{}, idx.order...) {
+			matched, err := matches(req.Query, idx.docs[id])
+			if err != nil {
+				return newStatusError("delete-by-query failed", http.StatusBadRequest, "parsing_exception", err.Error(), name)
+			}
+			if matched {
+				idx.remove(id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Search returns the documents matching a query, in the same shape as the search API.
+//
+// The supported subset of the request body is: query (match_all, term, terms, match, bool, range,
+// exists and ids), from, size, sort on document fields, pit, search_after and slice. Aggregations and
+// other options are ignored.
+func (cli *Client) Search(_ context.Context, search client.Search) ([]byte, error) {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	res, err := cli.search(search)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(res)
+}
+
+// MultiSearch executes several searches, returning each response in order.
+func (cli *Client) MultiSearch(_ context.Context, searches []client.Search, _ *client.QueryParams) ([]byte, error) {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	responses := make([]interface{}, 0, len(searches))
+	for _, search := range searches {
+		res, err := cli.search(search)
+		if err != nil {
+			responses = append(responses, map[string]interface{}{
+				"error":  map[string]interface{}{"type": "search_phase_execution_exception", "reason": err.Error()},
+				"status": esError.ErrorStatus(err),
+			})
+			continue
+		}
+		responses = append(responses, res)
+	}
+
+	return json.Marshal(map[string]interface{}{"responses": responses})
+}
+
+// Count returns the number of documents, across all indices, matching a query.
+func (cli *Client) Count(_ context.Context, count client.Count) ([]byte, error) {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	hits, err := cli.matching(client.Search{Query: count.Query})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]interface{}{"count": len(hits)})
+}
+
+// CountIndices returns number of documents in the given indices.
+func (cli *Client) CountIndices(_ context.Context, indices []string) ([]byte, error) {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	hits, err := cli.matching(client.Search{Header: client.Header{Index: strings.Join(indices, ",")}})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]interface{}{"count": len(hits)})
+}
+
+// Explain reports whether the given document matches a query.
+func (cli *Client) Explain(_ context.Context, documentID string, search client.Search) ([]byte, error) {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	req, err := parseSearchRequest(search.Query)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to call explain api", http.StatusBadRequest,
+			"parsing_exception", err.Error(), search.Header.Index)
+	}
+
+	names, err := cli.resolve([]string{search.Header.Index}, false)
+	if err != nil || len(names) != 1 {
+		return nil, newStatusError("error occured while trying to call explain api", http.StatusNotFound,
+			"index_not_found_exception", "no such index ["+search.Header.Index+"]", search.Header.Index)
+	}
+
+	doc, ok := cli.indices[names[0]].docs[documentID]
+	if !ok {
+		body := fmt.Sprintf(`{"_index":%q,"_id":%q,"matched":false}`, names[0], documentID)
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to call explain api: %w", fmt.Errorf("error response from elasticsearch: %s", body)),
+			Code: http.StatusNotFound,
+		}
+	}
+
+	matched, err := matches(req.Query, doc)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to call explain api", http.StatusBadRequest,
+			"parsing_exception", err.Error(), names[0])
+	}
+
+	return json.Marshal(map[string]interface{}{"_index": names[0], "_id": documentID, "matched": matched})
+}
+
+// BulkUpdate applies a newline delimited set of bulk actions, returning a bulk API shaped response. The esURL
+// parameter is ignored, as the fake has no address to check it against.
+//
+// Deprecated: use Bulk with actions added to a client.BulkRequestBuilder instead.
+//
+//nolint:revive // context of esURL is important here.
+func (cli *Client) BulkUpdate(_ context.Context, indexName, esURL string, payload []byte) ([]byte, error) {
+	return cli.bulk(indexName, payload)
+}
+
+// Bulk applies the actions added to bulk, a request at a time as split by the builder, returning the result of
+// every action. As with the real clients, the response is returned with an error matching client.ErrBulkItemsFailed
+// if any action failed. Routing, refresh, pipeline and timeout are ignored.
+func (cli *Client) Bulk(ctx context.Context, bulk *client.BulkRequestBuilder, options *client.BulkOptions) (*client.BulkResponse, error) {
+	if options == nil {
+		options = &client.BulkOptions{}
+	}
+
+	return clientutil.SendBulkRequests(ctx, bulk, func(_ context.Context, body []byte) ([]byte, error) {
+		return cli.bulk(options.Index, body)
+	})
+}
+
+// bulk applies a newline delimited set of bulk actions, returning a bulk API shaped response
+func (cli *Client) bulk(indexName string, payload []byte) ([]byte, error) {
+	actions, err := parseBulkPayload(indexName, payload)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to bulk update document", http.StatusBadRequest,
+			"illegal_argument_exception", err.Error(), indexName)
+	}
+
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	hasErrors := false
+	items := make([]map[string]bulkResponseItem, 0, len(actions))
+	for _, action := range actions {
+		res := cli.applyBulkAction(action)
+		if res.Error != nil {
+			hasErrors = true
+		}
+		items = append(items, map[string]bulkResponseItem{action.Action: res})
+	}
+
+	return json.Marshal(map[string]interface{}{"took": 0, "errors": hasErrors, "items": items})
+}
+
+// ClusterHealth returns a green health, as every shard of the fake is always allocated. If any of the given indices
+// does not exist the health is red and, as with elasticsearch, waiting for a status times out.
+func (cli *Client) ClusterHealth(_ context.Context, indices []string, options *client.ClusterHealthOptions) (*client.ClusterHealthResponse, error) {
+	if options == nil {
+		options = &client.ClusterHealthOptions{}
+	}
+
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	res := &client.ClusterHealthResponse{
+		ClusterName:   "fake",
+		Status:        client.ClusterStatusGreen,
+		NumberOfNodes: 1,
+	}
+	names, err := cli.resolve(indices, true)
+	if err != nil {
+		res.Status = client.ClusterStatusRed
+		res.TimedOut = options.WaitForStatus != ""
+	}
+	res.ActivePrimaryShards, res.ActiveShards = len(names), len(names)
+
+	return res, nil
+}
+
+// RefreshIndices checks that the given indices exist. Every write to the fake is visible straight away, so there
+// is nothing to refresh.
+func (cli *Client) RefreshIndices(_ context.Context, indices []string) error {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	if _, err := cli.resolve(indices, true); err != nil {
+		return newStatusError("error occured while trying to refresh indices", http.StatusNotFound,
+			"index_not_found_exception", "no such index ["+err.Error()+"]", err.Error())
+	}
+
+	return nil
+}
+
+// Reindex copies the documents matching the source query into the destination index, creating it if needed.
+// Remote sources and scripts are not supported. As with UpdateByQuery, the copy always runs synchronously.
+func (cli *Client) Reindex(_ context.Context, reindex client.Reindex, options *client.ReindexOptions) (*client.BulkByScrollResponse, error) {
+	if options == nil {
+		options = &client.ReindexOptions{}
+	}
+
+	const msg = "error occured while trying to reindex"
+
+	if reindex.Source.Remote != nil || reindex.Script != nil {
+		return nil, newStatusError(msg, http.StatusBadRequest, "illegal_argument_exception",
+			"remote sources and scripts are not supported by the fake client", reindex.Dest.Index)
+	}
+
+	if len(reindex.Source.Index) == 0 || reindex.Dest.Index == "" {
+		return nil, newStatusError(msg, http.StatusBadRequest, "action_request_validation_exception",
+			"Validation Failed: 1: source and destination indices are required;", "")
+	}
+
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	hits, err := cli.matching(client.Search{
+		Header: client.Header{Index: strings.Join(reindex.Source.Index, ",")},
+		Query:  mustMarshal(map[string]json.RawMessage{"query": reindex.Source.Query}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err := cli.writeIndex(reindex.Dest.Index)
+	if err != nil {
+		return nil, newStatusError(msg, http.StatusBadRequest, "illegal_argument_exception", err.Error(), reindex.Dest.Index)
+	}
+
+	res := &client.BulkByScrollResponse{Failures: []client.ByQueryFailure{}}
+	for _, h := range hits {
+		if options.MaxDocs != nil && res.Total >= int64(*options.MaxDocs) {
+			break
+		}
+		res.Total++
+
+		_, exists := dest.docs[h.doc.id]
+		if exists && reindex.Dest.OpType == "create" {
+			res.VersionConflicts++
+			if !reindex.ProceedOnConflicts {
+				// As with elasticsearch, documents copied before the conflict are left in the destination
+				return nil, newStatusError(msg, http.StatusConflict, "version_conflict_engine_exception",
+					fmt.Sprintf("[%s]: version conflict, document already exists", h.doc.id), dest.name)
+			}
+			continue
+		}
+
+		if _, err := dest.put(h.doc.id, h.doc.source); err != nil {
+			return nil, newStatusError(msg, http.StatusBadRequest, "mapper_parsing_exception", "failed to parse", dest.name)
+		}
+		if exists {
+			res.Updated++
+		} else {
+			res.Created++
+		}
+	}
+	if res.Total > 0 {
+		res.Batches = 1
+	}
+
+	if options.WaitForCompletion != nil && !*options.WaitForCompletion {
+		return cli.newTaskResponse("indices:data/write/reindex", res), nil
+	}
+
+	return res, nil
+}
+
+// newTaskResponse stores the result of a request that is not waited for as a completed task, returning the
+// response holding its ID. It must be called with the write lock held.
+func (cli *Client) newTaskResponse(action string, res *client.BulkByScrollResponse) *client.BulkByScrollResponse {
+	cli.taskSeq++
+	task := client.TaskInfo{
+		Node:              "fake",
+		ID:                cli.taskSeq,
+		Type:              "transport",
+		Action:            action,
+		StartTimeInMillis: time.Now().UnixMilli(),
+		Cancellable:       true,
+	}
+	cli.tasks[task.TaskID()] = &client.TaskResponse{Completed: true, Task: task, Response: res}
+
+	return &client.BulkByScrollResponse{Task: task.TaskID()}
+}
+
+// GetTask returns a task started by a request that was not waited for. As the fake runs every request
+// synchronously, the task has always completed.
+func (cli *Client) GetTask(_ context.Context, taskID string, _ *client.GetTaskOptions) (*client.TaskResponse, error) {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	task, ok := cli.tasks[taskID]
+	if !ok {
+		return nil, newStatusError("error occured while trying to get task", http.StatusNotFound, "resource_not_found_exception",
+			fmt.Sprintf("task [%s] isn't running and hasn't stored its results", taskID), "")
+	}
+
+	res := *task
+	return &res, nil
+}
+
+// ListTasks returns no tasks, as the tasks of the fake have always completed.
+func (cli *Client) ListTasks(_ context.Context, _ *client.ListTasksOptions) (*client.TasksResponse, error) {
+	return &client.TasksResponse{Tasks: []client.TaskInfo{}}, nil
+}
+
+// CancelTask returns a not found error, as the tasks of the fake have always completed.
+func (cli *Client) CancelTask(_ context.Context, taskID string) (*client.TasksResponse, error) {
+	return nil, newStatusError("error occured while trying to cancel task", http.StatusNotFound,
+		"resource_not_found_exception", fmt.Sprintf("task [%s] is not found", taskID), "")
+}
+
+// RethrottleTask returns a not found error, as the tasks of the fake have always completed.
+func (cli *Client) RethrottleTask(_ context.Context, taskID string, _ int) (*client.TasksResponse, error) {
+	return nil, newStatusError("error occured while trying to rethrottle task", http.StatusNotFound,
+		"resource_not_found_exception", fmt.Sprintf("task [%s] is not found", taskID), "")
+}
+
+// Scroll runs a search, returning its first page of hits and the ID of a scroll holding the rest. The hits are
+// those matching when the scroll is opened, as with elasticsearch. Scrolls never expire, so keepAlive is ignored.
+func (cli *Client) Scroll(_ context.Context, search client.Search, _ time.Duration) ([]byte, error) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	req, err := parseSearchRequest(search.Query)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to open scroll", http.StatusBadRequest,
+			"parsing_exception", err.Error(), search.Header.Index)
+	}
+
+	size := defaultSearchSize
+	if req.Size != nil {
+		size = *req.Size
+	}
+
+	// The whole result set is searched for at once, and then returned a page at a time
+	var body map[string]json.RawMessage
+	if len(search.Query) > 0 {
+		if err := json.Unmarshal(search.Query, &body); err != nil {
+			return nil, newStatusError("error occured while trying to open scroll", http.StatusBadRequest,
+				"parsing_exception", err.Error(), search.Header.Index)
+		}
+	}
+	if body == nil {
+		body = make(map[string]json.RawMessage)
+	}
+	body["from"], body["size"] = mustMarshal(0), mustMarshal(math.MaxInt32)
+	search.Query = mustMarshal(body)
+
+	res, err := cli.search(search)
+	if err != nil {
+		return nil, err
+	}
+
+	cli.scrollSeq++
+	scrollID := fmt.Sprintf("fake-scroll-%d", cli.scrollSeq)
+	s := &scroll{res: res, hits: res.Hits.Hits, size: size}
+	cli.scrolls[scrollID] = s
+
+	return json.Marshal(s.page(scrollID))
+}
+
+// ScrollNext returns the next page of hits of a scroll. The page is empty once every hit has been returned.
+func (cli *Client) ScrollNext(_ context.Context, scrollID string, _ time.Duration) ([]byte, error) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	s, ok := cli.scrolls[scrollID]
+	if !ok {
+		return nil, newStatusError("error occured while trying to scroll", http.StatusNotFound,
+			"search_context_missing_exception", "No search context found for id ["+scrollID+"]", "")
+	}
+
+	return json.Marshal(s.page(scrollID))
+}
+
+// ClearScroll clears scrolls. Scrolls that do not exist are ignored.
+func (cli *Client) ClearScroll(_ context.Context, scrollIDs []string) error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	for _, scrollID := range scrollIDs {
+		delete(cli.scrolls, scrollID)
+	}
+
+	return nil
+}
+
+// OpenPointInTime opens a point in time on the given indices. Unlike elasticsearch, searches of the point in time
+// see the documents of the indices as they are when searched rather than when it was opened. Points in time never
+// expire, so keepAlive is ignored.
+func (cli *Client) OpenPointInTime(_ context.Context, indices []string, _ time.Duration) (string, error) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	names, err := cli.resolve(indices, false)
+	if err != nil {
+		return "", newStatusError("error occured while trying to open point in time", http.StatusNotFound,
+			"index_not_found_exception", "no such index ["+err.Error()+"]", err.Error())
+	}
+
+	cli.pitSeq++
+	pitID := fmt.Sprintf("fake-pit-%d", cli.pitSeq)
+	cli.pits[pitID] = names
+
+	return pitID, nil
+}
+
+// ClosePointInTime closes a point in time. Points in time that do not exist are ignored.
+func (cli *Client) ClosePointInTime(_ context.Context, pitID string) error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	delete(cli.pits, pitID)
+
+	return nil
+}
+
+// NewBulkIndexer creates the default bulk indexer of the client, used by BulkIndexAdd, BulkIndexClose and
+// BulkIndexStats. The worker, flush and request settings of the config are ignored, as every item is applied in a
+// single flush when the indexer is closed. An error is returned if the default bulk indexer is still open.
+func (cli *Client) NewBulkIndexer(ctx context.Context, cfg *client.BulkIndexerConfig) error {
+	_, err := cli.openBulkIndexer(ctx, "", cfg)
+	return err
+}
+
+// OpenBulkIndexer creates a bulk indexer registered under name and returns it. As with NewBulkIndexer, its items
+// are applied when it is closed. An error is returned if name is empty or the indexer registered under name is
+// still open.
+func (cli *Client) OpenBulkIndexer(ctx context.Context, name string, cfg *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+	if name == "" {
+		return nil, esError.StatusError{
+			Err: errors.New("bulk indexer name should not be empty"),
+		}
+	}
+
+	return cli.openBulkIndexer(ctx, name, cfg)
+}
+
+func (cli *Client) openBulkIndexer(ctx context.Context, name string, cfg *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+	return cli.bulkIndexers.Open(name, func() (client.BulkIndexer, error) {
+		return clientutil.WrapBulkIndexer(ctx, cfg, func(cfg client.BulkIndexerConfig) (clientutil.Indexer, error) {
+			return newBulkIndexer(cli, cfg), nil
+		})
+	})
+}
+
+// defaultBulkIndexer returns the bulk indexer created by NewBulkIndexer
+func (cli *Client) defaultBulkIndexer() (client.BulkIndexer, error) {
+	bi, ok := cli.bulkIndexers.Get("")
+	if !ok {
+		return nil, esError.StatusError{
+			Err:  errors.New("bulk indexer client should not be nil"),
+			Code: http.StatusInternalServerError,
+		}
+	}
+
+	return bi, nil
+}
+
+// BulkIndexAdd adds an item to the default indexer. Items are applied, and their callbacks called, when the
+// indexer is closed or, if it has a flush size, once their documents reach it.
+func (cli *Client) BulkIndexAdd(
+	ctx context.Context,
+	action client.BulkIndexerAction,
+	index,
+	documentID string,
+	document []byte,
+	onSuccess client.SuccessFunc,
+	onFailure client.FailureFunc,
+) error {
+	bi, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return err
+	}
+
+	return bi.Add(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+// BulkIndexTryAdd adds an item to the default indexer as BulkIndexAdd does, except that it returns an error
+// matching client.ErrBulkIndexerFull straight away, rather than waiting, when the buffer of the indexer is full.
+func (cli *Client) BulkIndexTryAdd(
+	ctx context.Context,
+	action client.BulkIndexerAction,
+	index,
+	documentID string,
+	document []byte,
+	onSuccess client.SuccessFunc,
+	onFailure client.FailureFunc,
+) error {
+	bi, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return err
+	}
+
+	return bi.TryAdd(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+// BulkIndexClose applies all added items and closes the default indexer.
+func (cli *Client) BulkIndexClose(ctx context.Context) error {
+	bi, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return err
+	}
+
+	return bi.Close(ctx)
+}
+
+// BulkIndexStats returns the counts of items handled by the default bulk indexer since it was created.
+func (cli *Client) BulkIndexStats(_ context.Context) (client.BulkIndexerStats, error) {
+	bi, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return client.BulkIndexerStats{}, err
+	}
+
+	return bi.Stats(), nil
+}
+
+// Documents returns the source of every document in the given index, keyed by ID.
+// It is a test helper and is not part of client.Client.
+func (cli *Client) Documents(indexName string) map[string][]byte {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	idx, ok := cli.indices[indexName]
+	if !ok {
+		return nil
+	}
+
+	docs := make(map[string][]byte, len(idx.docs))
+	for id, doc := range idx.docs {
+		docs[id] = append([]byte{}, doc.source...)
+	}
+
+	return docs
+}
+
+// Aliases returns the indices the given alias points to, sorted by name.
+// It is a test helper and is not part of client.Client.
+func (cli *Client) Aliases(alias string) []string {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	indices := make([]string, 0, len(cli.aliases[alias]))
+	for name := range cli.aliases[alias] {
+		indices = append(indices, name)
+	}
+	sort.Strings(indices)
+
+	return indices
+}
+
+// OpenPointsInTime returns the IDs of the points in time that have not been closed, so that tests can check they are.
+func (cli *Client) OpenPointsInTime() []string {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	pitIDs := make([]string, 0, len(cli.pits))
+	for pitID := range cli.pits {
+		pitIDs = append(pitIDs, pitID)
+	}
+	sort.Strings(pitIDs)
+
+	return pitIDs
+}
+
+// OpenScrolls returns the IDs of the scrolls that have not been cleared, so that tests can check they are.
+func (cli *Client) OpenScrolls() []string {
+	cli.mu.RLock()
+	defer cli.mu.RUnlock()
+
+	scrollIDs := make([]string, 0, len(cli.scrolls))
+	for scrollID := range cli.scrolls {
+		scrollIDs = append(scrollIDs, scrollID)
+	}
+	sort.Strings(scrollIDs)
+
+	return scrollIDs
+}
+
+func newIndex(name string, settings []byte) *index {
+	return &index{
+		name:     name,
+		settings: append(json.RawMessage{}, settings...),
+		docs:     make(map[string]*document),
+	}
+}
+
+// put stores a document, replacing any existing document with the same ID
+func (idx *index) put(documentID string, source []byte) (*document, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(source, &fields); err != nil {
+		return nil, err
+	}
+
+	if existing, ok := idx.docs[documentID]; ok {
+		existing.source = append(json.RawMessage{}, source...)
+		existing.fields = fields
+		existing.version++
+		existing.seqNo = idx.nextSeqNo()
+		return existing, nil
+	}
+
+	doc := &document{
+		id:      documentID,
+		source:  append(json.RawMessage{}, source...),
+		fields:  fields,
+		version: 1,
+		seqNo:   idx.nextSeqNo(),
+	}
+	idx.docs[documentID] = doc
+	idx.order = append(idx.order, documentID)
+
+	return doc, nil
+}
+
+// get returns a document with its _source filtered by the given field patterns
+func (idx *index) get(documentID string, includes, excludes []string) *client.GetResult {
+	doc, ok := idx.docs[documentID]
+	if !ok {
+		return &client.GetResult{Index: idx.name, ID: documentID}
+	}
+
+	source := append(json.RawMessage{}, doc.source...)
+	if len(includes) > 0 || len(excludes) > 0 {
+		source = mustMarshal(filterSource(doc.fields, "", includes, excludes))
+	}
+
+	return &client.GetResult{
+		Index:       idx.name,
+		ID:          documentID,
+		Version:     doc.version,
+		SeqNo:       doc.seqNo,
+		PrimaryTerm: primaryTerm,
+		Found:       true,
+		Source:      source,
+	}
+}
+
+// filterSource returns the fields of an object that match any of the includes, or all fields if there are none,
+// and none of the excludes. Patterns are dotted field paths that may contain wildcards.
+func filterSource(fields map[string]interface{}, prefix string, includes, excludes []string) map[string]interface{} {
+	filtered := make(map[string]interface{})
+	for name, value := range fields {
+		fieldPath := name
+		if prefix != "" {
+			fieldPath = prefix + "." + name
+		}
+
+		if matchesAny(fieldPath, excludes) {
+			continue
+		}
+
+		obj, isObj := value.(map[string]interface{})
+		switch {
+		case len(includes) == 0 || matchesAny(fieldPath, includes):
+			if isObj && len(excludes) > 0 {
+				value = filterSource(obj, fieldPath, nil, excludes)
+			}
+			filtered[name] = value
+		case isObj:
+			// Fields of the object may still be included
+			if child := filterSource(obj, fieldPath, includes, excludes); len(child) > 0 {
+				filtered[name] = child
+			}
+		}
+	}
+
+	return filtered
+}
+
+// matchesAny reports whether a dotted field path matches any of the patterns
+func matchesAny(fieldPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, fieldPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// nextSeqNo returns the sequence number of the next write to the index
+func (idx *index) nextSeqNo() int64 {
+	seqNo := idx.seqNo
+	idx.seqNo++
+	return seqNo
+}
+
+// checkConcurrency checks the concurrency control options of a write against the current document, which is
+// nil if it does not exist. If the write must not go ahead the reason for the version conflict is returned.
+func checkConcurrency(documentID string, doc *document, cc client.ConcurrencyControl) (string, bool) {
+	if cc.IfSeqNo != nil || cc.IfPrimaryTerm != nil {
+		var seqNo, term int64
+		if cc.IfSeqNo != nil {
+			seqNo = *cc.IfSeqNo
+		}
+		if cc.IfPrimaryTerm != nil {
+			term = *cc.IfPrimaryTerm
+		}
+
+		if doc == nil {
+			return fmt.Sprintf("[%s]: version conflict, required seqNo [%d], primary term [%d]. but no document was found",
+				documentID, seqNo, term), false
+		}
+		if doc.seqNo != seqNo || term != primaryTerm {
+			return fmt.Sprintf("[%s]: version conflict, required seqNo [%d], primary term [%d]. current document has seqNo [%d] and primary term [%d]",
+				documentID, seqNo, term, doc.seqNo, primaryTerm), false
+		}
+	}
+
+	if cc.Version != nil && doc != nil {
+		stale := *cc.Version <= doc.version
+		if cc.VersionType == client.VersionTypeExternalGTE {
+			stale = *cc.Version < doc.version
+		}
+		if stale {
+			return fmt.Sprintf("[%s]: version conflict, current version [%d] is higher or equal to the one provided [%d]",
+				documentID, doc.version, *cc.Version), false
+		}
+	}
+
+	return "", true
+}
+
+// writeResult returns the result of a write to a document
+func writeResult(indexName string, doc *document, result string) *client.WriteResult {
+	return &client.WriteResult{
+		Index:       indexName,
+		ID:          doc.id,
+		Version:     doc.version,
+		SeqNo:       doc.seqNo,
+		PrimaryTerm: primaryTerm,
+		Result:      result,
+	}
+}
+
+// mergeFields returns the fields of an existing document with the given fields merged in. As with
+// elasticsearch, objects are merged recursively and any other value is replaced.
+func mergeFields(existing, fields map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(existing)+len(fields))
+	for k, v := range existing {
+		merged[k] = v
+	}
+
+	for k, v := range fields {
+		existingObj, ok := merged[k].(map[string]interface{})
+		obj, isObj := v.(map[string]interface{})
+		if ok && isObj {
+			merged[k] = mergeFields(existingObj, obj)
+			continue
+		}
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// nextID generates an ID for a document added without one
+func (idx *index) nextID() string {
+	for {
+		idx.idSeq++
+		id := strconv.Itoa(idx.idSeq)
+		if _, ok := idx.docs[id]; !ok {
+			return id
+		}
+	}
+}
+
+// remove deletes a document, reporting whether it existed
+func (idx *index) remove(documentID string) bool {
+	if _, ok := idx.docs[documentID]; !ok {
+		return false
+	}
+
+	delete(idx.docs, documentID)
+	for i, id := range idx.order {
+		if id == documentID {
+			idx.order = append(idx.order[:i], idx.order[i+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+// writeIndex returns the index that writes to the given name should go to, creating it if needed.
+// It must be called with the write lock held.
+func (cli *Client) writeIndex(name string) (*index, error) {
+	if idx, ok := cli.indices[name]; ok {
+		return idx, nil
+	}
+
+	if _, ok := cli.aliases[name]; ok {
+		member, ok := cli.aliasWriteIndex(name)
+		if !ok {
+			return nil, fmt.Errorf("no write index is defined for alias [%s]", name)
+		}
+		return cli.indices[member], nil
+	}
+
+	idx := newIndex(name, nil)
+	cli.indices[name] = idx
+
+	return idx, nil
+}
+
+// lookupIndex returns an existing index, or the write index of an alias
+func (cli *Client) lookupIndex(name string) (*index, bool) {
+	if idx, ok := cli.indices[name]; ok {
+		return idx, true
+	}
+
+	if member, ok := cli.aliasWriteIndex(name); ok {
+		return cli.indices[member], true
+	}
+
+	return nil, false
+}
+
+// aliasWriteIndex returns the write index of an alias: the index with is_write_index set or, if none is set,
+// the only index of the alias
+func (cli *Client) aliasWriteIndex(alias string) (string, bool) {
+	members := cli.aliases[alias]
+	for member, props := range members {
+		if props.IsWriteIndex != nil && *props.IsWriteIndex {
+			return member, true
+		}
+		if len(members) == 1 && props.IsWriteIndex == nil {
+			return member, true
+		}
+	}
+
+	return "", false
+}
+
+func (cli *Client) isAlias(name string) bool {
+	_, ok := cli.aliases[name]
+	return ok
+}
+
+// aliasesOf returns the aliases of an index in the shape used by the index APIs
+func (cli *Client) aliasesOf(indexName string) map[string]interface{} {
+	aliases := make(map[string]interface{})
+	for alias, members := range cli.aliases {
+		if props, ok := members[indexName]; ok {
+			aliases[alias] = props
+		}
+	}
+
+	return aliases
+}
+
+// resolve expands index names, aliases and wildcard patterns into a sorted list of concrete indices.
+// An empty list, or allowAll with no names, resolves to every index. The returned error holds the
+// first name that did not resolve to anything.
+func (cli *Client) resolve(names []string, allowAll bool) ([]string, error) {
+	if len(names) == 0 && allowAll {
+		names = []string{"_all"}
+	}
+
+	resolved := make(map[string]struct{})
+	for _, name := range names {
+		switch {
+		case name == "_all" || name == "*" || (name == "" && allowAll):
+			for indexName := range cli.indices {
+				resolved[indexName] = struct{}{}
+			}
+		case strings.Contains(name, "*"):
+			for indexName := range cli.indices {
+				if ok, _ := path.Match(name, indexName); ok {
+					resolved[indexName] = struct{}{}
+				}
+			}
+			for alias, members := range cli.aliases {
+				if ok, _ := path.Match(name, alias); ok {
+					for member := range members {
+						resolved[member] = struct{}{}
+					}
+				}
+			}
+		case cli.indices[name] != nil:
+			resolved[name] = struct{}{}
+		case cli.isAlias(name):
+			for member := range cli.aliases[name] {
+				resolved[member] = struct{}{}
+			}
+		default:
+			return nil, errors.New(name)
+		}
+	}
+
+	indices := make([]string, 0, len(resolved))
+	for name := range resolved {
+		indices = append(indices, name)
+	}
+	sort.Strings(indices)
+
+	return indices, nil
+}
+
+func splitIndices(indices string) []string {
+	if indices == "" {
+		return nil
+	}
+
+	return strings.Split(indices, ",")
+}
+
+// newStatusError builds an error in the same shape as the real clients return for an error response
+func newStatusError(msg string, code int, errType, reason, indexName string) error {
+	cause := map[string]interface{}{"type": errType, "reason": reason}
+	if indexName != "" {
+		cause["index"] = indexName
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]interface{}{
+			"root_cause": []interface{}{cause},
+			"type":       errType,
+			"reason":     reason,
+			"index":      indexName,
+		},
+		"status": code,
+	})
+
+	return esError.StatusError{
+		Err:  fmt.Errorf("%s: %w", msg, esError.NewESError("elasticsearch", code, body)),
+		Code: code,
+	}
+}
@@ -0,0 +1,747 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var testCtx = context.Background()
+
+func addDocument(cli *Client, indexName, documentID, document string) {
+	_, err := cli.AddDocument(testCtx, indexName, documentID, []byte(document), nil)
+	So(err, ShouldBeNil)
+}
+
+type testSearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Index  string          `json:"_index"`
+			ID     string          `json:"_id"`
+			Source json.RawMessage `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+func searchIDs(cli *Client, index, query string) []string {
+	data, err := cli.Search(testCtx, client.Search{Header: client.Header{Index: index}, Query: []byte(query)})
+	So(err, ShouldBeNil)
+
+	var res testSearchResponse
+	So(json.Unmarshal(data, &res), ShouldBeNil)
+
+	ids := []string{}
+	for _, hit := range res.Hits.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestIndexManagement(t *testing.T) {
+	Convey("Given a fake client", t, func() {
+		cli := NewClient()
+
+		Convey("When an index is created", func() {
+			err := cli.CreateIndex(testCtx, "my-index", []byte(`{"settings":{"number_of_shards":1}}`))
+			So(err, ShouldBeNil)
+
+			Convey("Then creating it again returns a 400 error", func() {
+				err := cli.CreateIndex(testCtx, "my-index", nil)
+				So(err, ShouldNotBeNil)
+				So(esError.ErrorStatus(err), ShouldEqual, 400)
+				So(esError.IsResourceAlreadyExists(err), ShouldBeTrue)
+			})
+
+			Convey("Then GetIndices returns its settings", func() {
+				data, err := cli.GetIndices(testCtx, []string{"my-*"})
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"my-index":{"aliases":{},"settings":{"settings":{"number_of_shards":1}}}}`)
+			})
+
+			Convey("Then it can be deleted", func() {
+				So(cli.DeleteIndex(testCtx, "my-index"), ShouldBeNil)
+				So(cli.Documents("my-index"), ShouldBeNil)
+			})
+		})
+
+		Convey("When a missing index is deleted", func() {
+			err := cli.DeleteIndex(testCtx, "missing")
+
+			Convey("Then a 404 error is returned", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 404)
+				So(esError.IsIndexNotFound(err), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestAliases(t *testing.T) {
+	Convey("Given a fake client with two indices", t, func() {
+		cli := NewClient()
+		So(cli.CreateIndex(testCtx, "index-1", nil), ShouldBeNil)
+		So(cli.CreateIndex(testCtx, "index-2", nil), ShouldBeNil)
+		addDocument(cli, "index-1", "a", `{"n":1}`)
+		addDocument(cli, "index-2", "b", `{"n":2}`)
+
+		Convey("When an alias is added to the first index", func() {
+			So(cli.UpdateAliases(testCtx, "search", nil, []string{"index-1"}), ShouldBeNil)
+
+			Convey("Then searching the alias returns documents from the first index", func() {
+				So(searchIDs(cli, "search", ""), ShouldResemble, []string{"a"})
+			})
+
+			Convey("Then GetAlias reports the alias", func() {
+				data, err := cli.GetAlias(testCtx)
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"index-1":{"aliases":{"search":{}}},"index-2":{"aliases":{}}}`)
+			})
+
+			Convey("And the alias is swapped to the second index", func() {
+				So(cli.UpdateAliases(testCtx, "search", []string{"index-1"}, []string{"index-2"}), ShouldBeNil)
+
+				Convey("Then searching the alias returns documents from the second index", func() {
+					So(cli.Aliases("search"), ShouldResemble, []string{"index-2"})
+					So(searchIDs(cli, "search", ""), ShouldResemble, []string{"b"})
+				})
+			})
+		})
+
+		Convey("When an alias is removed from an index it does not point to", func() {
+			err := cli.UpdateAliases(testCtx, "search", []string{"index-1"}, nil)
+
+			Convey("Then an error is returned", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 404)
+			})
+		})
+
+		Convey("When an alias is added to both indices with the second as the write index", func() {
+			isWriteIndex := true
+			err := cli.UpdateAliasActions(testCtx, []client.AliasAction{
+				{Type: client.AliasAdd, Indices: []string{"index-*"}, Aliases: []string{"search"}},
+				{Type: client.AliasAdd, Indices: []string{"index-2"}, Aliases: []string{"search"}, IsWriteIndex: &isWriteIndex},
+			})
+			So(err, ShouldBeNil)
+
+			Convey("Then documents added through the alias are written to the second index", func() {
+				addDocument(cli, "search", "c", `{"n":3}`)
+				So(cli.Documents("index-2"), ShouldContainKey, "c")
+				So(searchIDs(cli, "search", ""), ShouldResemble, []string{"a", "b", "c"})
+			})
+		})
+
+		Convey("When one of several alias actions fails", func() {
+			err := cli.UpdateAliasActions(testCtx, []client.AliasAction{
+				{Type: client.AliasAdd, Indices: []string{"index-1"}, Aliases: []string{"search"}},
+				{Type: client.AliasRemoveIndex, Indices: []string{"index-2"}},
+				{Type: client.AliasRemove, Indices: []string{"index-1"}, Aliases: []string{"missing"}},
+			})
+
+			Convey("Then none of the actions are applied", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 404)
+				So(cli.Aliases("search"), ShouldBeEmpty)
+				So(cli.Documents("index-2"), ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestDocuments(t *testing.T) {
+	Convey("Given a fake client", t, func() {
+		cli := NewClient()
+
+		Convey("When a document is added to a missing index", func() {
+			addDocument(cli, "my-index", "1", `{"title":"Hello"}`)
+
+			Convey("Then the index is created with the document", func() {
+				So(cli.Documents("my-index"), ShouldResemble, map[string][]byte{"1": []byte(`{"title":"Hello"}`)})
+			})
+
+			Convey("Then adding it again returns a conflict", func() {
+				_, err := cli.AddDocument(testCtx, "my-index", "1", []byte(`{"title":"Again"}`), nil)
+				So(esError.ErrorStatus(err), ShouldEqual, 409)
+				So(esError.IsVersionConflict(err), ShouldBeTrue)
+			})
+
+			Convey("Then it can be deleted", func() {
+				_, err := cli.DeleteDocument(testCtx, "my-index", "1", nil)
+				So(err, ShouldBeNil)
+				So(cli.Documents("my-index"), ShouldBeEmpty)
+
+				_, err = cli.DeleteDocument(testCtx, "my-index", "1", nil)
+				So(esError.ErrorStatus(err), ShouldEqual, 404)
+				So(err.Error(), ShouldContainSubstring, "delete request failed")
+			})
+		})
+
+		Convey("When an existing document is upserted", func() {
+			addDocument(cli, "my-index", "1", `{"title":"Hello","meta":{"a":1,"b":2}}`)
+
+			Convey("Then by default the document is replaced", func() {
+				_, err := cli.AddDocument(testCtx, "my-index", "1", []byte(`{"meta":{"b":3}}`), &client.AddDocumentOptions{Upsert: true})
+				So(err, ShouldBeNil)
+				So(string(cli.Documents("my-index")["1"]), ShouldEqual, `{"meta":{"b":3}}`)
+			})
+
+			Convey("Then with the merge mode the fields are merged into the document", func() {
+				_, err := cli.AddDocument(testCtx, "my-index", "1", []byte(`{"meta":{"b":3}}`),
+					&client.AddDocumentOptions{Upsert: true, UpsertMode: client.UpsertMerge})
+				So(err, ShouldBeNil)
+				So(string(cli.Documents("my-index")["1"]), ShouldEqual, `{"meta":{"a":1,"b":3},"title":"Hello"}`)
+			})
+		})
+
+		Convey("When a missing document is upserted with the merge mode", func() {
+			_, err := cli.AddDocument(testCtx, "my-index", "2", []byte(`{"title":"New"}`),
+				&client.AddDocumentOptions{Upsert: true, UpsertMode: client.UpsertMerge})
+
+			Convey("Then the document is created", func() {
+				So(err, ShouldBeNil)
+				So(string(cli.Documents("my-index")["2"]), ShouldEqual, `{"title":"New"}`)
+			})
+		})
+
+		Convey("When documents are deleted by query", func() {
+			addDocument(cli, "my-index", "1", `{"uri":"/a"}`)
+			addDocument(cli, "my-index", "2", `{"uri":"/b"}`)
+
+			err := cli.DeleteDocumentByQuery(testCtx, client.Search{
+				Header: client.Header{Index: "my-index"},
+				Query:  []byte(`{"query":{"term":{"uri":"/a"}}}`),
+			})
+
+			Convey("Then only the matching documents are removed", func() {
+				So(err, ShouldBeNil)
+				So(searchIDs(cli, "my-index", ""), ShouldResemble, []string{"2"})
+			})
+		})
+	})
+}
+
+func TestBulkIndexer(t *testing.T) {
+	Convey("Given a fake client with a bulk indexer", t, func() {
+		cli := NewClient()
+		So(cli.NewBulkIndexer(testCtx, nil), ShouldBeNil)
+
+		var succeeded, failed []string
+		onSuccess := func(_ context.Context, item esutil.BulkIndexerItem, _ esutil.BulkIndexerResponseItem) {
+			succeeded = append(succeeded, item.DocumentID)
+		}
+		onFailure := func(_ context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, _ error) {
+			failed = append(failed, item.DocumentID+":"+res.Error.Type)
+		}
+
+		Convey("When items are added", func() {
+			So(cli.BulkIndexAdd(testCtx, Create, "my-index", "1", []byte(`{"n":1}`), onSuccess, onFailure), ShouldBeNil)
+			So(cli.BulkIndexAdd(testCtx, Create, "my-index", "1", []byte(`{"n":2}`), onSuccess, onFailure), ShouldBeNil)
+			So(cli.BulkIndexAdd(testCtx, Index, "my-index", "2", []byte(`{"n":3}`), onSuccess, onFailure), ShouldBeNil)
+
+			Convey("Then nothing is applied until the indexer is closed", func() {
+				So(cli.Documents("my-index"), ShouldBeNil)
+
+				So(cli.BulkIndexClose(testCtx), ShouldBeNil)
+
+				So(succeeded, ShouldResemble, []string{"1", "2"})
+				So(failed, ShouldResemble, []string{"1:version_conflict_engine_exception"})
+				So(cli.Documents("my-index"), ShouldHaveLength, 2)
+			})
+
+			Convey("Then the stats count the items once the indexer is closed", func() {
+				So(cli.BulkIndexClose(testCtx), ShouldBeNil)
+
+				stats, err := cli.BulkIndexStats(testCtx)
+				So(err, ShouldBeNil)
+				So(stats, ShouldResemble, client.BulkIndexerStats{
+					NumAdded: 3, NumFlushed: 2, NumFailed: 1, NumIndexed: 1, NumCreated: 1, NumRequests: 1,
+				})
+			})
+		})
+	})
+
+	Convey("Given a fake client with a bulk indexer that has a default index", t, func() {
+		cli := NewClient()
+		flushes := 0
+		So(cli.NewBulkIndexer(testCtx, &client.BulkIndexerConfig{
+			Index:           "default-index",
+			OnFlushEnd:      func(context.Context) { flushes++ },
+			MaxFailureRatio: 0.5,
+		}), ShouldBeNil)
+
+		Convey("When an item without an index is added and the indexer closed", func() {
+			So(cli.BulkIndexAdd(testCtx, Create, "", "1", []byte(`{}`), nil, nil), ShouldBeNil)
+			So(cli.BulkIndexClose(testCtx), ShouldBeNil)
+
+			Convey("Then the item is applied to the default index in a single flush", func() {
+				So(cli.Documents("default-index"), ShouldHaveLength, 1)
+				So(flushes, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When more items fail than the maximum failure ratio allows", func() {
+			So(cli.BulkIndexAdd(testCtx, Update, "", "missing", []byte(`{"doc":{}}`), nil, nil), ShouldBeNil)
+			err := cli.BulkIndexClose(testCtx)
+
+			Convey("Then closing the indexer returns an error", func() {
+				So(errors.Is(err, client.ErrBulkFailureRatioExceeded), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a fake client with a bulk indexer that flushes every 14 bytes", t, func() {
+		cli := NewClient()
+		So(cli.NewBulkIndexer(testCtx, &client.BulkIndexerConfig{FlushBytes: 14, MaxBufferedBytes: 100, NumWorkers: 1}), ShouldBeNil)
+
+		Convey("When items are added with BulkIndexTryAdd", func() {
+			So(cli.BulkIndexTryAdd(testCtx, Create, "my-index", "1", []byte(`{"n":1}`), nil, nil), ShouldBeNil)
+			So(cli.Documents("my-index"), ShouldBeNil)
+			So(cli.BulkIndexTryAdd(testCtx, Create, "my-index", "2", []byte(`{"n":2}`), nil, nil), ShouldBeNil)
+
+			Convey("Then they are applied once their documents reach the flush size, without closing the indexer", func() {
+				So(cli.Documents("my-index"), ShouldHaveLength, 2)
+				So(cli.BulkIndexClose(testCtx), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a fake client with a default and a named bulk indexer", t, func() {
+		cli := NewClient()
+		So(cli.NewBulkIndexer(testCtx, &client.BulkIndexerConfig{Index: "products"}), ShouldBeNil)
+		orders, err := cli.OpenBulkIndexer(testCtx, "orders", &client.BulkIndexerConfig{Index: "orders"})
+		So(err, ShouldBeNil)
+
+		Convey("When items are added to both and only the named indexer is closed", func() {
+			So(cli.BulkIndexAdd(testCtx, Create, "", "1", []byte(`{}`), nil, nil), ShouldBeNil)
+			So(orders.Add(testCtx, Create, "", "1", []byte(`{}`), nil, nil), ShouldBeNil)
+			So(orders.Close(testCtx), ShouldBeNil)
+
+			Convey("Then only the items of the named indexer are applied", func() {
+				So(cli.Documents("orders"), ShouldHaveLength, 1)
+				So(cli.Documents("products"), ShouldBeNil)
+				So(orders.Stats().NumCreated, ShouldEqual, 1)
+			})
+
+			Convey("Then the named indexer can be opened again", func() {
+				_, err := cli.OpenBulkIndexer(testCtx, "orders", nil)
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When the default indexer is created again while open", func() {
+			err := cli.NewBulkIndexer(testCtx, nil)
+
+			Convey("Then an error is returned", func() {
+				So(errors.Is(err, client.ErrBulkIndexerOpen), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a fake client without a bulk indexer", t, func() {
+		cli := NewClient()
+
+		Convey("When an item is added", func() {
+			err := cli.BulkIndexAdd(testCtx, Create, "my-index", "1", []byte(`{}`), nil, nil)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(esError.ErrorStatus(err), ShouldEqual, 500)
+			})
+		})
+	})
+}
+
+func TestBulkUpdate(t *testing.T) {
+	Convey("Given a fake client with a document", t, func() {
+		cli := NewClient()
+		addDocument(cli, "my-index", "1", `{"title":"one","n":1}`)
+
+		Convey("When a bulk payload is applied", func() {
+			payload := `{"update":{"_id":"1"}}
+{"doc":{"n":2}}
+{"index":{"_id":"2"}}
+{"title":"two"}
+{"delete":{"_id":"missing"}}
+`
+			data, err := cli.BulkUpdate(testCtx, "my-index", "", []byte(payload))
+
+			Convey("Then every action is applied and reported", func() {
+				So(err, ShouldBeNil)
+
+				var res struct {
+					Errors bool                         `json:"errors"`
+					Items  []map[string]json.RawMessage `json:"items"`
+				}
+				So(json.Unmarshal(data, &res), ShouldBeNil)
+				So(res.Errors, ShouldBeFalse)
+				So(res.Items, ShouldHaveLength, 3)
+				So(string(cli.Documents("my-index")["1"]), ShouldEqual, `{"n":2,"title":"one"}`)
+				So(string(cli.Documents("my-index")["2"]), ShouldEqual, `{"title":"two"}`)
+			})
+		})
+	})
+}
+
+func TestBulk(t *testing.T) {
+	Convey("Given a fake client with a document", t, func() {
+		cli := NewClient()
+		created, err := cli.AddDocument(testCtx, "my-index", "1", []byte(`{"title":"one"}`), nil)
+		So(err, ShouldBeNil)
+
+		Convey("When Bulk is called with actions split across requests, deleting with the sequence number it was created at", func() {
+			bulk := client.NewBulkRequestBuilder(60)
+			So(bulk.Update("", "1", client.DocumentUpdate{Doc: []byte(`{"n":1}`)}, nil), ShouldBeNil)
+			So(bulk.Create("", "2", []byte(`{"title":"two"}`), nil), ShouldBeNil)
+			So(bulk.Delete("", "1", &client.BulkActionOptions{
+				ConcurrencyControl: client.ConcurrencyControl{IfSeqNo: &created.SeqNo, IfPrimaryTerm: &created.PrimaryTerm},
+			}), ShouldBeNil)
+			So(len(bulk.Requests()), ShouldBeGreaterThan, 1)
+
+			res, err := cli.Bulk(testCtx, bulk, &client.BulkOptions{Index: "my-index"})
+
+			Convey("Then the delete conflicts with the update before it and is returned as a failed item", func() {
+				So(errors.Is(err, client.ErrBulkItemsFailed), ShouldBeTrue)
+				So(res.Items, ShouldHaveLength, 3)
+				So(res.Items[0].Result, ShouldEqual, "updated")
+				So(res.Items[1].Result, ShouldEqual, "created")
+
+				failed := res.Failed()
+				So(failed, ShouldHaveLength, 1)
+				So(failed[0].Action, ShouldEqual, client.BulkIndexerAction("delete"))
+				So(failed[0].Status, ShouldEqual, 409)
+				So(failed[0].Error.Type, ShouldEqual, "version_conflict_engine_exception")
+
+				So(string(cli.Documents("my-index")["1"]), ShouldEqual, `{"n":1,"title":"one"}`)
+				So(string(cli.Documents("my-index")["2"]), ShouldEqual, `{"title":"two"}`)
+			})
+		})
+	})
+}
+
+func TestCount(t *testing.T) {
+	Convey("Given a fake client with documents in two indices", t, func() {
+		cli := NewClient()
+		addDocument(cli, "index-1", "1", `{"type":"a"}`)
+		addDocument(cli, "index-1", "2", `{"type":"b"}`)
+		addDocument(cli, "index-2", "3", `{"type":"a"}`)
+
+		Convey("When Count is called with a query", func() {
+			data, err := cli.Count(testCtx, client.Count{Query: []byte(`{"query":{"term":{"type":"a"}}}`)})
+
+			Convey("Then matching documents across all indices are counted", func() {
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"count":2}`)
+			})
+		})
+
+		Convey("When CountIndices is called", func() {
+			data, err := cli.CountIndices(testCtx, []string{"index-1"})
+
+			Convey("Then documents in the given indices are counted", func() {
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"count":2}`)
+			})
+		})
+	})
+}
+
+func TestConcurrencyControl(t *testing.T) {
+	Convey("Given a fake client with a document", t, func() {
+		cli := NewClient()
+		created, err := cli.AddDocument(testCtx, "my-index", "1", []byte(`{"title":"one"}`), nil)
+		So(err, ShouldBeNil)
+		So(created.Result, ShouldEqual, "created")
+		So(created.Version, ShouldEqual, 1)
+
+		ifMatch := func(res *client.WriteResult) client.ConcurrencyControl {
+			return client.ConcurrencyControl{IfSeqNo: &res.SeqNo, IfPrimaryTerm: &res.PrimaryTerm}
+		}
+
+		Convey("When it is updated with the sequence number it was read at", func() {
+			updated, err := cli.UpdateDocument(testCtx, "my-index", "1", client.DocumentUpdate{Doc: []byte(`{"title":"two"}`)},
+				&client.UpdateDocumentOptions{ConcurrencyControl: ifMatch(created)})
+
+			Convey("Then the update succeeds with a new sequence number and version", func() {
+				So(err, ShouldBeNil)
+				So(updated.Result, ShouldEqual, "updated")
+				So(updated.Version, ShouldEqual, 2)
+				So(updated.SeqNo, ShouldBeGreaterThan, created.SeqNo)
+			})
+
+			Convey("Then a second writer using the old sequence number gets a version conflict", func() {
+				_, err := cli.UpdateDocument(testCtx, "my-index", "1", client.DocumentUpdate{Doc: []byte(`{"title":"three"}`)},
+					&client.UpdateDocumentOptions{ConcurrencyControl: ifMatch(created)})
+				So(esError.ErrorStatus(err), ShouldEqual, 409)
+				So(esError.IsVersionConflict(err), ShouldBeTrue)
+
+				_, err = cli.DeleteDocument(testCtx, "my-index", "1", &client.DeleteDocumentOptions{ConcurrencyControl: ifMatch(created)})
+				So(esError.IsVersionConflict(err), ShouldBeTrue)
+				So(string(cli.Documents("my-index")["1"]), ShouldEqual, `{"title":"two"}`)
+			})
+		})
+
+		Convey("When an update does not change the document", func() {
+			res, err := cli.UpdateDocument(testCtx, "my-index", "1", client.DocumentUpdate{Doc: []byte(`{"title":"one"}`)}, nil)
+
+			Convey("Then the result is a noop", func() {
+				So(err, ShouldBeNil)
+				So(res.Result, ShouldEqual, "noop")
+				So(res.Version, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When a missing document is updated", func() {
+			_, err := cli.UpdateDocument(testCtx, "my-index", "2", client.DocumentUpdate{Doc: []byte(`{"title":"two"}`)}, nil)
+
+			Convey("Then a 404 error is returned", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 404)
+			})
+		})
+
+		Convey("When it is upserted with external versions", func() {
+			version := int64(10)
+			options := &client.AddDocumentOptions{Upsert: true, ConcurrencyControl: client.ConcurrencyControl{Version: &version}}
+			res, err := cli.AddDocument(testCtx, "my-index", "1", []byte(`{"title":"v10"}`), options)
+			So(err, ShouldBeNil)
+			So(res.Version, ShouldEqual, 10)
+
+			Convey("Then an older or equal version is rejected", func() {
+				_, err := cli.AddDocument(testCtx, "my-index", "1", []byte(`{"title":"v10 again"}`), options)
+				So(esError.IsVersionConflict(err), ShouldBeTrue)
+			})
+
+			Convey("Then an equal version is accepted with external_gte", func() {
+				options.VersionType = client.VersionTypeExternalGTE
+				_, err := cli.AddDocument(testCtx, "my-index", "1", []byte(`{"title":"v10 again"}`), options)
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then it can be deleted with a newer version", func() {
+				newer := int64(11)
+				res, err := cli.DeleteDocument(testCtx, "my-index", "1",
+					&client.DeleteDocumentOptions{ConcurrencyControl: client.ConcurrencyControl{Version: &newer}})
+				So(err, ShouldBeNil)
+				So(res.Result, ShouldEqual, "deleted")
+				So(res.Version, ShouldEqual, 11)
+			})
+		})
+	})
+}
+
+func TestGetDocument(t *testing.T) {
+	Convey("Given a fake client with a document", t, func() {
+		cli := NewClient()
+		addDocument(cli, "my-index", "1", `{"title":"Hello","meta":{"author":"A","secret":"S"},"tags":["a"]}`)
+		So(cli.UpdateAliases(testCtx, "my-alias", nil, []string{"my-index"}), ShouldBeNil)
+
+		Convey("When it is got by ID through an alias", func() {
+			res, err := cli.GetDocument(testCtx, "my-alias", "1", nil)
+
+			Convey("Then the document is returned from its index", func() {
+				So(err, ShouldBeNil)
+				So(res.Found, ShouldBeTrue)
+				So(res.Index, ShouldEqual, "my-index")
+				So(res.Version, ShouldEqual, 1)
+				So(string(res.Source), ShouldEqual, `{"title":"Hello","meta":{"author":"A","secret":"S"},"tags":["a"]}`)
+			})
+		})
+
+		Convey("When it is got with source filtering", func() {
+			res, err := cli.GetDocument(testCtx, "my-index", "1", &client.GetDocumentOptions{
+				SourceIncludes: []string{"title", "meta.*"},
+				SourceExcludes: []string{"meta.secret"},
+			})
+
+			Convey("Then only the matching fields are returned", func() {
+				So(err, ShouldBeNil)
+				So(string(res.Source), ShouldEqual, `{"meta":{"author":"A"},"title":"Hello"}`)
+			})
+		})
+
+		Convey("When a missing document is got", func() {
+			res, err := cli.GetDocument(testCtx, "my-index", "2", nil)
+
+			Convey("Then the result is not found", func() {
+				So(err, ShouldBeNil)
+				So(res.Found, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a document is got from a missing index", func() {
+			_, err := cli.GetDocument(testCtx, "missing", "1", nil)
+
+			Convey("Then an index not found error is returned", func() {
+				So(esError.IsIndexNotFound(err), ShouldBeTrue)
+			})
+		})
+
+		Convey("When MultiGet is called", func() {
+			res, err := cli.MultiGet(testCtx, []client.MultiGetDocument{
+				{Index: "my-index", ID: "1", SourceExcludes: []string{"meta"}},
+				{Index: "my-index", ID: "2"},
+				{Index: "missing", ID: "3"},
+			}, &client.MultiGetOptions{SourceIncludes: []string{"title"}})
+
+			Convey("Then a result is returned for each document", func() {
+				So(err, ShouldBeNil)
+				So(res.Docs, ShouldHaveLength, 3)
+				So(string(res.Docs[0].Source), ShouldEqual, `{"tags":["a"],"title":"Hello"}`)
+				So(res.Docs[1].Found, ShouldBeFalse)
+				So(res.Docs[2].Error.Type, ShouldEqual, "index_not_found_exception")
+			})
+		})
+	})
+}
+
+func TestUpdateByQuery(t *testing.T) {
+	Convey("Given a fake client with draft and published documents", t, func() {
+		cli := NewClient()
+		addDocument(cli, "my-index", "1", `{"status":"draft"}`)
+		addDocument(cli, "my-index", "2", `{"status":"published"}`)
+		addDocument(cli, "my-index", "3", `{"status":"draft"}`)
+		query := client.UpdateByQuery{Query: []byte(`{"term":{"status":"draft"}}`)}
+
+		Convey("When UpdateByQuery is called without a script", func() {
+			res, err := cli.UpdateByQuery(testCtx, []string{"my-index"}, query, nil)
+
+			Convey("Then every matching document is reindexed in place", func() {
+				So(err, ShouldBeNil)
+				So(res.Total, ShouldEqual, 2)
+				So(res.Updated, ShouldEqual, 2)
+				So(res.VersionConflicts, ShouldEqual, 0)
+
+				doc, err := cli.GetDocument(testCtx, "my-index", "1", nil)
+				So(err, ShouldBeNil)
+				So(doc.Version, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When UpdateByQuery is called without waiting for completion", func() {
+			wait := false
+			res, err := cli.UpdateByQuery(testCtx, []string{"my-index"}, query, &client.UpdateByQueryOptions{WaitForCompletion: &wait})
+
+			Convey("Then only a task ID is returned", func() {
+				So(err, ShouldBeNil)
+				So(res.Task, ShouldNotBeEmpty)
+				So(res.Updated, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When UpdateByQuery is called with a script", func() {
+			query.Script = &client.Script{Source: "ctx._source.status = 'published'"}
+			_, err := cli.UpdateByQuery(testCtx, []string{"my-index"}, query, nil)
+
+			Convey("Then an error is returned as scripts are not supported", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 400)
+			})
+		})
+
+		Convey("When UpdateDocument is called with an upsert for a missing document", func() {
+			res, err := cli.UpdateDocument(testCtx, "my-index", "4",
+				client.DocumentUpdate{Doc: []byte(`{"status":"draft"}`), Upsert: []byte(`{"status":"new"}`)}, nil)
+
+			Convey("Then the upsert document is created", func() {
+				So(err, ShouldBeNil)
+				So(res.Result, ShouldEqual, "created")
+				So(string(cli.Documents("my-index")["4"]), ShouldEqual, `{"status":"new"}`)
+			})
+		})
+	})
+}
+
+func TestReindex(t *testing.T) {
+	Convey("Given a fake client with a source index and a partly populated destination", t, func() {
+		cli := NewClient()
+		addDocument(cli, "old", "1", `{"type":"bulletin"}`)
+		addDocument(cli, "old", "2", `{"type":"article"}`)
+		addDocument(cli, "old", "3", `{"type":"bulletin"}`)
+		addDocument(cli, "new", "3", `{"type":"existing"}`)
+
+		Convey("When the documents matching a query are reindexed", func() {
+			res, err := cli.Reindex(testCtx, client.Reindex{
+				Source: client.ReindexSource{Index: []string{"old"}, Query: []byte(`{"term":{"type":"bulletin"}}`)},
+				Dest:   client.ReindexDest{Index: "new"},
+			}, nil)
+
+			Convey("Then they are copied into the destination, replacing existing documents", func() {
+				So(err, ShouldBeNil)
+				So(res.Created, ShouldEqual, 1)
+				So(res.Updated, ShouldEqual, 1)
+				So(cli.Documents("new"), ShouldResemble, map[string][]byte{
+					"1": []byte(`{"type":"bulletin"}`),
+					"3": []byte(`{"type":"bulletin"}`),
+				})
+			})
+		})
+
+		Convey("When every document is reindexed with the create op type, proceeding on conflicts", func() {
+			res, err := cli.Reindex(testCtx, client.Reindex{
+				Source:             client.ReindexSource{Index: []string{"old"}},
+				Dest:               client.ReindexDest{Index: "new", OpType: "create"},
+				ProceedOnConflicts: true,
+			}, nil)
+
+			Convey("Then existing documents are counted as conflicts and left unchanged", func() {
+				So(err, ShouldBeNil)
+				So(res.Created, ShouldEqual, 2)
+				So(res.VersionConflicts, ShouldEqual, 1)
+				So(string(cli.Documents("new")["3"]), ShouldEqual, `{"type":"existing"}`)
+			})
+		})
+
+		Convey("When a remote source is reindexed", func() {
+			_, err := cli.Reindex(testCtx, client.Reindex{
+				Source: client.ReindexSource{Index: []string{"old"}, Remote: &client.ReindexRemote{Host: "https://other:9200"}},
+				Dest:   client.ReindexDest{Index: "new"},
+			}, nil)
+
+			Convey("Then an error is returned as remote sources are not supported", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 400)
+			})
+		})
+	})
+}
+
+func TestTasks(t *testing.T) {
+	Convey("Given a fake client with documents to reindex", t, func() {
+		cli := NewClient()
+		addDocument(cli, "old", "1", `{"n":1}`)
+		addDocument(cli, "old", "2", `{"n":2}`)
+
+		Convey("When a reindex is started without waiting for completion", func() {
+			wait := false
+			started, err := cli.Reindex(testCtx, client.Reindex{
+				Source: client.ReindexSource{Index: []string{"old"}},
+				Dest:   client.ReindexDest{Index: "new"},
+			}, &client.ReindexOptions{WaitForCompletion: &wait})
+			So(err, ShouldBeNil)
+
+			Convey("Then waiting for its task returns the result of the reindex", func() {
+				res, err := client.WaitForTask(testCtx, cli, started.Task, nil)
+				So(err, ShouldBeNil)
+				So(res.Completed, ShouldBeTrue)
+				So(res.Task.Action, ShouldEqual, "indices:data/write/reindex")
+				So(res.Response.Created, ShouldEqual, 2)
+			})
+
+			Convey("Then the task cannot be cancelled as it has completed", func() {
+				_, err := cli.CancelTask(testCtx, started.Task)
+				So(esError.ErrorStatus(err), ShouldEqual, 404)
+			})
+		})
+
+		Convey("When an unknown task is got", func() {
+			_, err := cli.GetTask(testCtx, "fake:99", nil)
+
+			Convey("Then a not found error is returned", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 404)
+			})
+		})
+	})
+}
@@ -0,0 +1,811 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+)
+
+const defaultSearchSize = 10
+
+// searchRequest is the subset of a search API request body understood by the fake client
+type searchRequest struct {
+	Query       map[string]json.RawMessage `json:"query"`
+	From        *int                       `json:"from"`
+	Size        *int                       `json:"size"`
+	Sort        json.RawMessage            `json:"sort"`
+	PIT         *pointInTime               `json:"pit"`
+	SearchAfter []interface{}              `json:"search_after"`
+	Slice       *slice                     `json:"slice"`
+}
+
+type slice struct {
+	ID  int `json:"id"`
+	Max int `json:"max"`
+}
+
+type pointInTime struct {
+	ID string `json:"id"`
+}
+
+type sortField struct {
+	field string
+	desc  bool
+}
+
+type hit struct {
+	index string
+	doc   *document
+	// shardDoc emulates the _shard_doc tiebreaker of a point in time search, as the position of the hit in the
+	// order the documents of the searched indices were matched
+	shardDoc int
+}
+
+type searchResponse struct {
+	ScrollID string     `json:"_scroll_id,omitempty"`
+	PitID    string     `json:"pit_id,omitempty"`
+	Took     int        `json:"took"`
+	TimedOut bool       `json:"timed_out"`
+	Shards   shards     `json:"_shards"`
+	Hits     searchHits `json:"hits"`
+}
+
+// scroll is an open scroll, holding every hit of the search as it was when the scroll was opened
+type scroll struct {
+	res  *searchResponse
+	hits []searchHit
+	size int
+	next int
+}
+
+// page returns the next page of hits of the scroll
+func (s *scroll) page(scrollID string) *searchResponse {
+	res := *s.res
+	res.ScrollID = scrollID
+
+	end := s.next + s.size
+	if end > len(s.hits) {
+		end = len(s.hits)
+	}
+	res.Hits.Hits = s.hits[s.next:end]
+	s.next = end
+
+	return &res
+}
+
+type shards struct {
+	Total      int `json:"total"`
+	Successful int `json:"successful"`
+	Skipped    int `json:"skipped"`
+	Failed     int `json:"failed"`
+}
+
+type searchHits struct {
+	Total    total       `json:"total"`
+	MaxScore *float64    `json:"max_score"`
+	Hits     []searchHit `json:"hits"`
+}
+
+type total struct {
+	Value    int    `json:"value"`
+	Relation string `json:"relation"`
+}
+
+type searchHit struct {
+	Index  string          `json:"_index"`
+	ID     string          `json:"_id"`
+	Score  *float64        `json:"_score"`
+	Source json.RawMessage `json:"_source"`
+	Sort   []interface{}   `json:"sort,omitempty"`
+}
+
+func parseSearchRequest(body []byte) (*searchRequest, error) {
+	req := &searchRequest{}
+	if len(body) == 0 {
+		return req, nil
+	}
+
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, fmt.Errorf("failed to parse search request: %w", err)
+	}
+
+	return req, nil
+}
+
+// search evaluates a search request. It must be called with at least the read lock held.
+func (cli *Client) search(search client.Search) (*searchResponse, error) {
+	req, err := parseSearchRequest(search.Query)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to search documents", http.StatusBadRequest,
+			"parsing_exception", err.Error(), search.Header.Index)
+	}
+
+	if req.PIT != nil {
+		if search.Header.Index != "" {
+			return nil, newStatusError("error occured while trying to search documents", http.StatusBadRequest,
+				"illegal_argument_exception", "[indices] cannot be used with point in time", search.Header.Index)
+		}
+
+		indices, ok := cli.pits[req.PIT.ID]
+		if !ok {
+			return nil, newStatusError("error occured while trying to search documents", http.StatusNotFound,
+				"search_context_missing_exception", "No search context found for id ["+req.PIT.ID+"]", "")
+		}
+		search.Header.Index = strings.Join(indices, ",")
+	}
+
+	hits, err := cli.matching(search)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Slice != nil {
+		if hits, err = sliceHits(hits, req.Slice); err != nil {
+			return nil, newStatusError("error occured while trying to search documents", http.StatusBadRequest,
+				"illegal_argument_exception", err.Error(), search.Header.Index)
+		}
+	}
+
+	sortFields, err := parseSort(req.Sort)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to search documents", http.StatusBadRequest,
+			"parsing_exception", err.Error(), search.Header.Index)
+	}
+
+	// As in elasticsearch, ties in a search of a point in time are implicitly broken on _shard_doc
+	if req.PIT != nil {
+		for i := range hits {
+			hits[i].shardDoc = i
+		}
+		if !hasSortField(sortFields, "_shard_doc") {
+			sortFields = append(sortFields, sortField{field: "_shard_doc"})
+		}
+	}
+	sortHits(hits, sortFields)
+
+	// As in elasticsearch, the total counts every matching document, including those before search_after
+	matched := len(hits)
+	if req.SearchAfter != nil {
+		if len(req.SearchAfter) != len(sortFields) {
+			return nil, newStatusError("error occured while trying to search documents", http.StatusBadRequest,
+				"illegal_argument_exception", fmt.Sprintf("search_after has %d value(s) but sort has %d",
+					len(req.SearchAfter), len(sortFields)), search.Header.Index)
+		}
+		hits = searchAfter(hits, sortFields, req.SearchAfter)
+	}
+
+	from, size := 0, defaultSearchSize
+	if req.From != nil {
+		from = *req.From
+	}
+	if req.Size != nil {
+		size = *req.Size
+	}
+
+	res := &searchResponse{
+		Shards: shards{Total: 1, Successful: 1},
+		Hits: searchHits{
+			Total: total{Value: matched, Relation: "eq"},
+			Hits:  []searchHit{},
+		},
+	}
+	if req.PIT != nil {
+		res.PitID = req.PIT.ID
+	}
+
+	score := 1.0
+	if len(sortFields) == 0 && len(hits) > 0 {
+		res.Hits.MaxScore = &score
+	}
+
+	for i := from; i < len(hits) && i < from+size; i++ {
+		h := searchHit{
+			Index:  hits[i].index,
+			ID:     hits[i].doc.id,
+			Source: hits[i].doc.source,
+		}
+		if len(sortFields) == 0 {
+			h.Score = &score
+		}
+		for _, sf := range sortFields {
+			h.Sort = append(h.Sort, sortValue(hits[i], sf.field))
+		}
+		res.Hits.Hits = append(res.Hits.Hits, h)
+	}
+
+	return res, nil
+}
+
+// matching returns every document, across the searched indices, that matches the search query.
+// It must be called with at least the read lock held.
+func (cli *Client) matching(search client.Search) ([]hit, error) {
+	req, err := parseSearchRequest(search.Query)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to search documents", http.StatusBadRequest,
+			"parsing_exception", err.Error(), search.Header.Index)
+	}
+
+	names, err := cli.resolve(splitIndices(search.Header.Index), true)
+	if err != nil {
+		return nil, newStatusError("error occured while trying to search documents", http.StatusNotFound,
+			"index_not_found_exception", "no such index ["+err.Error()+"]", err.Error())
+	}
+
+	var hits []hit
+	for _, name := range names {
+		idx := cli.indices[name]
+		for _, id := range idx.order {
+			matched, err := matches(req.Query, idx.docs[id])
+			if err != nil {
+				return nil, newStatusError("error occured while trying to search documents", http.StatusBadRequest,
+					"parsing_exception", err.Error(), name)
+			}
+			if matched {
+				hits = append(hits, hit{index: name, doc: idx.docs[id]})
+			}
+		}
+	}
+
+	return hits, nil
+}
+
+// matches reports whether a document matches a query clause. A nil or empty clause matches everything.
+func matches(query map[string]json.RawMessage, doc *document) (bool, error) {
+	if len(query) == 0 {
+		return true, nil
+	}
+
+	if len(query) != 1 {
+		return false, fmt.Errorf("query clause must contain exactly one query type, got %d", len(query))
+	}
+
+	for queryType, body := range query {
+		switch queryType {
+		case "match_all":
+			return true, nil
+		case "match_none":
+			return false, nil
+		case "term":
+			return matchTerm(body, doc)
+		case "terms":
+			return matchTerms(body, doc)
+		case "match":
+			return matchText(body, doc)
+		case "bool":
+			return matchBool(body, doc)
+		case "range":
+			return matchRange(body, doc)
+		case "exists":
+			return matchExists(body, doc)
+		case "ids":
+			return matchIDs(body, doc)
+		default:
+			return false, fmt.Errorf("unsupported query type [%s] in fake client", queryType)
+		}
+	}
+
+	return false, nil
+}
+
+// fieldQuery decodes the common {"field": value} and {"field": {"<key>": value, ...}} query shapes
+func fieldQuery(body json.RawMessage, key string) (field string, value interface{}, params map[string]interface{}, err error) {
+	var clause map[string]interface{}
+	if err = json.Unmarshal(body, &clause); err != nil {
+		return "", nil, nil, err
+	}
+
+	if len(clause) != 1 {
+		return "", nil, nil, fmt.Errorf("query must target exactly one field, got %d", len(clause))
+	}
+
+	for f, v := range clause {
+		field, value = f, v
+		if p, ok := v.(map[string]interface{}); ok {
+			params = p
+			value = p[key]
+		}
+	}
+
+	return field, value, params, nil
+}
+
+func matchTerm(body json.RawMessage, doc *document) (bool, error) {
+	field, value, _, err := fieldQuery(body, "value")
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range fieldValues(doc, field) {
+		if equal(v, value) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func matchTerms(body json.RawMessage, doc *document) (bool, error) {
+	var clause map[string]interface{}
+	if err := json.Unmarshal(body, &clause); err != nil {
+		return false, err
+	}
+
+	for field, values := range clause {
+		if field == "boost" {
+			continue
+		}
+
+		list, ok := values.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("terms query for field [%s] must be an array", field)
+		}
+
+		for _, v := range fieldValues(doc, field) {
+			for _, want := range list {
+				if equal(v, want) {
+					return true, nil
+				}
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// matchText approximates full text matching by comparing lower cased, whitespace separated tokens
+func matchText(body json.RawMessage, doc *document) (bool, error) {
+	field, value, params, err := fieldQuery(body, "query")
+	if err != nil {
+		return false, err
+	}
+
+	requireAll := false
+	if params != nil {
+		if op, ok := params["operator"].(string); ok {
+			requireAll = strings.EqualFold(op, "and")
+		}
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return matchTerm(mustMarshal(map[string]interface{}{field: value}), doc)
+	}
+
+	wanted := tokenize(text)
+	if len(wanted) == 0 {
+		return false, nil
+	}
+
+	present := make(map[string]bool)
+	for _, v := range fieldValues(doc, field) {
+		s, ok := v.(string)
+		if !ok {
+			s = fmt.Sprint(v)
+		}
+		for _, token := range tokenize(s) {
+			present[token] = true
+		}
+	}
+
+	found := 0
+	for _, token := range wanted {
+		if present[token] {
+			found++
+		}
+	}
+
+	if requireAll {
+		return found == len(wanted), nil
+	}
+
+	return found > 0, nil
+}
+
+func matchBool(body json.RawMessage, doc *document) (bool, error) {
+	var clause struct {
+		Must               json.RawMessage `json:"must"`
+		Filter             json.RawMessage `json:"filter"`
+		Should             json.RawMessage `json:"should"`
+		MustNot            json.RawMessage `json:"must_not"`
+		MinimumShouldMatch interface{}     `json:"minimum_should_match"`
+	}
+	if err := json.Unmarshal(body, &clause); err != nil {
+		return false, err
+	}
+
+	for _, required := range []json.RawMessage{clause.Must, clause.Filter} {
+		queries, err := queryList(required)
+		if err != nil {
+			return false, err
+		}
+		for _, q := range queries {
+			ok, err := matches(q, doc)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+	}
+
+	mustNot, err := queryList(clause.MustNot)
+	if err != nil {
+		return false, err
+	}
+	for _, q := range mustNot {
+		ok, err := matches(q, doc)
+		if err != nil || ok {
+			return false, err
+		}
+	}
+
+	should, err := queryList(clause.Should)
+	if err != nil {
+		return false, err
+	}
+
+	// As in elasticsearch, at least one should clause must match when there are no required clauses
+	minimumShouldMatch := 0
+	if len(should) > 0 && len(clause.Must) == 0 && len(clause.Filter) == 0 {
+		minimumShouldMatch = 1
+	}
+	if n, ok := clause.MinimumShouldMatch.(float64); ok {
+		minimumShouldMatch = int(n)
+	}
+
+	matched := 0
+	for _, q := range should {
+		ok, err := matches(q, doc)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			matched++
+		}
+	}
+
+	return matched >= minimumShouldMatch, nil
+}
+
+func matchRange(body json.RawMessage, doc *document) (bool, error) {
+	field, _, params, err := fieldQuery(body, "")
+	if err != nil {
+		return false, err
+	}
+
+	if params == nil {
+		return false, fmt.Errorf("range query for field [%s] must be an object", field)
+	}
+
+	for _, v := range fieldValues(doc, field) {
+		inRange := true
+		for op, bound := range params {
+			c, ok := compare(v, bound)
+			if !ok {
+				if op == "gt" || op == "gte" || op == "lt" || op == "lte" {
+					inRange = false
+				}
+				continue
+			}
+
+			switch op {
+			case "gt":
+				inRange = inRange && c > 0
+			case "gte":
+				inRange = inRange && c >= 0
+			case "lt":
+				inRange = inRange && c < 0
+			case "lte":
+				inRange = inRange && c <= 0
+			}
+		}
+		if inRange {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func matchExists(body json.RawMessage, doc *document) (bool, error) {
+	var clause struct {
+		Field string `json:"field"`
+	}
+	if err := json.Unmarshal(body, &clause); err != nil {
+		return false, err
+	}
+
+	return len(fieldValues(doc, clause.Field)) > 0, nil
+}
+
+func matchIDs(body json.RawMessage, doc *document) (bool, error) {
+	var clause struct {
+		Values []string `json:"values"`
+	}
+	if err := json.Unmarshal(body, &clause); err != nil {
+		return false, err
+	}
+
+	for _, id := range clause.Values {
+		if id == doc.id {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// queryList decodes a bool clause, which may be either a single query or an array of queries
+func queryList(raw json.RawMessage) ([]map[string]json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var list []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, nil
+	}
+
+	var single map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &single); err != nil {
+		return nil, err
+	}
+
+	return []map[string]json.RawMessage{single}, nil
+}
+
+// fieldValues returns the values at a dotted field path, flattening arrays and objects in arrays
+func fieldValues(doc *document, field string) []interface{} {
+	if field == "_id" {
+		return []interface{}{doc.id}
+	}
+
+	values := []interface{}{doc.fields}
+	for _, part := range strings.Split(field, ".") {
+		var next []interface{}
+		for _, v := range values {
+			for _, item := range flatten(v) {
+				if obj, ok := item.(map[string]interface{}); ok {
+					if child, ok := obj[part]; ok {
+						next = append(next, child)
+					}
+				}
+			}
+		}
+		values = next
+	}
+
+	var leaves []interface{}
+	for _, v := range values {
+		for _, item := range flatten(v) {
+			if item != nil {
+				leaves = append(leaves, item)
+			}
+		}
+	}
+
+	return leaves
+}
+
+func flatten(v interface{}) []interface{} {
+	if list, ok := v.([]interface{}); ok {
+		return list
+	}
+
+	return []interface{}{v}
+}
+
+func equal(a, b interface{}) bool {
+	c, ok := compare(a, b)
+	return ok && c == 0
+}
+
+// compare orders two JSON values of the same kind, reporting false if they are not comparable
+func compare(a, b interface{}) (int, bool) {
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av < bv:
+			return -1, true
+		case av > bv:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(av, bv), true
+	case bool:
+		bv, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		if av == bv {
+			return 0, true
+		}
+		if !av {
+			return -1, true
+		}
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	})
+}
+
+// parseSort decodes the sort options, which may be a field name, an object or an array of either
+func parseSort(raw json.RawMessage) ([]sortField, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		list = []json.RawMessage{raw}
+	}
+
+	fields := make([]sortField, 0, len(list))
+	for _, item := range list {
+		var name string
+		if err := json.Unmarshal(item, &name); err == nil {
+			fields = append(fields, sortField{field: name})
+			continue
+		}
+
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(item, &obj); err != nil {
+			return nil, fmt.Errorf("failed to parse sort: %w", err)
+		}
+
+		for name, order := range obj {
+			var direction string
+			if err := json.Unmarshal(order, &direction); err != nil {
+				var options struct {
+					Order string `json:"order"`
+				}
+				if err := json.Unmarshal(order, &options); err != nil {
+					return nil, fmt.Errorf("failed to parse sort for field [%s]: %w", name, err)
+				}
+				direction = options.Order
+			}
+			fields = append(fields, sortField{field: name, desc: strings.EqualFold(direction, "desc")})
+		}
+	}
+
+	return fields, nil
+}
+
+// sortHits sorts hits by the given fields, leaving insertion order in place for ties. Documents
+// missing a sort field are sorted last, as they are by default in elasticsearch.
+func sortHits(hits []hit, fields []sortField) {
+	if len(fields) == 0 {
+		return
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		for _, sf := range fields {
+			a, b := sortValue(hits[i], sf.field), sortValue(hits[j], sf.field)
+			switch {
+			case a == nil && b == nil:
+				continue
+			case a == nil:
+				return false
+			case b == nil:
+				return true
+			}
+
+			c, ok := compare(a, b)
+			if !ok || c == 0 {
+				continue
+			}
+			if sf.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// sliceHits returns the hits in a slice of a sliced search. Elasticsearch assigns documents to slices by a hash of
+// their IDs, whereas the fake assigns them in turn in the order they were matched.
+func sliceHits(hits []hit, s *slice) ([]hit, error) {
+	if s.Max <= 1 {
+		return nil, fmt.Errorf("max must be greater than 1")
+	}
+	if s.ID < 0 || s.ID >= s.Max {
+		return nil, fmt.Errorf("id must be greater than or equal to 0 and lower than max")
+	}
+
+	var sliced []hit
+	for i, h := range hits {
+		if i%s.Max == s.ID {
+			sliced = append(sliced, h)
+		}
+	}
+
+	return sliced, nil
+}
+
+// searchAfter returns the sorted hits that come after the given sort values
+func searchAfter(hits []hit, fields []sortField, values []interface{}) []hit {
+	for i, h := range hits {
+		if sortsAfter(h, fields, values) {
+			return hits[i:]
+		}
+	}
+
+	return nil
+}
+
+// sortsAfter reports whether a hit comes after the given sort values, in the same order as sortHits
+func sortsAfter(h hit, fields []sortField, values []interface{}) bool {
+	for i, sf := range fields {
+		a, b := sortValue(h, sf.field), values[i]
+		switch {
+		case a == nil && b == nil:
+			continue
+		case a == nil:
+			return true
+		case b == nil:
+			return false
+		}
+
+		c, ok := compare(a, b)
+		if !ok || c == 0 {
+			continue
+		}
+		if sf.desc {
+			return c < 0
+		}
+		return c > 0
+	}
+
+	return false
+}
+
+func hasSortField(fields []sortField, field string) bool {
+	for _, sf := range fields {
+		if sf.field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortValue(h hit, field string) interface{} {
+	if field == "_doc" || field == "_score" {
+		return nil
+	}
+
+	if field == "_shard_doc" {
+		return float64(h.shardDoc)
+	}
+
+	values := fieldValues(h.doc, field)
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values[0]
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	b, _ := json.Marshal(v)
+	return b
+}
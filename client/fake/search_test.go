@@ -0,0 +1,235 @@
+package fake
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSearchQueries(t *testing.T) {
+	Convey("Given a fake client with some documents", t, func() {
+		cli := NewClient()
+		docs := map[string]string{
+			"1": `{"title":"Consumer price inflation","type":"bulletin","year":2020,"topics":["economy","prices"]}`,
+			"2": `{"title":"Labour market overview","type":"bulletin","year":2021,"topics":["employment"]}`,
+			"3": `{"title":"Consumer trends","type":"article","year":2022,"meta":{"release":"2022-03-01"}}`,
+		}
+		for _, id := range []string{"1", "2", "3"} {
+			addDocument(cli, "my-index", id, docs[id])
+		}
+
+		testCases := []struct {
+			name     string
+			query    string
+			expected []string
+		}{
+			{"match_all", `{"query":{"match_all":{}}}`, []string{"1", "2", "3"}},
+			{"empty body", ``, []string{"1", "2", "3"}},
+			{"term", `{"query":{"term":{"type":"bulletin"}}}`, []string{"1", "2"}},
+			{"term with value", `{"query":{"term":{"year":{"value":2021}}}}`, []string{"2"}},
+			{"term on array", `{"query":{"term":{"topics":"prices"}}}`, []string{"1"}},
+			{"terms", `{"query":{"terms":{"year":[2020,2022]}}}`, []string{"1", "3"}},
+			{"match", `{"query":{"match":{"title":"consumer market"}}}`, []string{"1", "2", "3"}},
+			{"match with and operator", `{"query":{"match":{"title":{"query":"consumer trends","operator":"and"}}}}`, []string{"3"}},
+			{"range", `{"query":{"range":{"year":{"gte":2021,"lt":2022}}}}`, []string{"2"}},
+			{"range on nested string", `{"query":{"range":{"meta.release":{"gt":"2022-01-01"}}}}`, []string{"3"}},
+			{"exists", `{"query":{"exists":{"field":"meta"}}}`, []string{"3"}},
+			{"ids", `{"query":{"ids":{"values":["2","3"]}}}`, []string{"2", "3"}},
+			{
+				"bool",
+				`{"query":{"bool":{"must":[{"match":{"title":"consumer"}}],"must_not":{"term":{"type":"article"}}}}}`,
+				[]string{"1"},
+			},
+			{
+				"bool should",
+				`{"query":{"bool":{"should":[{"term":{"year":2020}},{"term":{"year":2022}}]}}}`,
+				[]string{"1", "3"},
+			},
+			{
+				"bool filter with should",
+				`{"query":{"bool":{"filter":{"term":{"type":"bulletin"}},"should":[{"term":{"year":2020}}]}}}`,
+				[]string{"1", "2"},
+			},
+			{"sort", `{"query":{"match_all":{}},"sort":[{"year":{"order":"desc"}}]}`, []string{"3", "2", "1"}},
+			{"from and size", `{"sort":["year"],"from":1,"size":1}`, []string{"2"}},
+		}
+
+		for _, tc := range testCases {
+			Convey("When searching with a "+tc.name+" query", func() {
+				ids := searchIDs(cli, "my-index", tc.query)
+
+				Convey("Then the expected documents are returned", func() {
+					So(ids, ShouldResemble, tc.expected)
+				})
+			})
+		}
+
+		Convey("When searching with an unsupported query type", func() {
+			_, err := cli.Search(testCtx, client.Search{
+				Header: client.Header{Index: "my-index"},
+				Query:  []byte(`{"query":{"fuzzy":{"title":"consumr"}}}`),
+			})
+
+			Convey("Then a 400 error is returned", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 400)
+				So(err.Error(), ShouldContainSubstring, "unsupported query type [fuzzy]")
+			})
+		})
+
+		Convey("When searching a missing index", func() {
+			_, err := cli.Search(testCtx, client.Search{Header: client.Header{Index: "missing"}})
+
+			Convey("Then a 404 error is returned", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 404)
+			})
+		})
+
+		Convey("When the response is decoded", func() {
+			data, err := cli.Search(testCtx, client.Search{
+				Header: client.Header{Index: "my-index"},
+				Query:  []byte(`{"query":{"term":{"type":"article"}}}`),
+			})
+			So(err, ShouldBeNil)
+
+			var res testSearchResponse
+			So(json.Unmarshal(data, &res), ShouldBeNil)
+
+			Convey("Then it has the same shape as an elasticsearch response", func() {
+				So(res.Hits.Total.Value, ShouldEqual, 1)
+				So(res.Hits.Hits[0].Index, ShouldEqual, "my-index")
+				So(string(res.Hits.Hits[0].Source), ShouldEqual, docs["3"])
+			})
+		})
+
+		Convey("When a multi search is made", func() {
+			data, err := cli.MultiSearch(testCtx, []client.Search{
+				{Header: client.Header{Index: "my-index"}, Query: []byte(`{"query":{"term":{"year":2020}}}`)},
+				{Header: client.Header{Index: "missing"}},
+			}, nil)
+			So(err, ShouldBeNil)
+
+			var res struct {
+				Responses []json.RawMessage `json:"responses"`
+			}
+			So(json.Unmarshal(data, &res), ShouldBeNil)
+
+			Convey("Then a response is returned for each search", func() {
+				So(res.Responses, ShouldHaveLength, 2)
+				So(string(res.Responses[1]), ShouldContainSubstring, `"status":404`)
+			})
+		})
+
+		Convey("When explain is called", func() {
+			data, err := cli.Explain(testCtx, "1", client.Search{
+				Header: client.Header{Index: "my-index"},
+				Query:  []byte(`{"query":{"term":{"type":"article"}}}`),
+			})
+
+			Convey("Then whether the document matched is returned", func() {
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"_id":"1","_index":"my-index","matched":false}`)
+			})
+		})
+	})
+}
+
+func TestPointInTime(t *testing.T) {
+	Convey("Given a fake client with documents that tie on their sort field", t, func() {
+		cli := NewClient()
+		addDocument(cli, "my-index", "1", `{"year":2021}`)
+		addDocument(cli, "my-index", "2", `{"year":2020}`)
+		addDocument(cli, "my-index", "3", `{"year":2021}`)
+		addDocument(cli, "my-index", "4", `{"year":2020}`)
+
+		pitID, err := cli.OpenPointInTime(testCtx, []string{"my-index"}, time.Minute)
+		So(err, ShouldBeNil)
+		So(cli.OpenPointsInTime(), ShouldResemble, []string{pitID})
+
+		Convey("When the point in time is searched", func() {
+			data, err := cli.Search(testCtx, client.Search{
+				Query: []byte(`{"pit":{"id":"` + pitID + `"},"sort":[{"year":"desc"}],"size":2}`),
+			})
+			So(err, ShouldBeNil)
+
+			var res struct {
+				PitID string `json:"pit_id"`
+				Hits  struct {
+					Hits []struct {
+						ID   string        `json:"_id"`
+						Sort []interface{} `json:"sort"`
+					} `json:"hits"`
+				} `json:"hits"`
+			}
+			So(json.Unmarshal(data, &res), ShouldBeNil)
+
+			Convey("Then ties are broken on an implicit _shard_doc sort value", func() {
+				So(res.PitID, ShouldEqual, pitID)
+				So(res.Hits.Hits, ShouldHaveLength, 2)
+				So(res.Hits.Hits[0].ID, ShouldEqual, "1")
+				So(res.Hits.Hits[0].Sort, ShouldResemble, []interface{}{float64(2021), float64(0)})
+				So(res.Hits.Hits[1].ID, ShouldEqual, "3")
+				So(res.Hits.Hits[1].Sort, ShouldResemble, []interface{}{float64(2021), float64(2)})
+			})
+
+			Convey("Then searching after the last hit returns the following documents", func() {
+				ids := searchIDs(cli, "", `{"pit":{"id":"`+pitID+`"},"sort":[{"year":"desc"}],"search_after":[2021,2]}`)
+				So(ids, ShouldResemble, []string{"2", "4"})
+			})
+		})
+
+		Convey("When the point in time is searched with an index", func() {
+			_, err := cli.Search(testCtx, client.Search{
+				Header: client.Header{Index: "my-index"},
+				Query:  []byte(`{"pit":{"id":"` + pitID + `"}}`),
+			})
+
+			Convey("Then a 400 error is returned", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 400)
+			})
+		})
+
+		Convey("When the point in time is closed", func() {
+			So(cli.ClosePointInTime(testCtx, pitID), ShouldBeNil)
+
+			Convey("Then searching it returns a 404 error", func() {
+				_, err := cli.Search(testCtx, client.Search{Query: []byte(`{"pit":{"id":"` + pitID + `"}}`)})
+				So(esError.ErrorStatus(err), ShouldEqual, 404)
+				So(cli.OpenPointsInTime(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestSlicedSearch(t *testing.T) {
+	Convey("Given a fake client with some documents", t, func() {
+		cli := NewClient()
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			addDocument(cli, "my-index", id, `{}`)
+		}
+
+		Convey("When it is searched in two slices", func() {
+			first := searchIDs(cli, "my-index", `{"slice":{"id":0,"max":2}}`)
+			second := searchIDs(cli, "my-index", `{"slice":{"id":1,"max":2}}`)
+
+			Convey("Then each document is in exactly one slice", func() {
+				So(first, ShouldResemble, []string{"1", "3", "5"})
+				So(second, ShouldResemble, []string{"2", "4"})
+			})
+		})
+
+		Convey("When a slice beyond max is searched", func() {
+			_, err := cli.Search(testCtx, client.Search{
+				Header: client.Header{Index: "my-index"},
+				Query:  []byte(`{"slice":{"id":2,"max":2}}`),
+			})
+
+			Convey("Then a 400 error is returned", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 400)
+			})
+		})
+	})
+}
@@ -0,0 +1,20 @@
+// Package bulkctx holds the values a bulk indexer passes to the callbacks of its items through their context. It
+// is a package of its own so that both the client package, which reads them, and the client implementations, which
+// set them, can import it.
+package bulkctx
+
+import "context"
+
+// attemptsKey is the context key of the number of times a bulk item was sent
+type attemptsKey struct{}
+
+// WithAttempts returns a copy of ctx holding the number of times a bulk item was sent
+func WithAttempts(ctx context.Context, attempts int) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, attempts)
+}
+
+// Attempts returns the number of times a bulk item was sent held by ctx, or zero if it holds none
+func Attempts(ctx context.Context) int {
+	attempts, _ := ctx.Value(attemptsKey{}).(int)
+	return attempts
+}
@@ -0,0 +1,42 @@
+package clientutil
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+)
+
+// CheckAddress returns an error if requested, the address passed to a deprecated method such as BulkUpdate, is set
+// and is not address, the one the client was created with. Requests are only ever sent to the address of the client,
+// so a different one would otherwise be silently ignored.
+func CheckAddress(address, requested string) error {
+	if requested == "" || sameAddress(address, requested) {
+		return nil
+	}
+
+	return esError.StatusError{
+		Err:  fmt.Errorf("requests are only sent to the address the client was created with, %s, not to %s", address, requested),
+		Code: http.StatusBadRequest,
+	}
+}
+
+// sameAddress reports whether the two addresses have the same scheme, host and path, ignoring case where it does not
+// matter and any trailing slash
+func sameAddress(a, b string) bool {
+	urlA, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+
+	urlB, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(urlA.Scheme, urlB.Scheme) &&
+		strings.EqualFold(urlA.Host, urlB.Host) &&
+		strings.TrimSuffix(urlA.Path, "/") == strings.TrimSuffix(urlB.Path, "/")
+}
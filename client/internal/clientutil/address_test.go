@@ -0,0 +1,32 @@
+package clientutil_test
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckAddress(t *testing.T) {
+	Convey("Given a client created with an address", t, func() {
+		address := "http://localhost:9200"
+
+		Convey("Then no address, or the same address, is accepted", func() {
+			So(clientutil.CheckAddress(address, ""), ShouldBeNil)
+			So(clientutil.CheckAddress(address, "http://localhost:9200"), ShouldBeNil)
+			So(clientutil.CheckAddress(address, "HTTP://LOCALHOST:9200/"), ShouldBeNil)
+		})
+
+		Convey("Then a different address is rejected with a 400", func() {
+			err := clientutil.CheckAddress(address, "http://other-cluster:9200")
+			So(err, ShouldNotBeNil)
+			So(esError.ErrorStatus(err), ShouldEqual, 400)
+			So(err.Error(), ShouldContainSubstring, "http://other-cluster:9200")
+		})
+
+		Convey("Then an address that cannot be parsed is rejected", func() {
+			So(clientutil.CheckAddress(address, "http://%zz"), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,26 @@
+package clientutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+)
+
+// NewAliasActionsBody validates the actions and returns the body of an update aliases request for them
+func NewAliasActionsBody(actions []client.AliasAction) ([]byte, error) {
+	if len(actions) == 0 {
+		return nil, errors.New("at least one alias action is required")
+	}
+
+	for i, action := range actions {
+		if err := action.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid alias action %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(struct {
+		Actions []client.AliasAction `json:"actions"`
+	}{Actions: actions})
+}
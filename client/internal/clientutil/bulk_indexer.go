@@ -0,0 +1,915 @@
+package clientutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/bulkctx"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+)
+
+// bulkItemRetryOnStatus are the statuses of bulk items that are retried, as the cluster rejected them while
+// overloaded rather than because of the item itself
+var bulkItemRetryOnStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusServiceUnavailable,
+}
+
+var (
+	// errClosing is returned when sending an item to an indexer that is being closed
+	errClosing = errors.New("bulk indexer is closing")
+	// errBusy is returned when sending an item without waiting and every lane of the indexer is busy
+	errBusy = errors.New("every worker of the bulk indexer is busy")
+)
+
+// Indexer is a bulk indexer of a client library, opened by a client implementation for WrapBulkIndexer
+type Indexer interface {
+	// Add adds an item to the indexer, waiting until its worker can take it or ctx is done
+	Add(ctx context.Context, item Item) error
+	// Close waits until every added item has been flushed and closes the indexer
+	Close(ctx context.Context) error
+	// Stats returns the counts of items handled by the indexer since it was opened
+	Stats() client.BulkIndexerStats
+}
+
+// Item is an item added to an Indexer. Its body must only be read by the worker of the indexer, when the item is
+// written to the request it is flushed with, so that WrapBulkIndexer knows which items each request holds.
+type Item struct {
+	Action     client.BulkIndexerAction
+	Index      string
+	DocumentID string
+	Body       io.ReadSeeker
+	OnSuccess  client.SuccessFunc
+	OnFailure  client.FailureFunc
+}
+
+// WrapBulkIndexer returns a bulk indexer, configured by cfg, that adds the behaviour shared by every client to the
+// bulk indexers of a client library, which are opened with open. A nil cfg uses the defaults.
+//
+// Each of the cfg.NumWorkers workers is an indexer of its own, opened with a single worker, so that the items of
+// each request are known. When a request fails as a whole the client libraries do not call the callbacks of its
+// items, so they are failed, and sent to the dead letter sink, once the flush has ended.
+//
+// Items rejected with a 429 or 503 are retried up to cfg.MaxAttempts times, and only passed to their FailureFunc,
+// and the dead letter sink, once they have run out of attempts. As items that fail while the indexer is being
+// closed cannot be added back to it, they are retried with further indexers opened with open until none are left.
+// Progress is reported and the failure ratio checked on close across every indexer opened.
+//
+// When cfg.MaxBufferedBytes is set, Add waits, and TryAdd fails, while the documents of the items not yet completed
+// would take up more than it. When cfg.MinFlushBytes is set, the flush size is adapted to the latency of flushes and
+// the items rejected, and each change of size is applied by opening new indexers and closing the previous ones in
+// the background.
+func WrapBulkIndexer(ctx context.Context, cfg *client.BulkIndexerConfig, open func(cfg client.BulkIndexerConfig) (Indexer, error)) (client.BulkIndexer, error) {
+	c := cfg.WithDefaults()
+
+	maxRetries := c.MaxAttempts - 1
+	if maxRetries <= 0 {
+		maxRetries = -1
+	}
+
+	b := &wrappedBulkIndexer{
+		cfg:  c,
+		open: open,
+		retry: client.NewRetryPolicy(client.Config{
+			MaxRetries:      maxRetries,
+			MinRetryBackoff: c.MinRetryBackoff,
+			MaxRetryBackoff: c.MaxRetryBackoff,
+			RetryOnStatus:   bulkItemRetryOnStatus,
+		}),
+		buffer:     newBulkBuffer(c.MaxBufferedBytes),
+		flushBytes: c.FlushBytes,
+	}
+
+	if c.MaxBufferedBytes > 0 || c.MinFlushBytes > 0 {
+		maxFlushBytes := c.FlushBytes
+		if maxFlushBytes <= 0 {
+			maxFlushBytes = client.DefaultBulkIndexerFlushBytes
+		}
+		if c.MaxBufferedBytes > 0 {
+			// Each worker fills a request of its own, so the buffer must hold a full request for every worker or it
+			// could fill up before any of them flushes, leaving Add waiting for the flush interval
+			maxFlushBytes = min(maxFlushBytes, max(c.MaxBufferedBytes/c.NumWorkers, 1))
+		}
+		if c.MinFlushBytes > 0 {
+			b.sizer = newFlushSizer(min(c.MinFlushBytes, maxFlushBytes), maxFlushBytes, c.TargetFlushLatency)
+		}
+		b.flushBytes = maxFlushBytes
+	}
+
+	lanes, err := b.openLanes(b.flushBytes)
+	if err != nil {
+		return nil, err
+	}
+	b.lanes = lanes
+	b.stopProgress = ReportBulkProgress(ctx, c, b.Stats)
+
+	return b, nil
+}
+
+// wrappedBulkIndexer is the bulk indexer returned by WrapBulkIndexer
+type wrappedBulkIndexer struct {
+	cfg          client.BulkIndexerConfig
+	open         func(cfg client.BulkIndexerConfig) (Indexer, error)
+	retry        client.RetryPolicy
+	stopProgress func()
+	buffer       *bulkBuffer
+	sizer        *flushSizer // Adapts the flush size, nil unless it is adaptive
+	next         atomic.Uint64
+
+	// mu guards the current lanes, which are nil between closing them and opening the next, their flush size, the
+	// lanes being retired after a change of flush size and the stats of the lanes already closed
+	mu          sync.RWMutex
+	lanes       []*lane
+	flushBytes  int
+	closing     bool
+	retiring    []*lane
+	closedStats client.BulkIndexerStats
+
+	retirements sync.WaitGroup
+	retries     sync.WaitGroup
+	deferMu     sync.Mutex
+	deferred    []*bulkItem
+
+	numRetried atomic.Uint64 // Failures that were retried, so are not counted as failed
+	numResent  atomic.Uint64 // Items added again to retry them, so are not counted as added
+	numLost    atomic.Uint64 // Items that failed without being counted as failed by an indexer
+}
+
+// bulkItem is an item added to a wrappedBulkIndexer, kept so that it can be sent again
+type bulkItem struct {
+	action     client.BulkIndexerAction
+	index      string
+	documentID string
+	document   []byte
+	onSuccess  client.SuccessFunc
+	onFailure  client.FailureFunc
+	attempts   int
+}
+
+// Add adds an item to the indexer, first waiting until there is room for it in the buffer if that is limited. An
+// error matching both client.ErrBulkIndexerFull and the error of ctx is returned if ctx is done while waiting. Use
+// the onSuccess and onFailure callbacks to get the result of the item, and client.BulkItemAttempts with their context
+// to get the number of times it was sent.
+func (b *wrappedBulkIndexer) Add(ctx context.Context, action client.BulkIndexerAction, index, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
+	if err := b.buffer.acquire(ctx, len(document)); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("%w: gave up waiting for room for document %s: %w", client.ErrBulkIndexerFull, documentID, err),
+			Code: http.StatusTooManyRequests,
+		}
+	}
+
+	b.resize(ctx)
+
+	item := b.newItem(action, index, documentID, document, onSuccess, onFailure)
+	if err := b.send(ctx, item, true); err != nil {
+		b.buffer.release(len(document))
+		return addError(err)
+	}
+
+	return nil
+}
+
+// TryAdd adds an item to the indexer as Add does, except that it returns an error matching client.ErrBulkIndexerFull
+// straight away if there is no room for the item in the buffer or every worker is busy
+func (b *wrappedBulkIndexer) TryAdd(ctx context.Context, action client.BulkIndexerAction, index, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
+	if !b.buffer.tryAcquire(len(document)) {
+		return esError.StatusError{
+			Err:  fmt.Errorf("%w: no room for document %s of %d bytes, the maximum buffered is %d", client.ErrBulkIndexerFull, documentID, len(document), b.cfg.MaxBufferedBytes),
+			Code: http.StatusTooManyRequests,
+		}
+	}
+
+	b.resize(ctx)
+
+	item := b.newItem(action, index, documentID, document, onSuccess, onFailure)
+	if err := b.send(ctx, item, false); err != nil {
+		b.buffer.release(len(document))
+		if errors.Is(err, errBusy) {
+			return esError.StatusError{
+				Err:  fmt.Errorf("%w: %w, document %s was not added", client.ErrBulkIndexerFull, err, documentID),
+				Code: http.StatusTooManyRequests,
+			}
+		}
+		return addError(err)
+	}
+
+	return nil
+}
+
+// newItem returns an item to send, which is sent to the dead letter sink if it fails
+func (b *wrappedBulkIndexer) newItem(action client.BulkIndexerAction, index, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) *bulkItem {
+	return &bulkItem{
+		action:     action,
+		index:      index,
+		documentID: documentID,
+		document:   document,
+		onSuccess:  onSuccess,
+		onFailure:  DeadLetterOnFailure(b.cfg, action, document, onFailure),
+	}
+}
+
+// addError returns the error of an item that could not be added
+func addError(err error) error {
+	if errors.Is(err, errClosing) {
+		return esError.StatusError{
+			Err:  client.ErrBulkIndexerClosed,
+			Code: http.StatusInternalServerError,
+		}
+	}
+
+	return err
+}
+
+// send adds the item to one of the current lanes, waiting for one to take it if wait is set. errBusy is returned
+// if wait is not set and every lane is busy, and errClosing if the indexer is being closed.
+func (b *wrappedBulkIndexer) send(ctx context.Context, item *bulkItem, wait bool) error {
+	for {
+		b.mu.RLock()
+		lanes, closing := b.lanes, b.closing
+		b.mu.RUnlock()
+		if closing {
+			return errClosing
+		}
+
+		added, err := b.sendTo(ctx, lanes, item, wait)
+		if added || err != nil {
+			return err
+		}
+		if !wait {
+			return errBusy
+		}
+		// The lanes were retired by a change of flush size, so the item is sent to the lanes that replaced them
+	}
+}
+
+// sendTo adds the item to the first of lanes that is free, or if none are and wait is set, waits for one of them
+// to take it. It reports whether the item was added, which it is not if every lane is busy or has been closed.
+func (b *wrappedBulkIndexer) sendTo(ctx context.Context, lanes []*lane, item *bulkItem, wait bool) (bool, error) {
+	// The attempt is counted before the item is added, as its callbacks may be called before the add returns
+	s := &sending{item: item, attempt: item.attempts + 1}
+	item.attempts = s.attempt
+
+	// Filling the first free lane, rather than spreading items across them, keeps the requests full
+	for _, l := range lanes {
+		if added, err := l.add(ctx, b.newAttempt(s, l), false); added {
+			return true, err
+		}
+	}
+
+	if wait && len(lanes) > 0 {
+		l := lanes[b.next.Add(1)%uint64(len(lanes))]
+		if added, err := l.add(ctx, b.newAttempt(s, l), true); added {
+			return true, err
+		}
+	}
+
+	item.attempts--
+	return false, nil
+}
+
+// newAttempt returns the item to add to the lane for an attempt to send an item. Its callbacks complete the
+// attempt, so that it is not also failed when the flush ends.
+func (b *wrappedBulkIndexer) newAttempt(s *sending, l *lane) Item {
+	s.lane = l
+	item := s.item
+
+	onSuccess := func(ctx context.Context, res esutil.BulkIndexerItem, resItem esutil.BulkIndexerResponseItem) {
+		if !s.complete() {
+			return
+		}
+		b.buffer.release(len(item.document))
+		if item.onSuccess != nil {
+			item.onSuccess(bulkctx.WithAttempts(ctx, s.attempt), res, resItem)
+		}
+	}
+
+	onFailure := func(ctx context.Context, res esutil.BulkIndexerItem, resItem esutil.BulkIndexerResponseItem, err error) {
+		if !s.complete() {
+			return
+		}
+		s.failed.Store(true)
+		b.fail(ctx, s, res, resItem, err)
+	}
+
+	return Item{
+		Action:     item.action,
+		Index:      item.index,
+		DocumentID: item.documentID,
+		Body:       &sendingBody{s: s, r: bytes.NewReader(item.document)},
+		OnSuccess:  onSuccess,
+		OnFailure:  onFailure,
+	}
+}
+
+// fail handles an attempt that failed, retrying the item if it was rejected and has attempts left
+func (b *wrappedBulkIndexer) fail(ctx context.Context, s *sending, res esutil.BulkIndexerItem, resItem esutil.BulkIndexerResponseItem, err error) {
+	if b.sizer != nil && err == nil && b.retry.ShouldRetryStatus(resItem.Status) {
+		b.sizer.reject()
+	}
+	if err == nil && s.attempt <= b.retry.MaxRetries && b.retry.ShouldRetryStatus(resItem.Status) {
+		b.scheduleRetry(ctx, s.item, s.attempt)
+		return
+	}
+
+	b.buffer.release(len(s.item.document))
+	if s.item.onFailure != nil {
+		s.item.onFailure(bulkctx.WithAttempts(ctx, s.attempt), res, resItem, err)
+	}
+}
+
+// scheduleRetry sends the item again after the backoff of the attempt that failed
+func (b *wrappedBulkIndexer) scheduleRetry(ctx context.Context, item *bulkItem, attempt int) {
+	b.numRetried.Add(1)
+	b.retries.Add(1)
+
+	go func() {
+		defer b.retries.Done()
+
+		b.retry.Wait(ctx, attempt) //nolint:errcheck // the item is sent whether or not the wait was cut short
+		b.resend(ctx, item)
+	}()
+}
+
+// resend adds a retried item to the current lanes, or defers it until they have closed if the indexer is closing
+func (b *wrappedBulkIndexer) resend(ctx context.Context, item *bulkItem) {
+	err := b.send(ctx, item, true)
+	switch {
+	case errors.Is(err, errClosing):
+		b.deferMu.Lock()
+		defer b.deferMu.Unlock()
+		b.deferred = append(b.deferred, item)
+	case err != nil:
+		b.lose(ctx, item, err)
+	default:
+		b.numResent.Add(1)
+	}
+}
+
+// lose reports an item being retried that could not be sent again as failed
+func (b *wrappedBulkIndexer) lose(ctx context.Context, item *bulkItem, err error) {
+	b.numLost.Add(1)
+	b.buffer.release(len(item.document))
+
+	if item.onFailure != nil {
+		item.onFailure(bulkctx.WithAttempts(ctx, item.attempts), bulkIndexerItem(item), esutil.BulkIndexerResponseItem{}, err)
+	}
+}
+
+// bulkIndexerItem returns the item as it is passed to its callbacks
+func bulkIndexerItem(item *bulkItem) esutil.BulkIndexerItem {
+	return esutil.BulkIndexerItem{
+		Action:     string(item.action),
+		Index:      item.index,
+		DocumentID: item.documentID,
+		Body:       bytes.NewReader(item.document),
+	}
+}
+
+// Close waits until every added item has been flushed, or has run out of attempts, and closes the indexer. An error
+// matching client.ErrBulkFailureRatioExceeded is returned if the ratio of failed items is above the configured
+// maximum.
+func (b *wrappedBulkIndexer) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closing = true
+	lanes := b.lanes
+	b.mu.Unlock()
+
+	var errs []error
+	for lanes != nil {
+		closeErr := closeLanes(ctx, lanes)
+
+		b.mu.Lock()
+		b.closedStats = addBulkIndexerStats(b.closedStats, laneStats(lanes))
+		b.lanes, lanes = nil, nil
+		b.mu.Unlock()
+
+		// Every item that failed in the final flushes, including those of the lanes retired by a change of flush
+		// size, has now been scheduled, so waiting for the retries leaves them all deferred
+		b.retirements.Wait()
+		b.retries.Wait()
+		b.deferMu.Lock()
+		deferred := b.deferred
+		b.deferred = nil
+		b.deferMu.Unlock()
+
+		if len(deferred) == 0 {
+			errs = append(errs, closeErr)
+			break
+		}
+
+		b.mu.RLock()
+		flushBytes := b.flushBytes
+		b.mu.RUnlock()
+
+		next, err := b.openLanes(flushBytes)
+		if err != nil {
+			errs = append(errs, closeErr, err)
+			for _, item := range deferred {
+				b.lose(ctx, item, err)
+			}
+			break
+		}
+
+		b.mu.Lock()
+		b.lanes, lanes = next, next
+		b.mu.Unlock()
+
+		for _, item := range deferred {
+			if _, err := b.sendTo(ctx, next, item, true); err != nil {
+				b.lose(ctx, item, err)
+				continue
+			}
+			b.numResent.Add(1)
+		}
+	}
+
+	b.stopProgress()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	return b.Stats().CheckFailureRatio(b.cfg.MaxFailureRatio)
+}
+
+// Stats returns the counts of items handled by the indexer since it was created. Each item is counted once,
+// however many times it was sent.
+func (b *wrappedBulkIndexer) Stats() client.BulkIndexerStats {
+	b.mu.RLock()
+	stats := addBulkIndexerStats(b.closedStats, laneStats(b.lanes))
+	stats = addBulkIndexerStats(stats, laneStats(b.retiring))
+	b.mu.RUnlock()
+
+	retried := b.numRetried.Load()
+	stats.NumAdded -= min(stats.NumAdded, b.numResent.Load())
+	stats.NumFailed = stats.NumFailed + b.numLost.Load() - min(stats.NumFailed+b.numLost.Load(), retried)
+	stats.NumRetried = retried
+
+	return stats
+}
+
+// openLanes opens a lane for each worker, with a flush size of flushBytes
+func (b *wrappedBulkIndexer) openLanes(flushBytes int) ([]*lane, error) {
+	lanes := make([]*lane, b.cfg.NumWorkers)
+	for i := range lanes {
+		l := &lane{}
+		indexer, err := b.open(b.laneConfig(l, flushBytes))
+		if err != nil {
+			// Nothing has been added to the lanes already opened, so there is nothing to flush
+			closeLanes(context.Background(), lanes[:i]) //nolint:errcheck // the error opening the lane is returned
+			return nil, err
+		}
+		l.indexer = indexer
+		lanes[i] = l
+	}
+
+	return lanes, nil
+}
+
+// laneConfig returns the config of the indexer of a lane, opened with a single worker and a flush size of
+// flushBytes. Its flushes keep a ledger of their items, to fail any left without a result when the flush ends,
+// and are timed for the sizer if the flush size is adaptive.
+func (b *wrappedBulkIndexer) laneConfig(l *lane, flushBytes int) client.BulkIndexerConfig {
+	cfg := b.cfg
+	cfg.NumWorkers = 1
+	cfg.FlushBytes = flushBytes
+
+	onFlushStart, onFlushEnd, onError := cfg.OnFlushStart, cfg.OnFlushEnd, cfg.OnError
+	cfg.OnFlushStart = func(ctx context.Context) context.Context {
+		if onFlushStart != nil {
+			ctx = onFlushStart(ctx)
+		}
+		if b.sizer != nil {
+			ctx = context.WithValue(ctx, flushStartKey{}, time.Now())
+		}
+		return context.WithValue(ctx, flushLedgerKey{}, l.startFlush())
+	}
+	cfg.OnError = func(ctx context.Context, err error) {
+		if ledger, ok := ctx.Value(flushLedgerKey{}).(*flushLedger); ok {
+			ledger.record(err)
+		}
+		if onError != nil {
+			onError(ctx, err)
+		}
+	}
+	cfg.OnFlushEnd = func(ctx context.Context) {
+		if ledger, ok := ctx.Value(flushLedgerKey{}).(*flushLedger); ok {
+			b.endFlush(ctx, l, ledger)
+		}
+		if start, ok := ctx.Value(flushStartKey{}).(time.Time); ok {
+			b.sizer.observe(time.Since(start))
+		}
+		if onFlushEnd != nil {
+			onFlushEnd(ctx)
+		}
+	}
+
+	return cfg
+}
+
+// endFlush fails the items of a flush that were left without a result, which they are when the request failed as
+// a whole. Items that the indexer did not count as failed are counted as lost.
+func (b *wrappedBulkIndexer) endFlush(ctx context.Context, l *lane, ledger *flushLedger) {
+	var incomplete []*sending
+	var failed uint64
+	for _, s := range ledger.items {
+		switch {
+		case !s.done.Load():
+			incomplete = append(incomplete, s)
+		case s.failed.Load():
+			failed++
+		}
+	}
+	if len(incomplete) == 0 {
+		return
+	}
+
+	// The indexer counts every item of a request that fails as a whole as failed, but none of those left without
+	// a result in a response it could decode
+	counted := l.indexer.Stats().NumFailed - ledger.numFailed
+	counted -= min(counted, failed)
+	if n := uint64(len(incomplete)); counted < n {
+		b.numLost.Add(n - counted)
+	}
+
+	err := errors.New("bulk response has no result for the item")
+	if ledger.err != nil {
+		err = fmt.Errorf("bulk request failed as a whole: %w", ledger.err)
+	}
+
+	for _, s := range incomplete {
+		if s.complete() {
+			s.failed.Store(true)
+			b.fail(ctx, s, bulkIndexerItem(s.item), esutil.BulkIndexerResponseItem{}, err)
+		}
+	}
+}
+
+// resize replaces the current lanes with lanes opened with the flush size chosen by the sizer, if that has
+// changed. The replaced lanes are closed in the background, flushing their items, and Close waits for them.
+func (b *wrappedBulkIndexer) resize(ctx context.Context) {
+	if b.sizer == nil {
+		return
+	}
+
+	size := b.sizer.flushBytes()
+	b.mu.RLock()
+	unchanged := b.closing || b.flushBytes == size
+	b.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	b.mu.Lock()
+	if b.closing || b.flushBytes == size {
+		b.mu.Unlock()
+		return
+	}
+
+	// The size is kept even if no lanes could be opened with it, so that opening them is not tried on every add
+	b.flushBytes = size
+	next, err := b.openLanes(size)
+	if err != nil {
+		b.mu.Unlock()
+		if b.cfg.OnError != nil {
+			b.cfg.OnError(ctx, fmt.Errorf("failed to open bulk indexer with a flush size of %d bytes: %w", size, err))
+		}
+		return
+	}
+
+	previous := b.lanes
+	b.lanes = next
+	b.retiring = append(b.retiring, previous...)
+	b.retirements.Add(1)
+	b.mu.Unlock()
+
+	go b.retire(context.WithoutCancel(ctx), previous)
+}
+
+// retire closes lanes replaced by a change of flush size
+func (b *wrappedBulkIndexer) retire(ctx context.Context, lanes []*lane) {
+	defer b.retirements.Done()
+
+	if err := closeLanes(ctx, lanes); err != nil && b.cfg.OnError != nil {
+		b.cfg.OnError(ctx, fmt.Errorf("failed to close bulk indexer when changing its flush size: %w", err))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.retiring = slices.DeleteFunc(b.retiring, func(l *lane) bool { return slices.Contains(lanes, l) })
+	b.closedStats = addBulkIndexerStats(b.closedStats, laneStats(lanes))
+}
+
+// lane is the indexer of a single worker. As the worker reads the body of each item when it writes the item to
+// its request, the items read since the last flush started are those of the next flush.
+type lane struct {
+	indexer Indexer
+	queued  atomic.Int64 // Items added that the worker has not taken yet
+
+	mu     sync.Mutex // Held while adding an item, so that TryAdd can skip a lane that is busy
+	closed bool
+
+	pendingMu sync.Mutex
+	pending   []*sending
+}
+
+// add adds an item to the lane, reporting whether it was added. Unless wait is set, it is not added if the lane
+// is busy adding another item or its worker has yet to take the last one added, as adding it would wait.
+func (l *lane) add(ctx context.Context, item Item, wait bool) (bool, error) {
+	if wait {
+		l.mu.Lock()
+	} else if !l.mu.TryLock() {
+		return false, nil
+	}
+	defer l.mu.Unlock()
+
+	if l.closed || (!wait && l.queued.Load() > 0) {
+		return false, nil
+	}
+
+	s := item.Body.(*sendingBody).s
+	l.queued.Add(1)
+	if err := l.indexer.Add(ctx, item); err != nil {
+		s.take(false)
+		return true, err
+	}
+
+	return true, nil
+}
+
+// close closes the indexer of the lane once nothing is being added to it
+func (l *lane) close(ctx context.Context) error {
+	l.mu.Lock()
+	l.closed = true
+	l.mu.Unlock()
+
+	return l.indexer.Close(ctx)
+}
+
+// startFlush returns the ledger of a flush that is starting, holding the items read since the last one started
+func (l *lane) startFlush() *flushLedger {
+	l.pendingMu.Lock()
+	ledger := &flushLedger{items: l.pending}
+	l.pending = nil
+	l.pendingMu.Unlock()
+
+	// The indexer is only read once items have been added, as it is not yet set when the worker first ticks
+	if len(ledger.items) > 0 {
+		ledger.numFailed = l.indexer.Stats().NumFailed
+	}
+
+	return ledger
+}
+
+// closeLanes closes every lane, returning their errors
+func closeLanes(ctx context.Context, lanes []*lane) error {
+	var errs []error
+	for _, l := range lanes {
+		errs = append(errs, l.close(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+// laneStats returns the sum of the stats of the lanes
+func laneStats(lanes []*lane) client.BulkIndexerStats {
+	var stats client.BulkIndexerStats
+	for _, l := range lanes {
+		stats = addBulkIndexerStats(stats, l.indexer.Stats())
+	}
+
+	return stats
+}
+
+// sending is an attempt to send an item through a lane
+type sending struct {
+	item    *bulkItem
+	attempt int
+	lane    *lane
+
+	taken  atomic.Bool // Taken by the worker of the lane, or completed without it
+	done   atomic.Bool // Completed, with a result from the indexer or once the flush it was in has ended
+	failed atomic.Bool
+}
+
+// take records the attempt as taken by the worker, adding it to the items of the next flush if its body was read
+func (s *sending) take(read bool) {
+	if !s.taken.CompareAndSwap(false, true) {
+		return
+	}
+	s.lane.queued.Add(-1)
+
+	if read {
+		s.lane.pendingMu.Lock()
+		defer s.lane.pendingMu.Unlock()
+		s.lane.pending = append(s.lane.pending, s)
+	}
+}
+
+// complete reports whether the attempt has now been completed, which is false if it already was
+func (s *sending) complete() bool {
+	if !s.done.CompareAndSwap(false, true) {
+		return false
+	}
+	s.take(false)
+
+	return true
+}
+
+// sendingBody is the body of an attempt, which is taken by the worker when it is first read
+type sendingBody struct {
+	s *sending
+	r *bytes.Reader
+}
+
+// Read reads the body, taking the attempt for the worker reading it
+func (b *sendingBody) Read(p []byte) (int, error) {
+	b.s.take(true)
+	return b.r.Read(p)
+}
+
+// Seek seeks the body without taking the attempt, as some client libraries seek it to measure it when it is added
+func (b *sendingBody) Seek(offset int64, whence int) (int64, error) {
+	return b.r.Seek(offset, whence)
+}
+
+// flushLedgerKey is the context key of the ledger of a flush
+type flushLedgerKey struct{}
+
+// flushLedger holds the items of a flush and the first error the flush failed with
+type flushLedger struct {
+	items     []*sending
+	numFailed uint64 // The failed items counted by the indexer when the flush started
+
+	mu  sync.Mutex
+	err error
+}
+
+// record records an error of the flush, keeping the first
+func (f *flushLedger) record(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err == nil {
+		f.err = err
+	}
+}
+
+func addBulkIndexerStats(a, b client.BulkIndexerStats) client.BulkIndexerStats {
+	return client.BulkIndexerStats{
+		NumAdded:    a.NumAdded + b.NumAdded,
+		NumFlushed:  a.NumFlushed + b.NumFlushed,
+		NumFailed:   a.NumFailed + b.NumFailed,
+		NumIndexed:  a.NumIndexed + b.NumIndexed,
+		NumCreated:  a.NumCreated + b.NumCreated,
+		NumUpdated:  a.NumUpdated + b.NumUpdated,
+		NumDeleted:  a.NumDeleted + b.NumDeleted,
+		NumRequests: a.NumRequests + b.NumRequests,
+		NumRetried:  a.NumRetried + b.NumRetried,
+	}
+}
+
+// bulkBuffer limits the bytes of the documents of the items that have been added to a bulk indexer but have not
+// completed. An item larger than the limit is let in once nothing else is buffered, so that it does not wait forever.
+type bulkBuffer struct {
+	maxBytes int
+
+	mu    sync.Mutex
+	bytes int
+	freed chan struct{} // Closed, and replaced, whenever bytes are released
+}
+
+// newBulkBuffer returns a buffer of at most maxBytes, or an unlimited buffer if maxBytes is zero or less
+func newBulkBuffer(maxBytes int) *bulkBuffer {
+	return &bulkBuffer{maxBytes: maxBytes, freed: make(chan struct{})}
+}
+
+// tryAcquire takes n bytes of the buffer, reporting whether there was room for them
+func (b *bulkBuffer) tryAcquire(n int) bool {
+	_, ok := b.take(n)
+	return ok
+}
+
+// acquire takes n bytes of the buffer, waiting until there is room for them or ctx is done
+func (b *bulkBuffer) acquire(ctx context.Context, n int) error {
+	for {
+		freed, ok := b.take(n)
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-freed:
+		}
+	}
+}
+
+// take takes n bytes of the buffer if there is room for them, otherwise returning a channel closed once bytes
+// are released
+func (b *bulkBuffer) take(n int) (<-chan struct{}, bool) {
+	if b.maxBytes <= 0 {
+		return nil, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.bytes > 0 && b.bytes+n > b.maxBytes {
+		return b.freed, false
+	}
+	b.bytes += n
+
+	return nil, true
+}
+
+// release returns n bytes to the buffer
+func (b *bulkBuffer) release(n int) {
+	if b.maxBytes <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bytes -= n
+	close(b.freed)
+	b.freed = make(chan struct{})
+}
+
+// flushStartKey is the context key of the time a flush started
+type flushStartKey struct{}
+
+// flushSizerWindow is the number of flushes the flush size is kept for before it can be changed again, so that the
+// indexers are not reopened after every flush
+const flushSizerWindow = 8
+
+// flushSizer adapts the flush size of a bulk indexer to how well the cluster is keeping up. The size is only
+// changed once it has been kept for flushSizerWindow flushes. It is then halved, down to the minimum, if any items
+// were rejected with a 429 or 503 or more than half of the flushes took longer than the target latency. It is
+// raised by a quarter, up to the maximum, if every flush took less than half the target latency.
+type flushSizer struct {
+	minBytes int
+	maxBytes int
+	target   time.Duration
+	rejected atomic.Uint64
+
+	mu      sync.Mutex
+	size    int
+	flushes int // Flushes since the size was last changed
+	slow    int // Flushes since then that took longer than the target latency
+	quick   int // Flushes since then that took less than half the target latency
+}
+
+// newFlushSizer returns a sizer starting at the maximum flush size
+func newFlushSizer(minBytes, maxBytes int, target time.Duration) *flushSizer {
+	return &flushSizer{minBytes: minBytes, maxBytes: maxBytes, target: target, size: maxBytes}
+}
+
+// reject records an item rejected by the cluster
+func (s *flushSizer) reject() {
+	s.rejected.Add(1)
+}
+
+// observe records a flush that took latency, adjusting the flush size at the end of each window of flushes
+func (s *flushSizer) observe(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flushes++
+	switch {
+	case latency > s.target:
+		s.slow++
+	case latency < s.target/2:
+		s.quick++
+	}
+	if s.flushes < flushSizerWindow {
+		return
+	}
+
+	switch {
+	case s.rejected.Swap(0) > 0 || s.slow > s.flushes/2:
+		s.size = max(s.minBytes, s.size/2)
+	case s.quick == s.flushes:
+		s.size = min(s.maxBytes, s.size+max(s.size/4, 1))
+	}
+	s.flushes, s.slow, s.quick = 0, 0, 0
+}
+
+// flushBytes returns the flush size
+func (s *flushSizer) flushBytes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.size
+}
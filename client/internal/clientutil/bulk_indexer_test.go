@@ -0,0 +1,578 @@
+package clientutil_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// scriptedBulkIndexers opens bulk indexers whose items get the next status of their document ID, or 201 once
+// there are none left. Items are flushed when the indexer is closed, all in one request, or, if flushOnAdd is set,
+// as they are added, each taking latency, or the next of latencies in turn if that is set. If failRequests is set every request fails as a whole. If queued is set
+// the bodies of the items are not read until the indexer is closed, as though its worker were busy. The flush size
+// of each indexer opened is recorded.
+type scriptedBulkIndexers struct {
+	mu           sync.Mutex
+	statuses     map[string][]int
+	flushOnAdd   bool
+	failRequests bool
+	queued       bool
+	latency      time.Duration
+	latencies    []time.Duration
+	flushes      int
+	opened       int
+	flushBytes   []int
+}
+
+func (s *scriptedBulkIndexers) open(cfg client.BulkIndexerConfig) (clientutil.Indexer, error) {
+	s.mu.Lock()
+	s.opened++
+	s.flushBytes = append(s.flushBytes, cfg.FlushBytes)
+	s.mu.Unlock()
+
+	return &scriptedIndexer{scripted: s, cfg: cfg}, nil
+}
+
+// scriptedIndexer is a bulk indexer opened by scriptedBulkIndexers
+type scriptedIndexer struct {
+	scripted *scriptedBulkIndexers
+	cfg      client.BulkIndexerConfig
+
+	mu     sync.Mutex
+	items  []clientutil.Item
+	closed bool
+	stats  client.BulkIndexerStats
+}
+
+func (i *scriptedIndexer) Add(ctx context.Context, item clientutil.Item) error {
+	i.mu.Lock()
+	if i.closed {
+		i.mu.Unlock()
+		return errors.New("closed")
+	}
+	i.stats.NumAdded++
+	i.mu.Unlock()
+
+	if !i.scripted.queued {
+		if _, err := io.ReadAll(item.Body); err != nil {
+			return err
+		}
+	}
+
+	if i.scripted.flushOnAdd {
+		i.flush(ctx, []clientutil.Item{item})
+		return nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.items = append(i.items, item)
+	return nil
+}
+
+func (i *scriptedIndexer) Close(ctx context.Context) error {
+	i.mu.Lock()
+	pending := i.items
+	i.items, i.closed = nil, true
+	i.mu.Unlock()
+
+	if i.scripted.queued {
+		for _, item := range pending {
+			if _, err := io.ReadAll(item.Body); err != nil {
+				return err
+			}
+		}
+	}
+	if len(pending) > 0 {
+		i.flush(ctx, pending)
+	}
+	return nil
+}
+
+func (i *scriptedIndexer) Stats() client.BulkIndexerStats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.stats
+}
+
+// flush sends the items in one request, failing it as a whole, as the client libraries do, if failRequests is set
+func (i *scriptedIndexer) flush(ctx context.Context, items []clientutil.Item) {
+	s := i.scripted
+	if i.cfg.OnFlushStart != nil {
+		ctx = i.cfg.OnFlushStart(ctx)
+	}
+	if i.cfg.OnFlushEnd != nil {
+		defer i.cfg.OnFlushEnd(ctx)
+	}
+	s.mu.Lock()
+	latency := s.latency
+	if len(s.latencies) > 0 {
+		latency = s.latencies[s.flushes%len(s.latencies)]
+	}
+	s.flushes++
+	s.mu.Unlock()
+	time.Sleep(latency)
+
+	i.mu.Lock()
+	i.stats.NumRequests++
+	if s.failRequests {
+		i.stats.NumFailed += uint64(len(items))
+	}
+	i.mu.Unlock()
+
+	if s.failRequests {
+		if i.cfg.OnError != nil {
+			i.cfg.OnError(ctx, errors.New("flush: 503 Service Unavailable"))
+		}
+		return
+	}
+
+	for _, item := range items {
+		s.mu.Lock()
+		status := 201
+		if statuses := s.statuses[item.DocumentID]; len(statuses) > 0 {
+			status, s.statuses[item.DocumentID] = statuses[0], statuses[1:]
+		}
+		s.mu.Unlock()
+
+		res := esutil.BulkIndexerResponseItem{Status: status}
+		i.mu.Lock()
+		if status > 201 {
+			i.stats.NumFailed++
+		} else {
+			i.stats.NumFlushed++
+		}
+		i.mu.Unlock()
+
+		if status > 201 {
+			if item.OnFailure != nil {
+				item.OnFailure(ctx, esutil.BulkIndexerItem{DocumentID: item.DocumentID}, res, nil)
+			}
+			continue
+		}
+		if item.OnSuccess != nil {
+			item.OnSuccess(ctx, esutil.BulkIndexerItem{DocumentID: item.DocumentID}, res)
+		}
+	}
+}
+
+// bulkResults records the results of bulk items and the attempts they took
+type bulkResults struct {
+	mu        sync.Mutex
+	succeeded map[string]int
+	failed    map[string]int
+	errs      map[string]error
+}
+
+func newBulkResults() *bulkResults {
+	return &bulkResults{succeeded: map[string]int{}, failed: map[string]int{}, errs: map[string]error{}}
+}
+
+func (r *bulkResults) onSuccess(ctx context.Context, item esutil.BulkIndexerItem, _ esutil.BulkIndexerResponseItem) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.succeeded[item.DocumentID] = client.BulkItemAttempts(ctx)
+}
+
+func (r *bulkResults) onFailure(ctx context.Context, item esutil.BulkIndexerItem, _ esutil.BulkIndexerResponseItem, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed[item.DocumentID] = client.BulkItemAttempts(ctx)
+	r.errs[item.DocumentID] = err
+}
+
+func (r *bulkResults) succeededAttempts(id string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.succeeded[id]
+}
+
+func TestBulkIndexerRetries(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a bulk indexer that retries items up to 3 times", t, func() {
+		var deadLetters []client.DeadLetter
+		cfg := &client.BulkIndexerConfig{
+			NumWorkers:      1,
+			MaxAttempts:     3,
+			MinRetryBackoff: time.Millisecond,
+			MaxRetryBackoff: time.Millisecond,
+			DeadLetter: client.DeadLetterSinkFunc(func(_ context.Context, letter client.DeadLetter) error {
+				deadLetters = append(deadLetters, letter)
+				return nil
+			}),
+		}
+		scripted := &scriptedBulkIndexers{statuses: map[string][]int{
+			"rejected-twice": {429, 503},
+			"always-503":     {503, 503, 503},
+			"bad-request":    {400},
+		}}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, cfg, scripted.open)
+		So(err, ShouldBeNil)
+		results := newBulkResults()
+
+		Convey("When the items are added and the indexer closed", func() {
+			for _, id := range []string{"ok", "rejected-twice", "always-503", "bad-request"} {
+				So(indexer.Add(ctx, "index", "my-index", id, []byte(`{}`), results.onSuccess, results.onFailure), ShouldBeNil)
+			}
+			So(indexer.Close(ctx), ShouldBeNil)
+
+			Convey("Then an item rejected fewer times than the maximum attempts succeeds on its last attempt", func() {
+				So(results.succeeded, ShouldResemble, map[string]int{"ok": 1, "rejected-twice": 3})
+			})
+
+			Convey("Then items only fail once their attempts run out, or if the failure is not retried", func() {
+				So(results.failed, ShouldResemble, map[string]int{"always-503": 3, "bad-request": 1})
+			})
+
+			Convey("Then only items that have failed for good are dead lettered, with their attempts", func() {
+				So(deadLetters, ShouldHaveLength, 2)
+				attempts := map[string]int{}
+				for _, letter := range deadLetters {
+					attempts[letter.DocumentID] = letter.Attempts
+				}
+				So(attempts, ShouldResemble, map[string]int{"always-503": 3, "bad-request": 1})
+			})
+
+			Convey("Then items that failed in the final flush are retried with further indexers", func() {
+				So(scripted.opened, ShouldEqual, 3)
+			})
+
+			Convey("Then the stats count each item once", func() {
+				stats := indexer.Stats()
+				So(stats.NumAdded, ShouldEqual, 4)
+				So(stats.NumFlushed, ShouldEqual, 2)
+				So(stats.NumFailed, ShouldEqual, 2)
+				So(stats.NumRetried, ShouldEqual, 4)
+			})
+		})
+	})
+
+	Convey("Given a bulk indexer that flushes items as they are added", t, func() {
+		scripted := &scriptedBulkIndexers{statuses: map[string][]int{"1": {429}}, flushOnAdd: true}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{
+			NumWorkers:      1,
+			MaxAttempts:     2,
+			MinRetryBackoff: time.Millisecond,
+		}, scripted.open)
+		So(err, ShouldBeNil)
+		results := newBulkResults()
+
+		Convey("When a rejected item is added", func() {
+			So(indexer.Add(ctx, "index", "my-index", "1", []byte(`{}`), results.onSuccess, results.onFailure), ShouldBeNil)
+
+			Convey("Then it is retried by the same indexer without waiting for it to be closed", func() {
+				deadline := time.Now().Add(5 * time.Second)
+				for results.succeededAttempts("1") == 0 && time.Now().Before(deadline) {
+					time.Sleep(time.Millisecond)
+				}
+				So(results.succeededAttempts("1"), ShouldEqual, 2)
+
+				So(indexer.Close(ctx), ShouldBeNil)
+				So(scripted.opened, ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given a bulk indexer without retries", t, func() {
+		scripted := &scriptedBulkIndexers{statuses: map[string][]int{"1": {429}}}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, nil, scripted.open)
+		So(err, ShouldBeNil)
+		results := newBulkResults()
+
+		Convey("When a rejected item is added and the indexer closed", func() {
+			So(indexer.Add(ctx, "index", "my-index", "1", []byte(`{}`), results.onSuccess, results.onFailure), ShouldBeNil)
+			So(indexer.Close(ctx), ShouldBeNil)
+
+			Convey("Then it fails after one attempt", func() {
+				So(results.failed, ShouldResemble, map[string]int{"1": 1})
+				So(indexer.Stats().NumRetried, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestBulkIndexerRequestFailures(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a bulk indexer with room for 10 bytes of documents whose requests fail as a whole", t, func() {
+		scripted := &scriptedBulkIndexers{flushOnAdd: true, failRequests: true}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{
+			NumWorkers:       1,
+			MaxBufferedBytes: 10,
+			MaxAttempts:      3,
+		}, scripted.open)
+		So(err, ShouldBeNil)
+		results := newBulkResults()
+
+		Convey("When an item is added", func() {
+			So(indexer.Add(ctx, "index", "my-index", "1", []byte(`{"n":1}`), results.onSuccess, results.onFailure), ShouldBeNil)
+
+			Convey("Then it fails with the error of the request, without being retried", func() {
+				So(results.failed, ShouldResemble, map[string]int{"1": 1})
+				So(results.errs["1"], ShouldNotBeNil)
+				So(results.errs["1"].Error(), ShouldContainSubstring, "bulk request failed as a whole")
+				So(results.errs["1"].Error(), ShouldContainSubstring, "503 Service Unavailable")
+			})
+
+			Convey("Then its room in the buffer is released", func() {
+				So(indexer.TryAdd(ctx, "index", "my-index", "2", []byte(`{"n":2}`), results.onSuccess, results.onFailure), ShouldBeNil)
+
+				timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+				defer cancel()
+				So(indexer.Add(timeoutCtx, "index", "my-index", "3", []byte(`{"n":3}`), results.onSuccess, results.onFailure), ShouldBeNil)
+			})
+
+			Convey("Then the stats count it as failed once", func() {
+				So(indexer.Close(ctx), ShouldBeNil)
+				stats := indexer.Stats()
+				So(stats.NumAdded, ShouldEqual, 1)
+				So(stats.NumFailed, ShouldEqual, 1)
+				So(stats.NumRetried, ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given a bulk indexer with a dead letter sink whose requests fail as a whole when it is closed", t, func() {
+		scripted := &scriptedBulkIndexers{failRequests: true}
+		sink := &memoryDeadLetterSink{}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{NumWorkers: 1, DeadLetter: sink}, scripted.open)
+		So(err, ShouldBeNil)
+		results := newBulkResults()
+
+		Convey("When items are added and the indexer closed", func() {
+			for _, id := range []string{"1", "2", "3"} {
+				So(indexer.Add(ctx, "index", "my-index", id, []byte(`{}`), results.onSuccess, results.onFailure), ShouldBeNil)
+			}
+			So(indexer.Close(ctx), ShouldBeNil)
+
+			Convey("Then every item of the request fails", func() {
+				So(results.failed, ShouldResemble, map[string]int{"1": 1, "2": 1, "3": 1})
+				So(indexer.Stats().NumFailed, ShouldEqual, 3)
+			})
+
+			Convey("Then every item is sent to the dead letter sink with the error of the request and no status", func() {
+				So(sink.letters, ShouldHaveLength, 3)
+				for _, letter := range sink.letters {
+					So(letter.Status, ShouldEqual, 0)
+					So(letter.Error.Reason, ShouldContainSubstring, "503 Service Unavailable")
+					So(letter.Attempts, ShouldEqual, 1)
+				}
+			})
+		})
+	})
+}
+
+func TestBulkIndexerBuffer(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a bulk indexer with room for 10 bytes of documents, flushing items when closed", t, func() {
+		scripted := &scriptedBulkIndexers{}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{MaxBufferedBytes: 10}, scripted.open)
+		So(err, ShouldBeNil)
+		results := newBulkResults()
+		So(indexer.TryAdd(ctx, "index", "my-index", "1", []byte(`{"n":1}`), results.onSuccess, results.onFailure), ShouldBeNil)
+
+		Convey("When TryAdd is called with a document that does not fit", func() {
+			err := indexer.TryAdd(ctx, "index", "my-index", "2", []byte(`{"n":2}`), results.onSuccess, results.onFailure)
+
+			Convey("Then an error matching ErrBulkIndexerFull is returned straight away and the item is not added", func() {
+				So(errors.Is(err, client.ErrBulkIndexerFull), ShouldBeTrue)
+				So(esError.ErrorStatus(err), ShouldEqual, http.StatusTooManyRequests)
+
+				So(indexer.Close(ctx), ShouldBeNil)
+				So(results.succeeded, ShouldResemble, map[string]int{"1": 1})
+				So(indexer.Stats().NumAdded, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When Add is called with a document that does not fit and a context that times out", func() {
+			timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+			err := indexer.Add(timeoutCtx, "index", "my-index", "2", []byte(`{"n":2}`), results.onSuccess, results.onFailure)
+
+			Convey("Then it waits for room until the context is done", func() {
+				So(errors.Is(err, client.ErrBulkIndexerFull), ShouldBeTrue)
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given an empty bulk indexer with room for 10 bytes of documents", t, func() {
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{MaxBufferedBytes: 10}, (&scriptedBulkIndexers{}).open)
+		So(err, ShouldBeNil)
+
+		Convey("Then a larger document is added rather than waiting forever", func() {
+			So(indexer.TryAdd(ctx, "index", "my-index", "1", []byte(`{"text":"too long"}`), nil, nil), ShouldBeNil)
+			So(indexer.Close(ctx), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a bulk indexer with room for 10 bytes of documents, flushing items as they are added", t, func() {
+		scripted := &scriptedBulkIndexers{statuses: map[string][]int{"1": {429}}, flushOnAdd: true}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{
+			MaxBufferedBytes: 10,
+			MaxAttempts:      2,
+			MinRetryBackoff:  50 * time.Millisecond,
+			MaxRetryBackoff:  50 * time.Millisecond,
+		}, scripted.open)
+		So(err, ShouldBeNil)
+		results := newBulkResults()
+
+		Convey("When an item is rejected and waiting to be retried", func() {
+			So(indexer.Add(ctx, "index", "my-index", "1", []byte(`{"n":1}`), results.onSuccess, results.onFailure), ShouldBeNil)
+
+			Convey("Then it keeps its room in the buffer", func() {
+				err := indexer.TryAdd(ctx, "index", "my-index", "2", []byte(`{"n":2}`), results.onSuccess, results.onFailure)
+				So(errors.Is(err, client.ErrBulkIndexerFull), ShouldBeTrue)
+				So(indexer.Close(ctx), ShouldBeNil)
+			})
+
+			Convey("Then Add waits until the retry has completed to add another item", func() {
+				So(indexer.Add(ctx, "index", "my-index", "2", []byte(`{"n":2}`), results.onSuccess, results.onFailure), ShouldBeNil)
+				So(indexer.Close(ctx), ShouldBeNil)
+				So(results.succeeded, ShouldResemble, map[string]int{"1": 2, "2": 1})
+			})
+		})
+	})
+
+	Convey("Given a bulk indexer with 2 workers that are both busy", t, func() {
+		scripted := &scriptedBulkIndexers{queued: true}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{NumWorkers: 2}, scripted.open)
+		So(err, ShouldBeNil)
+		results := newBulkResults()
+		So(indexer.TryAdd(ctx, "index", "my-index", "1", []byte(`{}`), results.onSuccess, results.onFailure), ShouldBeNil)
+		So(indexer.TryAdd(ctx, "index", "my-index", "2", []byte(`{}`), results.onSuccess, results.onFailure), ShouldBeNil)
+
+		Convey("When TryAdd is called", func() {
+			err := indexer.TryAdd(ctx, "index", "my-index", "3", []byte(`{}`), results.onSuccess, results.onFailure)
+
+			Convey("Then an error matching ErrBulkIndexerFull is returned straight away and the item is not added", func() {
+				So(errors.Is(err, client.ErrBulkIndexerFull), ShouldBeTrue)
+				So(esError.ErrorStatus(err), ShouldEqual, http.StatusTooManyRequests)
+
+				So(indexer.Close(ctx), ShouldBeNil)
+				So(results.succeeded, ShouldResemble, map[string]int{"1": 1, "2": 1})
+				So(indexer.Stats().NumAdded, ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestBulkIndexerAdaptiveFlush(t *testing.T) {
+	ctx := context.Background()
+
+	// ids returns n document IDs
+	ids := func(n int) []string {
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = strconv.Itoa(i + 1)
+		}
+		return ids
+	}
+
+	Convey("Given an adaptive bulk indexer whose flushes are quick", t, func() {
+		scripted := &scriptedBulkIndexers{statuses: map[string][]int{"rejected": {429}}, flushOnAdd: true}
+		var flushes atomic.Int32
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{
+			NumWorkers:         1,
+			FlushBytes:         1000,
+			MinFlushBytes:      100,
+			TargetFlushLatency: time.Hour,
+			OnFlushEnd:         func(context.Context) { flushes.Add(1) },
+		}, scripted.open)
+		So(err, ShouldBeNil)
+
+		Convey("When an item is rejected with a 429 and more items are added", func() {
+			for _, id := range append([]string{"rejected"}, ids(16)...) {
+				So(indexer.Add(ctx, "index", "my-index", id, []byte(`{}`), nil, nil), ShouldBeNil)
+			}
+			So(indexer.Close(ctx), ShouldBeNil)
+
+			Convey("Then the flush size is halved after the window with the rejection and raised after a window of quick flushes", func() {
+				So(scripted.flushBytes, ShouldResemble, []int{1000, 500, 625})
+			})
+
+			Convey("Then the stats and flush callbacks cover every indexer opened", func() {
+				stats := indexer.Stats()
+				So(stats.NumAdded, ShouldEqual, 17)
+				So(stats.NumFlushed, ShouldEqual, 16)
+				So(stats.NumFailed, ShouldEqual, 1)
+				So(flushes.Load(), ShouldEqual, 17)
+			})
+		})
+	})
+
+	Convey("Given an adaptive bulk indexer whose flushes are slower than the target latency", t, func() {
+		scripted := &scriptedBulkIndexers{flushOnAdd: true, latency: time.Millisecond}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{
+			NumWorkers:         1,
+			FlushBytes:         1000,
+			MinFlushBytes:      100,
+			TargetFlushLatency: time.Microsecond,
+		}, scripted.open)
+		So(err, ShouldBeNil)
+
+		Convey("When items are added with TryAdd", func() {
+			for _, id := range ids(40) {
+				So(indexer.TryAdd(ctx, "index", "my-index", id, []byte(`{}`), nil, nil), ShouldBeNil)
+			}
+			So(indexer.Close(ctx), ShouldBeNil)
+
+			Convey("Then the flush size is halved after each window of flushes until it reaches the minimum", func() {
+				So(scripted.flushBytes, ShouldResemble, []int{1000, 500, 250, 125, 100})
+				So(indexer.Stats().NumFlushed, ShouldEqual, 40)
+			})
+
+			Convey("Then the indexers are reopened at most once every 8 flushes", func() {
+				So(scripted.opened, ShouldBeLessThanOrEqualTo, 1+40/8)
+			})
+		})
+	})
+
+	Convey("Given an adaptive bulk indexer whose flushes are alternately quick and slow", t, func() {
+		scripted := &scriptedBulkIndexers{flushOnAdd: true, latencies: []time.Duration{0, 5 * time.Millisecond}}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{
+			NumWorkers:         1,
+			FlushBytes:         1000,
+			MinFlushBytes:      100,
+			TargetFlushLatency: 2 * time.Millisecond,
+		}, scripted.open)
+		So(err, ShouldBeNil)
+
+		Convey("When a stream of items is added", func() {
+			for _, id := range ids(40) {
+				So(indexer.Add(ctx, "index", "my-index", id, []byte(`{}`), nil, nil), ShouldBeNil)
+			}
+			So(indexer.Close(ctx), ShouldBeNil)
+
+			Convey("Then the indexers are not reopened, as no window of flushes is slow or quick enough to change the size", func() {
+				So(scripted.opened, ShouldEqual, 1)
+				So(indexer.Stats().NumFlushed, ShouldEqual, 40)
+			})
+		})
+	})
+
+	Convey("Given a bulk indexer whose buffer limit could not hold a full request for each worker", t, func() {
+		scripted := &scriptedBulkIndexers{}
+		indexer, err := clientutil.WrapBulkIndexer(ctx, &client.BulkIndexerConfig{MaxBufferedBytes: 1000, NumWorkers: 4}, scripted.open)
+		So(err, ShouldBeNil)
+
+		Convey("Then its flush size is lowered to the share of the buffer of each worker", func() {
+			So(scripted.flushBytes, ShouldResemble, []int{250, 250, 250, 250})
+			So(indexer.Close(ctx), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,39 @@
+package clientutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+)
+
+// SendBulkRequests sends every request built by bulk with send, one at a time in order, and returns the results of
+// every action in a single response. It is used by the client implementations of Bulk.
+//
+// If any action failed, the response is returned with an error matching client.ErrBulkItemsFailed. If a request
+// fails, the remaining requests are not sent and the results of those already sent are returned with the error.
+func SendBulkRequests(ctx context.Context, bulk *client.BulkRequestBuilder, send func(ctx context.Context, body []byte) ([]byte, error)) (*client.BulkResponse, error) {
+	merged := &client.BulkResponse{}
+	if bulk == nil {
+		return merged, nil
+	}
+
+	requests := bulk.Requests()
+	for i, body := range requests {
+		resBody, err := send(ctx, body)
+		if err != nil {
+			return merged, fmt.Errorf("bulk request %d of %d failed: %w", i+1, len(requests), err)
+		}
+
+		res, err := client.ParseBulkResponse(resBody)
+		if res == nil {
+			return merged, err
+		}
+
+		merged.Took += res.Took
+		merged.Errors = merged.Errors || res.Errors
+		merged.Items = append(merged.Items, res.Items...)
+	}
+
+	return merged, merged.Err()
+}
@@ -0,0 +1,57 @@
+package clientutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSendBulkRequests(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a bulk request builder split into two requests", t, func() {
+		bulk := client.NewBulkRequestBuilder(50)
+		So(bulk.Index("my-index", "1", []byte(`{}`), nil), ShouldBeNil)
+		So(bulk.Index("my-index", "2", []byte(`{}`), nil), ShouldBeNil)
+		So(bulk.Requests(), ShouldHaveLength, 2)
+
+		Convey("When the second request has a failed item", func() {
+			responses := []string{
+				`{"took":1,"errors":false,"items":[{"index":{"_id":"1","status":201}}]}`,
+				`{"took":2,"errors":true,"items":[{"index":{"_id":"2","status":429,"error":{"type":"es_rejected_execution_exception"}}}]}`,
+			}
+			sent := 0
+			res, err := clientutil.SendBulkRequests(ctx, bulk, func(context.Context, []byte) ([]byte, error) {
+				sent++
+				return []byte(responses[sent-1]), nil
+			})
+
+			Convey("Then the results of both requests are merged and returned with an error", func() {
+				So(sent, ShouldEqual, 2)
+				So(res.Took, ShouldEqual, 3)
+				So(res.Errors, ShouldBeTrue)
+				So(res.Items, ShouldHaveLength, 2)
+				So(errors.Is(err, client.ErrBulkItemsFailed), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the first request fails", func() {
+			errRequest := errors.New("connection refused")
+			sent := 0
+			res, err := clientutil.SendBulkRequests(ctx, bulk, func(context.Context, []byte) ([]byte, error) {
+				sent++
+				return nil, errRequest
+			})
+
+			Convey("Then the second request is not sent and the error is returned", func() {
+				So(sent, ShouldEqual, 1)
+				So(errors.Is(err, errRequest), ShouldBeTrue)
+				So(res.Items, ShouldBeEmpty)
+			})
+		})
+	})
+}
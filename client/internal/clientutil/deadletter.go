@@ -0,0 +1,83 @@
+package clientutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+)
+
+// DeadLetterOnFailure returns a FailureFunc that sends the failed item to the dead letter sink of cfg before
+// calling onFailure, which may be nil. A failure to send the item is passed to the OnError callback of cfg.
+// onFailure is returned unchanged if cfg has no sink. It is used by WrapBulkIndexer, which passes the action and
+// document of each item as it is added.
+func DeadLetterOnFailure(cfg client.BulkIndexerConfig, action client.BulkIndexerAction, document []byte, onFailure client.FailureFunc) client.FailureFunc {
+	if cfg.DeadLetter == nil {
+		return onFailure
+	}
+
+	return func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+		letter := client.DeadLetter{
+			Action:     action,
+			Index:      item.Index,
+			DocumentID: item.DocumentID,
+			Body:       document,
+			Status:     res.Status,
+			Error:      bulkItemErrorCause(res, err),
+			Time:       time.Now().UTC(),
+			Attempts:   client.BulkItemAttempts(ctx),
+		}
+		if letter.Index == "" {
+			letter.Index = res.Index
+		}
+		if letter.Index == "" {
+			letter.Index = cfg.Index
+		}
+
+		if sendErr := cfg.DeadLetter.Send(ctx, letter); sendErr != nil && cfg.OnError != nil {
+			cfg.OnError(ctx, fmt.Errorf("failed to send %s of document %s to the dead letter sink: %w", action, item.DocumentID, sendErr))
+		}
+
+		if onFailure != nil {
+			onFailure(ctx, item, res, err)
+		}
+	}
+}
+
+// bulkItemErrorCause returns the error of a failed bulk item, or of its request if that failed as a whole. The
+// type and reason of a request that the cluster responded to with an error are kept, otherwise the error message.
+func bulkItemErrorCause(res esutil.BulkIndexerResponseItem, err error) *esError.ErrorCause {
+	if res.Error.Type != "" || res.Error.Reason != "" {
+		cause := &esError.ErrorCause{
+			Type:   res.Error.Type,
+			Reason: res.Error.Reason,
+		}
+		if res.Error.Cause.Type != "" || res.Error.Cause.Reason != "" {
+			cause.CausedBy = &esError.ErrorCause{
+				Type:   res.Error.Cause.Type,
+				Reason: res.Error.Cause.Reason,
+			}
+		}
+		return cause
+	}
+
+	var esErr *esError.ESError
+	if errors.As(err, &esErr) && (esErr.Type != "" || esErr.Reason != "") {
+		return &esError.ErrorCause{
+			Type:     esErr.Type,
+			Reason:   esErr.Reason,
+			Index:    esErr.Index,
+			CausedBy: esErr.CausedBy,
+		}
+	}
+
+	if err != nil {
+		return &esError.ErrorCause{Reason: err.Error()}
+	}
+
+	return nil
+}
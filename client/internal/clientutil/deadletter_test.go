@@ -0,0 +1,109 @@
+package clientutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryDeadLetterSink keeps the dead letters sent to it
+type memoryDeadLetterSink struct {
+	mu      sync.Mutex
+	letters []client.DeadLetter
+	err     error
+}
+
+func (s *memoryDeadLetterSink) Send(_ context.Context, letter client.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.letters = append(s.letters, letter)
+	return s.err
+}
+
+func TestDeadLetterOnFailure(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a config with a dead letter sink", t, func() {
+		sink := &memoryDeadLetterSink{}
+		var onErrors []error
+		cfg := client.BulkIndexerConfig{
+			Index:      "default-index",
+			DeadLetter: sink,
+			OnError:    func(_ context.Context, err error) { onErrors = append(onErrors, err) },
+		}
+
+		called := false
+		onFailure := clientutil.DeadLetterOnFailure(cfg, "create", []byte(`{"n":1}`), func(context.Context, esutil.BulkIndexerItem, esutil.BulkIndexerResponseItem, error) {
+			called = true
+		})
+
+		Convey("When an item fails with an error in the response", func() {
+			res := esutil.BulkIndexerResponseItem{Status: 400}
+			res.Error.Type = "mapper_parsing_exception"
+			res.Error.Reason = "failed to parse"
+			res.Error.Cause.Type = "json_parse_exception"
+			onFailure(ctx, esutil.BulkIndexerItem{DocumentID: "1"}, res, nil)
+
+			Convey("Then the item and its parsed error are sent to the sink before the failure func is called", func() {
+				So(sink.letters, ShouldHaveLength, 1)
+				letter := sink.letters[0]
+				So(letter.Action, ShouldEqual, client.BulkIndexerAction("create"))
+				So(letter.Index, ShouldEqual, "default-index")
+				So(letter.DocumentID, ShouldEqual, "1")
+				So(string(letter.Body), ShouldEqual, `{"n":1}`)
+				So(letter.Status, ShouldEqual, 400)
+				So(letter.Error.Type, ShouldEqual, "mapper_parsing_exception")
+				So(letter.Error.CausedBy.Type, ShouldEqual, "json_parse_exception")
+				So(letter.Time.IsZero(), ShouldBeFalse)
+				So(called, ShouldBeTrue)
+			})
+		})
+
+		Convey("When the request of an item fails as a whole", func() {
+			onFailure(ctx, esutil.BulkIndexerItem{Index: "my-index", DocumentID: "1"}, esutil.BulkIndexerResponseItem{}, errors.New("connection refused"))
+
+			Convey("Then the request error is sent to the sink", func() {
+				So(sink.letters[0].Index, ShouldEqual, "my-index")
+				So(sink.letters[0].Error.Reason, ShouldEqual, "connection refused")
+			})
+		})
+
+		Convey("When the request of an item fails as a whole with an error response", func() {
+			body := []byte(`{"error":{"type":"es_rejected_execution_exception","reason":"rejected execution of bulk"},"status":503}`)
+			err := fmt.Errorf("bulk request failed as a whole: %w", esError.NewESError("elasticsearch", 503, body))
+			onFailure(ctx, esutil.BulkIndexerItem{DocumentID: "1"}, esutil.BulkIndexerResponseItem{}, err)
+
+			Convey("Then the error of the response is sent to the sink, without a status", func() {
+				So(sink.letters[0].Status, ShouldEqual, 0)
+				So(sink.letters[0].Error.Type, ShouldEqual, "es_rejected_execution_exception")
+				So(sink.letters[0].Error.Reason, ShouldEqual, "rejected execution of bulk")
+			})
+		})
+
+		Convey("When the sink fails", func() {
+			sink.err = errors.New("disk full")
+			onFailure(ctx, esutil.BulkIndexerItem{DocumentID: "1"}, esutil.BulkIndexerResponseItem{}, errors.New("connection refused"))
+
+			Convey("Then the error is passed to OnError and the failure func is still called", func() {
+				So(onErrors, ShouldHaveLength, 1)
+				So(errors.Is(onErrors[0], sink.err), ShouldBeTrue)
+				So(called, ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a config without a dead letter sink", t, func() {
+		Convey("Then a nil failure func is returned unchanged", func() {
+			So(clientutil.DeadLetterOnFailure(client.BulkIndexerConfig{}, "index", nil, nil), ShouldBeNil)
+		})
+	})
+}
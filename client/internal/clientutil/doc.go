@@ -0,0 +1,3 @@
+// Package clientutil holds the behaviour shared by the client implementations, so that they stay consistent
+// without it being part of the API of the client package.
+package clientutil
@@ -0,0 +1,53 @@
+package clientutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+)
+
+// ReportBulkProgress calls the OnProgress callback of the config with the stats of a bulk indexer every
+// ProgressInterval, with the values of ctx, until the returned stop function is called. Stop waits for any report
+// in progress to finish and then reports the final stats. It is safe to call more than once. Nothing is reported
+// if OnProgress is nil.
+func ReportBulkProgress(ctx context.Context, cfg client.BulkIndexerConfig, stats func() client.BulkIndexerStats) (stop func()) {
+	if cfg.OnProgress == nil {
+		return func() {}
+	}
+
+	interval := cfg.ProgressInterval
+	if interval <= 0 {
+		interval = client.DefaultBulkIndexerProgressInterval
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				cfg.OnProgress(ctx, stats())
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+			cfg.OnProgress(ctx, stats())
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package clientutil_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReportBulkProgress(t *testing.T) {
+	Convey("Given a config with a progress callback", t, func() {
+		var mu sync.Mutex
+		var reports []uint64
+		cfg := client.BulkIndexerConfig{
+			ProgressInterval: time.Millisecond,
+			OnProgress: func(_ context.Context, stats client.BulkIndexerStats) {
+				mu.Lock()
+				defer mu.Unlock()
+				reports = append(reports, stats.NumAdded)
+			},
+		}
+
+		var added uint64
+		stats := func() client.BulkIndexerStats {
+			mu.Lock()
+			defer mu.Unlock()
+			added++
+			return client.BulkIndexerStats{NumAdded: added}
+		}
+
+		Convey("When progress is reported until it is stopped", func() {
+			stop := clientutil.ReportBulkProgress(context.Background(), cfg, stats)
+			time.Sleep(20 * time.Millisecond)
+			stop()
+			stop()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			Convey("Then the stats are reported periodically and once more when stopped", func() {
+				So(len(reports), ShouldBeGreaterThan, 1)
+				So(reports[len(reports)-1], ShouldEqual, added)
+			})
+		})
+	})
+
+	Convey("Given a config without a progress callback", t, func() {
+		Convey("Then stop can still be called", func() {
+			stop := clientutil.ReportBulkProgress(context.Background(), client.BulkIndexerConfig{}, nil)
+			So(stop, ShouldNotPanic)
+		})
+	})
+}
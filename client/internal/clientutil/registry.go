@@ -0,0 +1,141 @@
+package clientutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+)
+
+// BulkIndexerRegistry holds the bulk indexers of a client by name. It is used by the client implementations so
+// that each indexer opened is independent and a name can only be reused once its indexer has been closed.
+// An indexer is removed once it is closed, except for the default indexer registered under the empty name, which
+// is kept so that its stats can still be read. The zero value is ready to use, and it is safe for concurrent use.
+type BulkIndexerRegistry struct {
+	mu       sync.Mutex
+	indexers map[string]*registeredBulkIndexer
+}
+
+// Open creates a bulk indexer with newIndexer and registers it under name, returning it wrapped so that it can
+// no longer be added to once closed. An error matching client.ErrBulkIndexerOpen is returned if the indexer already
+// registered under name has not been closed.
+func (r *BulkIndexerRegistry) Open(name string, newIndexer func() (client.BulkIndexer, error)) (client.BulkIndexer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.indexers[name]; ok && !existing.isClosed() {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("%w: %q", client.ErrBulkIndexerOpen, name),
+			Code: http.StatusInternalServerError,
+		}
+	}
+
+	bi, err := newIndexer()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.indexers == nil {
+		r.indexers = make(map[string]*registeredBulkIndexer)
+	}
+	registered := &registeredBulkIndexer{BulkIndexer: bi, registry: r, name: name}
+	r.indexers[name] = registered
+
+	return registered, nil
+}
+
+// Get returns the bulk indexer registered under name. Only the default indexer, registered under the empty name,
+// may have been closed.
+func (r *BulkIndexerRegistry) Get(name string) (client.BulkIndexer, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bi, ok := r.indexers[name]
+	if !ok {
+		return nil, false
+	}
+
+	return bi, true
+}
+
+// remove removes the indexer registered under name if it is still bi, as the name may have been opened again
+func (r *BulkIndexerRegistry) remove(name string, bi *registeredBulkIndexer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexers[name] == bi {
+		delete(r.indexers, name)
+	}
+}
+
+// registeredBulkIndexer guards a bulk indexer against being added to once closed, or closed twice, either of
+// which would panic in the underlying indexers, and removes it from its registry once closed
+type registeredBulkIndexer struct {
+	client.BulkIndexer
+	registry *BulkIndexerRegistry
+	name     string
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func (b *registeredBulkIndexer) Add(ctx context.Context, action client.BulkIndexerAction, index, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return esError.StatusError{
+			Err:  client.ErrBulkIndexerClosed,
+			Code: http.StatusInternalServerError,
+		}
+	}
+
+	return b.BulkIndexer.Add(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+func (b *registeredBulkIndexer) TryAdd(ctx context.Context, action client.BulkIndexerAction, index, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return esError.StatusError{
+			Err:  client.ErrBulkIndexerClosed,
+			Code: http.StatusInternalServerError,
+		}
+	}
+
+	return b.BulkIndexer.TryAdd(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+func (b *registeredBulkIndexer) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	// The indexer is closed without holding the lock, as closing it runs the callbacks of its items, which would
+	// deadlock if they added to it rather than getting an error matching client.ErrBulkIndexerClosed. The lock is
+	// only taken once every add in progress has returned, so none of them are still adding to it.
+	err := b.BulkIndexer.Close(ctx)
+
+	// The registry is locked after the indexer is unlocked, as Open checks whether the indexer is closed while
+	// holding the registry lock
+	if b.name != "" {
+		b.registry.remove(b.name, b)
+	}
+
+	return err
+}
+
+func (b *registeredBulkIndexer) isClosed() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.closed
+}
@@ -0,0 +1,130 @@
+package clientutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/mocks"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBulkIndexerRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a registry with an open bulk indexer", t, func() {
+		var registry clientutil.BulkIndexerRegistry
+		indexer := &mocks.BulkIndexerMock{
+			AddFunc: func(context.Context, client.BulkIndexerAction, string, string, []byte, client.SuccessFunc, client.FailureFunc) error {
+				return nil
+			},
+			CloseFunc: func(context.Context) error { return nil },
+		}
+		bi, err := registry.Open("products", func() (client.BulkIndexer, error) { return indexer, nil })
+		So(err, ShouldBeNil)
+
+		Convey("When another indexer is opened with a different name", func() {
+			_, err := registry.Open("orders", func() (client.BulkIndexer, error) { return &mocks.BulkIndexerMock{}, nil })
+
+			Convey("Then it is opened alongside the first", func() {
+				So(err, ShouldBeNil)
+				got, ok := registry.Get("products")
+				So(ok, ShouldBeTrue)
+				So(got, ShouldEqual, bi)
+			})
+		})
+
+		Convey("When another indexer is opened with the same name", func() {
+			_, err := registry.Open("products", func() (client.BulkIndexer, error) { return &mocks.BulkIndexerMock{}, nil })
+
+			Convey("Then an error matching ErrBulkIndexerOpen is returned", func() {
+				So(errors.Is(err, client.ErrBulkIndexerOpen), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the indexer is closed twice and then added to", func() {
+			So(bi.Close(ctx), ShouldBeNil)
+			So(bi.Close(ctx), ShouldBeNil)
+			err := bi.Add(ctx, "index", "products", "1", []byte(`{}`), nil, nil)
+
+			Convey("Then the underlying indexer is only closed once and the add fails", func() {
+				So(indexer.CloseCalls(), ShouldHaveLength, 1)
+				So(indexer.AddCalls(), ShouldBeEmpty)
+				So(errors.Is(err, client.ErrBulkIndexerClosed), ShouldBeTrue)
+			})
+
+			Convey("Then the name can be opened again", func() {
+				reopened, err := registry.Open("products", func() (client.BulkIndexer, error) { return &mocks.BulkIndexerMock{}, nil })
+				So(err, ShouldBeNil)
+				So(reopened, ShouldNotEqual, bi)
+			})
+
+			Convey("Then it is removed from the registry", func() {
+				_, ok := registry.Get("products")
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a registry with a bulk indexer whose item callbacks add to it", t, func() {
+		var registry clientutil.BulkIndexerRegistry
+		var bi client.BulkIndexer
+		var addErr error
+		indexer := &mocks.BulkIndexerMock{
+			CloseFunc: func(ctx context.Context) error {
+				// A failure callback of an item flushed on close adds the item again
+				addErr = bi.Add(ctx, "index", "products", "1", []byte(`{}`), nil, nil)
+				return nil
+			},
+		}
+		var err error
+		bi, err = registry.Open("products", func() (client.BulkIndexer, error) { return indexer, nil })
+		So(err, ShouldBeNil)
+
+		Convey("When the indexer is closed", func() {
+			closed := make(chan error, 1)
+			go func() { closed <- bi.Close(ctx) }()
+
+			Convey("Then the close does not deadlock and the add fails as the indexer is closed", func() {
+				select {
+				case err := <-closed:
+					So(err, ShouldBeNil)
+				case <-time.After(5 * time.Second):
+					t.Fatal("closing the indexer deadlocked")
+				}
+				So(errors.Is(addErr, client.ErrBulkIndexerClosed), ShouldBeTrue)
+				So(indexer.AddCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a registry with an open default bulk indexer", t, func() {
+		var registry clientutil.BulkIndexerRegistry
+		bi, err := registry.Open("", func() (client.BulkIndexer, error) {
+			return &mocks.BulkIndexerMock{CloseFunc: func(context.Context) error { return nil }}, nil
+		})
+		So(err, ShouldBeNil)
+
+		Convey("When it is closed", func() {
+			So(bi.Close(ctx), ShouldBeNil)
+
+			Convey("Then it is kept so that its stats can be read", func() {
+				got, ok := registry.Get("")
+				So(ok, ShouldBeTrue)
+				So(got, ShouldEqual, bi)
+			})
+		})
+	})
+
+	Convey("Given an empty registry", t, func() {
+		var registry clientutil.BulkIndexerRegistry
+
+		Convey("Then no indexer is found", func() {
+			_, ok := registry.Get("")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
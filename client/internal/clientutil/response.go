@@ -0,0 +1,19 @@
+package clientutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+)
+
+// DecodeWriteResult decodes the response body of a single document write
+func DecodeWriteResult(body io.Reader) (*client.WriteResult, error) {
+	var res client.WriteResult
+	if err := json.NewDecoder(body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decode write response: %w", err)
+	}
+
+	return &res, nil
+}
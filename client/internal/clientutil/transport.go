@@ -0,0 +1,58 @@
+package clientutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+)
+
+// NewFlushTransport returns a transport that sends requests with base, or http.DefaultTransport if base is nil, and
+// records why the bulk requests flushed by the indexers of WrapBulkIndexer failed as a whole. The client libraries
+// only pass their callbacks a description of the error, or none at all when the response has an error status.
+// product names the cluster in the errors recorded.
+func NewFlushTransport(base http.RoundTripper, product string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &flushTransport{base: base, product: product}
+}
+
+// flushTransport is the transport returned by NewFlushTransport
+type flushTransport struct {
+	base    http.RoundTripper
+	product string
+}
+
+// RoundTrip sends the request, recording any error of a bulk request in the ledger of its flush
+func (t *flushTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ledger, ok := req.Context().Value(flushLedgerKey{}).(*flushLedger)
+	if !ok || !strings.HasSuffix(req.URL.Path, "/_bulk") {
+		return t.base.RoundTrip(req)
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		ledger.record(err)
+		return nil, err
+	}
+	if res.StatusCode < http.StatusMultipleChoices {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		err = fmt.Errorf("failed to read body of bulk response with status %d: %w", res.StatusCode, err)
+		ledger.record(err)
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	ledger.record(esError.NewESError(t.product, res.StatusCode, body))
+
+	return res, nil
+}
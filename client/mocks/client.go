@@ -5,9 +5,10 @@ package mocks
 
 import (
 	"context"
-	"github.com/ONSdigital/dp-elasticsearch/v4/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
 	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
 	"sync"
+	"time"
 )
 
 // Ensure, that ClientMock does implement client.Client.
@@ -20,21 +21,42 @@ var _ client.Client = &ClientMock{}
 //
 //		// make and configure a mocked client.Client
 //		mockedClient := &ClientMock{
-//			AddDocumentFunc: func(ctx context.Context, indexName string, documentID string, document []byte, opts *client.AddDocumentOptions) error {
+//			AddDocumentFunc: func(ctx context.Context, indexName string, documentID string, document []byte, opts *client.AddDocumentOptions) (*client.WriteResult, error) {
 //				panic("mock out the AddDocument method")
 //			},
+//			BulkFunc: func(ctx context.Context, bulk *client.BulkRequestBuilder, opts *client.BulkOptions) (*client.BulkResponse, error) {
+//				panic("mock out the Bulk method")
+//			},
 //			BulkIndexAddFunc: func(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
 //				panic("mock out the BulkIndexAdd method")
 //			},
 //			BulkIndexCloseFunc: func(contextMoqParam context.Context) error {
 //				panic("mock out the BulkIndexClose method")
 //			},
+//			BulkIndexStatsFunc: func(contextMoqParam context.Context) (client.BulkIndexerStats, error) {
+//				panic("mock out the BulkIndexStats method")
+//			},
+//			BulkIndexTryAddFunc: func(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
+//				panic("mock out the BulkIndexTryAdd method")
+//			},
 //			BulkUpdateFunc: func(ctx context.Context, indexName string, url string, settings []byte) ([]byte, error) {
 //				panic("mock out the BulkUpdate method")
 //			},
+//			CancelTaskFunc: func(ctx context.Context, taskID string) (*client.TasksResponse, error) {
+//				panic("mock out the CancelTask method")
+//			},
 //			CheckerFunc: func(ctx context.Context, state *health.CheckState) error {
 //				panic("mock out the Checker method")
 //			},
+//			ClearScrollFunc: func(ctx context.Context, scrollIDs []string) error {
+//				panic("mock out the ClearScroll method")
+//			},
+//			ClosePointInTimeFunc: func(ctx context.Context, pitID string) error {
+//				panic("mock out the ClosePointInTime method")
+//			},
+//			ClusterHealthFunc: func(ctx context.Context, indices []string, opts *client.ClusterHealthOptions) (*client.ClusterHealthResponse, error) {
+//				panic("mock out the ClusterHealth method")
+//			},
 //			CountFunc: func(ctx context.Context, count client.Count) ([]byte, error) {
 //				panic("mock out the Count method")
 //			},
@@ -44,7 +66,7 @@ var _ client.Client = &ClientMock{}
 //			CreateIndexFunc: func(ctx context.Context, indexName string, indexSettings []byte) error {
 //				panic("mock out the CreateIndex method")
 //			},
-//			DeleteDocumentFunc: func(ctx context.Context, indexName string, documentID string) error {
+//			DeleteDocumentFunc: func(ctx context.Context, indexName string, documentID string, opts *client.DeleteDocumentOptions) (*client.WriteResult, error) {
 //				panic("mock out the DeleteDocument method")
 //			},
 //			DeleteDocumentByQueryFunc: func(ctx context.Context, search client.Search) error {
@@ -62,21 +84,63 @@ var _ client.Client = &ClientMock{}
 //			GetAliasFunc: func(ctx context.Context) ([]byte, error) {
 //				panic("mock out the GetAlias method")
 //			},
+//			GetDocumentFunc: func(ctx context.Context, indexName string, documentID string, opts *client.GetDocumentOptions) (*client.GetResult, error) {
+//				panic("mock out the GetDocument method")
+//			},
 //			GetIndicesFunc: func(ctx context.Context, indexPatterns []string) ([]byte, error) {
 //				panic("mock out the GetIndices method")
 //			},
+//			GetTaskFunc: func(ctx context.Context, taskID string, opts *client.GetTaskOptions) (*client.TaskResponse, error) {
+//				panic("mock out the GetTask method")
+//			},
+//			ListTasksFunc: func(ctx context.Context, opts *client.ListTasksOptions) (*client.TasksResponse, error) {
+//				panic("mock out the ListTasks method")
+//			},
+//			MultiGetFunc: func(ctx context.Context, docs []client.MultiGetDocument, opts *client.MultiGetOptions) (*client.MultiGetResponse, error) {
+//				panic("mock out the MultiGet method")
+//			},
 //			MultiSearchFunc: func(ctx context.Context, searches []client.Search, queryParams *client.QueryParams) ([]byte, error) {
 //				panic("mock out the MultiSearch method")
 //			},
-//			NewBulkIndexerFunc: func(contextMoqParam context.Context) error {
+//			NewBulkIndexerFunc: func(ctx context.Context, cfg *client.BulkIndexerConfig) error {
 //				panic("mock out the NewBulkIndexer method")
 //			},
+//			OpenBulkIndexerFunc: func(ctx context.Context, name string, cfg *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+//				panic("mock out the OpenBulkIndexer method")
+//			},
+//			OpenPointInTimeFunc: func(ctx context.Context, indices []string, keepAlive time.Duration) (string, error) {
+//				panic("mock out the OpenPointInTime method")
+//			},
+//			RefreshIndicesFunc: func(ctx context.Context, indices []string) error {
+//				panic("mock out the RefreshIndices method")
+//			},
+//			ReindexFunc: func(ctx context.Context, reindex client.Reindex, opts *client.ReindexOptions) (*client.BulkByScrollResponse, error) {
+//				panic("mock out the Reindex method")
+//			},
+//			RethrottleTaskFunc: func(ctx context.Context, taskID string, requestsPerSecond int) (*client.TasksResponse, error) {
+//				panic("mock out the RethrottleTask method")
+//			},
+//			ScrollFunc: func(ctx context.Context, search client.Search, keepAlive time.Duration) ([]byte, error) {
+//				panic("mock out the Scroll method")
+//			},
+//			ScrollNextFunc: func(ctx context.Context, scrollID string, keepAlive time.Duration) ([]byte, error) {
+//				panic("mock out the ScrollNext method")
+//			},
 //			SearchFunc: func(ctx context.Context, search client.Search) ([]byte, error) {
 //				panic("mock out the Search method")
 //			},
+//			UpdateAliasActionsFunc: func(ctx context.Context, actions []client.AliasAction) error {
+//				panic("mock out the UpdateAliasActions method")
+//			},
 //			UpdateAliasesFunc: func(ctx context.Context, alias string, removeIndices []string, addIndices []string) error {
 //				panic("mock out the UpdateAliases method")
 //			},
+//			UpdateByQueryFunc: func(ctx context.Context, indices []string, update client.UpdateByQuery, opts *client.UpdateByQueryOptions) (*client.BulkByScrollResponse, error) {
+//				panic("mock out the UpdateByQuery method")
+//			},
+//			UpdateDocumentFunc: func(ctx context.Context, indexName string, documentID string, update client.DocumentUpdate, opts *client.UpdateDocumentOptions) (*client.WriteResult, error) {
+//				panic("mock out the UpdateDocument method")
+//			},
 //		}
 //
 //		// use mockedClient in code that requires client.Client
@@ -85,7 +149,10 @@ var _ client.Client = &ClientMock{}
 //	}
 type ClientMock struct {
 	// AddDocumentFunc mocks the AddDocument method.
-	AddDocumentFunc func(ctx context.Context, indexName string, documentID string, document []byte, opts *client.AddDocumentOptions) error
+	AddDocumentFunc func(ctx context.Context, indexName string, documentID string, document []byte, opts *client.AddDocumentOptions) (*client.WriteResult, error)
+
+	// BulkFunc mocks the Bulk method.
+	BulkFunc func(ctx context.Context, bulk *client.BulkRequestBuilder, opts *client.BulkOptions) (*client.BulkResponse, error)
 
 	// BulkIndexAddFunc mocks the BulkIndexAdd method.
 	BulkIndexAddFunc func(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error
@@ -93,12 +160,30 @@ type ClientMock struct {
 	// BulkIndexCloseFunc mocks the BulkIndexClose method.
 	BulkIndexCloseFunc func(contextMoqParam context.Context) error
 
+	// BulkIndexStatsFunc mocks the BulkIndexStats method.
+	BulkIndexStatsFunc func(contextMoqParam context.Context) (client.BulkIndexerStats, error)
+
+	// BulkIndexTryAddFunc mocks the BulkIndexTryAdd method.
+	BulkIndexTryAddFunc func(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error
+
 	// BulkUpdateFunc mocks the BulkUpdate method.
 	BulkUpdateFunc func(ctx context.Context, indexName string, url string, settings []byte) ([]byte, error)
 
+	// CancelTaskFunc mocks the CancelTask method.
+	CancelTaskFunc func(ctx context.Context, taskID string) (*client.TasksResponse, error)
+
 	// CheckerFunc mocks the Checker method.
 	CheckerFunc func(ctx context.Context, state *health.CheckState) error
 
+	// ClearScrollFunc mocks the ClearScroll method.
+	ClearScrollFunc func(ctx context.Context, scrollIDs []string) error
+
+	// ClosePointInTimeFunc mocks the ClosePointInTime method.
+	ClosePointInTimeFunc func(ctx context.Context, pitID string) error
+
+	// ClusterHealthFunc mocks the ClusterHealth method.
+	ClusterHealthFunc func(ctx context.Context, indices []string, opts *client.ClusterHealthOptions) (*client.ClusterHealthResponse, error)
+
 	// CountFunc mocks the Count method.
 	CountFunc func(ctx context.Context, count client.Count) ([]byte, error)
 
@@ -109,7 +194,7 @@ type ClientMock struct {
 	CreateIndexFunc func(ctx context.Context, indexName string, indexSettings []byte) error
 
 	// DeleteDocumentFunc mocks the DeleteDocument method.
-	DeleteDocumentFunc func(ctx context.Context, indexName string, documentID string) error
+	DeleteDocumentFunc func(ctx context.Context, indexName string, documentID string, opts *client.DeleteDocumentOptions) (*client.WriteResult, error)
 
 	// DeleteDocumentByQueryFunc mocks the DeleteDocumentByQuery method.
 	DeleteDocumentByQueryFunc func(ctx context.Context, search client.Search) error
@@ -126,21 +211,63 @@ type ClientMock struct {
 	// GetAliasFunc mocks the GetAlias method.
 	GetAliasFunc func(ctx context.Context) ([]byte, error)
 
+	// GetDocumentFunc mocks the GetDocument method.
+	GetDocumentFunc func(ctx context.Context, indexName string, documentID string, opts *client.GetDocumentOptions) (*client.GetResult, error)
+
 	// GetIndicesFunc mocks the GetIndices method.
 	GetIndicesFunc func(ctx context.Context, indexPatterns []string) ([]byte, error)
 
+	// GetTaskFunc mocks the GetTask method.
+	GetTaskFunc func(ctx context.Context, taskID string, opts *client.GetTaskOptions) (*client.TaskResponse, error)
+
+	// ListTasksFunc mocks the ListTasks method.
+	ListTasksFunc func(ctx context.Context, opts *client.ListTasksOptions) (*client.TasksResponse, error)
+
+	// MultiGetFunc mocks the MultiGet method.
+	MultiGetFunc func(ctx context.Context, docs []client.MultiGetDocument, opts *client.MultiGetOptions) (*client.MultiGetResponse, error)
+
 	// MultiSearchFunc mocks the MultiSearch method.
 	MultiSearchFunc func(ctx context.Context, searches []client.Search, queryParams *client.QueryParams) ([]byte, error)
 
 	// NewBulkIndexerFunc mocks the NewBulkIndexer method.
-	NewBulkIndexerFunc func(contextMoqParam context.Context) error
+	NewBulkIndexerFunc func(ctx context.Context, cfg *client.BulkIndexerConfig) error
+
+	// OpenBulkIndexerFunc mocks the OpenBulkIndexer method.
+	OpenBulkIndexerFunc func(ctx context.Context, name string, cfg *client.BulkIndexerConfig) (client.BulkIndexer, error)
+
+	// OpenPointInTimeFunc mocks the OpenPointInTime method.
+	OpenPointInTimeFunc func(ctx context.Context, indices []string, keepAlive time.Duration) (string, error)
+
+	// RefreshIndicesFunc mocks the RefreshIndices method.
+	RefreshIndicesFunc func(ctx context.Context, indices []string) error
+
+	// ReindexFunc mocks the Reindex method.
+	ReindexFunc func(ctx context.Context, reindex client.Reindex, opts *client.ReindexOptions) (*client.BulkByScrollResponse, error)
+
+	// RethrottleTaskFunc mocks the RethrottleTask method.
+	RethrottleTaskFunc func(ctx context.Context, taskID string, requestsPerSecond int) (*client.TasksResponse, error)
+
+	// ScrollFunc mocks the Scroll method.
+	ScrollFunc func(ctx context.Context, search client.Search, keepAlive time.Duration) ([]byte, error)
+
+	// ScrollNextFunc mocks the ScrollNext method.
+	ScrollNextFunc func(ctx context.Context, scrollID string, keepAlive time.Duration) ([]byte, error)
 
 	// SearchFunc mocks the Search method.
 	SearchFunc func(ctx context.Context, search client.Search) ([]byte, error)
 
+	// UpdateAliasActionsFunc mocks the UpdateAliasActions method.
+	UpdateAliasActionsFunc func(ctx context.Context, actions []client.AliasAction) error
+
 	// UpdateAliasesFunc mocks the UpdateAliases method.
 	UpdateAliasesFunc func(ctx context.Context, alias string, removeIndices []string, addIndices []string) error
 
+	// UpdateByQueryFunc mocks the UpdateByQuery method.
+	UpdateByQueryFunc func(ctx context.Context, indices []string, update client.UpdateByQuery, opts *client.UpdateByQueryOptions) (*client.BulkByScrollResponse, error)
+
+	// UpdateDocumentFunc mocks the UpdateDocument method.
+	UpdateDocumentFunc func(ctx context.Context, indexName string, documentID string, update client.DocumentUpdate, opts *client.UpdateDocumentOptions) (*client.WriteResult, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// AddDocument holds details about calls to the AddDocument method.
@@ -156,6 +283,15 @@ type ClientMock struct {
 			// Opts is the opts argument value.
 			Opts *client.AddDocumentOptions
 		}
+		// Bulk holds details about calls to the Bulk method.
+		Bulk []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Bulk is the bulk argument value.
+			Bulk *client.BulkRequestBuilder
+			// Opts is the opts argument value.
+			Opts *client.BulkOptions
+		}
 		// BulkIndexAdd holds details about calls to the BulkIndexAdd method.
 		BulkIndexAdd []struct {
 			// Ctx is the ctx argument value.
@@ -178,6 +314,28 @@ type ClientMock struct {
 			// ContextMoqParam is the contextMoqParam argument value.
 			ContextMoqParam context.Context
 		}
+		// BulkIndexStats holds details about calls to the BulkIndexStats method.
+		BulkIndexStats []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+		}
+		// BulkIndexTryAdd holds details about calls to the BulkIndexTryAdd method.
+		BulkIndexTryAdd []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Action is the action argument value.
+			Action client.BulkIndexerAction
+			// Index is the index argument value.
+			Index string
+			// DocumentID is the documentID argument value.
+			DocumentID string
+			// Document is the document argument value.
+			Document []byte
+			// OnSuccess is the onSuccess argument value.
+			OnSuccess client.SuccessFunc
+			// OnFailure is the onFailure argument value.
+			OnFailure client.FailureFunc
+		}
 		// BulkUpdate holds details about calls to the BulkUpdate method.
 		BulkUpdate []struct {
 			// Ctx is the ctx argument value.
@@ -189,6 +347,13 @@ type ClientMock struct {
 			// Settings is the settings argument value.
 			Settings []byte
 		}
+		// CancelTask holds details about calls to the CancelTask method.
+		CancelTask []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// TaskID is the taskID argument value.
+			TaskID string
+		}
 		// Checker holds details about calls to the Checker method.
 		Checker []struct {
 			// Ctx is the ctx argument value.
@@ -196,6 +361,29 @@ type ClientMock struct {
 			// State is the state argument value.
 			State *health.CheckState
 		}
+		// ClearScroll holds details about calls to the ClearScroll method.
+		ClearScroll []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ScrollIDs is the scrollIDs argument value.
+			ScrollIDs []string
+		}
+		// ClosePointInTime holds details about calls to the ClosePointInTime method.
+		ClosePointInTime []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PitID is the pitID argument value.
+			PitID string
+		}
+		// ClusterHealth holds details about calls to the ClusterHealth method.
+		ClusterHealth []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Indices is the indices argument value.
+			Indices []string
+			// Opts is the opts argument value.
+			Opts *client.ClusterHealthOptions
+		}
 		// Count holds details about calls to the Count method.
 		Count []struct {
 			// Ctx is the ctx argument value.
@@ -227,6 +415,8 @@ type ClientMock struct {
 			IndexName string
 			// DocumentID is the documentID argument value.
 			DocumentID string
+			// Opts is the opts argument value.
+			Opts *client.DeleteDocumentOptions
 		}
 		// DeleteDocumentByQuery holds details about calls to the DeleteDocumentByQuery method.
 		DeleteDocumentByQuery []struct {
@@ -263,6 +453,17 @@ type ClientMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// GetDocument holds details about calls to the GetDocument method.
+		GetDocument []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// IndexName is the indexName argument value.
+			IndexName string
+			// DocumentID is the documentID argument value.
+			DocumentID string
+			// Opts is the opts argument value.
+			Opts *client.GetDocumentOptions
+		}
 		// GetIndices holds details about calls to the GetIndices method.
 		GetIndices []struct {
 			// Ctx is the ctx argument value.
@@ -270,6 +471,31 @@ type ClientMock struct {
 			// IndexPatterns is the indexPatterns argument value.
 			IndexPatterns []string
 		}
+		// GetTask holds details about calls to the GetTask method.
+		GetTask []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// TaskID is the taskID argument value.
+			TaskID string
+			// Opts is the opts argument value.
+			Opts *client.GetTaskOptions
+		}
+		// ListTasks holds details about calls to the ListTasks method.
+		ListTasks []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Opts is the opts argument value.
+			Opts *client.ListTasksOptions
+		}
+		// MultiGet holds details about calls to the MultiGet method.
+		MultiGet []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Docs is the docs argument value.
+			Docs []client.MultiGetDocument
+			// Opts is the opts argument value.
+			Opts *client.MultiGetOptions
+		}
 		// MultiSearch holds details about calls to the MultiSearch method.
 		MultiSearch []struct {
 			// Ctx is the ctx argument value.
@@ -281,8 +507,71 @@ type ClientMock struct {
 		}
 		// NewBulkIndexer holds details about calls to the NewBulkIndexer method.
 		NewBulkIndexer []struct {
-			// ContextMoqParam is the contextMoqParam argument value.
-			ContextMoqParam context.Context
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Cfg is the cfg argument value.
+			Cfg *client.BulkIndexerConfig
+		}
+		// OpenBulkIndexer holds details about calls to the OpenBulkIndexer method.
+		OpenBulkIndexer []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+			// Cfg is the cfg argument value.
+			Cfg *client.BulkIndexerConfig
+		}
+		// OpenPointInTime holds details about calls to the OpenPointInTime method.
+		OpenPointInTime []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Indices is the indices argument value.
+			Indices []string
+			// KeepAlive is the keepAlive argument value.
+			KeepAlive time.Duration
+		}
+		// RefreshIndices holds details about calls to the RefreshIndices method.
+		RefreshIndices []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Indices is the indices argument value.
+			Indices []string
+		}
+		// Reindex holds details about calls to the Reindex method.
+		Reindex []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Reindex is the reindex argument value.
+			Reindex client.Reindex
+			// Opts is the opts argument value.
+			Opts *client.ReindexOptions
+		}
+		// RethrottleTask holds details about calls to the RethrottleTask method.
+		RethrottleTask []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// TaskID is the taskID argument value.
+			TaskID string
+			// RequestsPerSecond is the requestsPerSecond argument value.
+			RequestsPerSecond int
+		}
+		// Scroll holds details about calls to the Scroll method.
+		Scroll []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Search is the search argument value.
+			Search client.Search
+			// KeepAlive is the keepAlive argument value.
+			KeepAlive time.Duration
+		}
+		// ScrollNext holds details about calls to the ScrollNext method.
+		ScrollNext []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ScrollID is the scrollID argument value.
+			ScrollID string
+			// KeepAlive is the keepAlive argument value.
+			KeepAlive time.Duration
 		}
 		// Search holds details about calls to the Search method.
 		Search []struct {
@@ -291,6 +580,13 @@ type ClientMock struct {
 			// Search is the search argument value.
 			Search client.Search
 		}
+		// UpdateAliasActions holds details about calls to the UpdateAliasActions method.
+		UpdateAliasActions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Actions is the actions argument value.
+			Actions []client.AliasAction
+		}
 		// UpdateAliases holds details about calls to the UpdateAliases method.
 		UpdateAliases []struct {
 			// Ctx is the ctx argument value.
@@ -302,12 +598,43 @@ type ClientMock struct {
 			// AddIndices is the addIndices argument value.
 			AddIndices []string
 		}
+		// UpdateByQuery holds details about calls to the UpdateByQuery method.
+		UpdateByQuery []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Indices is the indices argument value.
+			Indices []string
+			// Update is the update argument value.
+			Update client.UpdateByQuery
+			// Opts is the opts argument value.
+			Opts *client.UpdateByQueryOptions
+		}
+		// UpdateDocument holds details about calls to the UpdateDocument method.
+		UpdateDocument []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// IndexName is the indexName argument value.
+			IndexName string
+			// DocumentID is the documentID argument value.
+			DocumentID string
+			// Update is the update argument value.
+			Update client.DocumentUpdate
+			// Opts is the opts argument value.
+			Opts *client.UpdateDocumentOptions
+		}
 	}
 	lockAddDocument           sync.RWMutex
+	lockBulk                  sync.RWMutex
 	lockBulkIndexAdd          sync.RWMutex
 	lockBulkIndexClose        sync.RWMutex
+	lockBulkIndexStats        sync.RWMutex
+	lockBulkIndexTryAdd       sync.RWMutex
 	lockBulkUpdate            sync.RWMutex
+	lockCancelTask            sync.RWMutex
 	lockChecker               sync.RWMutex
+	lockClearScroll           sync.RWMutex
+	lockClosePointInTime      sync.RWMutex
+	lockClusterHealth         sync.RWMutex
 	lockCount                 sync.RWMutex
 	lockCountIndices          sync.RWMutex
 	lockCreateIndex           sync.RWMutex
@@ -317,15 +644,29 @@ type ClientMock struct {
 	lockDeleteIndices         sync.RWMutex
 	lockExplain               sync.RWMutex
 	lockGetAlias              sync.RWMutex
+	lockGetDocument           sync.RWMutex
 	lockGetIndices            sync.RWMutex
+	lockGetTask               sync.RWMutex
+	lockListTasks             sync.RWMutex
+	lockMultiGet              sync.RWMutex
 	lockMultiSearch           sync.RWMutex
 	lockNewBulkIndexer        sync.RWMutex
+	lockOpenBulkIndexer       sync.RWMutex
+	lockOpenPointInTime       sync.RWMutex
+	lockRefreshIndices        sync.RWMutex
+	lockReindex               sync.RWMutex
+	lockRethrottleTask        sync.RWMutex
+	lockScroll                sync.RWMutex
+	lockScrollNext            sync.RWMutex
 	lockSearch                sync.RWMutex
+	lockUpdateAliasActions    sync.RWMutex
 	lockUpdateAliases         sync.RWMutex
+	lockUpdateByQuery         sync.RWMutex
+	lockUpdateDocument        sync.RWMutex
 }
 
 // AddDocument calls AddDocumentFunc.
-func (mock *ClientMock) AddDocument(ctx context.Context, indexName string, documentID string, document []byte, opts *client.AddDocumentOptions) error {
+func (mock *ClientMock) AddDocument(ctx context.Context, indexName string, documentID string, document []byte, opts *client.AddDocumentOptions) (*client.WriteResult, error) {
 	if mock.AddDocumentFunc == nil {
 		panic("ClientMock.AddDocumentFunc: method is nil but Client.AddDocument was just called")
 	}
@@ -372,6 +713,46 @@ func (mock *ClientMock) AddDocumentCalls() []struct {
 	return calls
 }
 
+// Bulk calls BulkFunc.
+func (mock *ClientMock) Bulk(ctx context.Context, bulk *client.BulkRequestBuilder, opts *client.BulkOptions) (*client.BulkResponse, error) {
+	if mock.BulkFunc == nil {
+		panic("ClientMock.BulkFunc: method is nil but Client.Bulk was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Bulk *client.BulkRequestBuilder
+		Opts *client.BulkOptions
+	}{
+		Ctx:  ctx,
+		Bulk: bulk,
+		Opts: opts,
+	}
+	mock.lockBulk.Lock()
+	mock.calls.Bulk = append(mock.calls.Bulk, callInfo)
+	mock.lockBulk.Unlock()
+	return mock.BulkFunc(ctx, bulk, opts)
+}
+
+// BulkCalls gets all the calls that were made to Bulk.
+// Check the length with:
+//
+//	len(mockedClient.BulkCalls())
+func (mock *ClientMock) BulkCalls() []struct {
+	Ctx  context.Context
+	Bulk *client.BulkRequestBuilder
+	Opts *client.BulkOptions
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Bulk *client.BulkRequestBuilder
+		Opts *client.BulkOptions
+	}
+	mock.lockBulk.RLock()
+	calls = mock.calls.Bulk
+	mock.lockBulk.RUnlock()
+	return calls
+}
+
 // BulkIndexAdd calls BulkIndexAddFunc.
 func (mock *ClientMock) BulkIndexAdd(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
 	if mock.BulkIndexAddFunc == nil {
@@ -460,6 +841,94 @@ func (mock *ClientMock) BulkIndexCloseCalls() []struct {
 	return calls
 }
 
+// BulkIndexStats calls BulkIndexStatsFunc.
+func (mock *ClientMock) BulkIndexStats(contextMoqParam context.Context) (client.BulkIndexerStats, error) {
+	if mock.BulkIndexStatsFunc == nil {
+		panic("ClientMock.BulkIndexStatsFunc: method is nil but Client.BulkIndexStats was just called")
+	}
+	callInfo := struct {
+		ContextMoqParam context.Context
+	}{
+		ContextMoqParam: contextMoqParam,
+	}
+	mock.lockBulkIndexStats.Lock()
+	mock.calls.BulkIndexStats = append(mock.calls.BulkIndexStats, callInfo)
+	mock.lockBulkIndexStats.Unlock()
+	return mock.BulkIndexStatsFunc(contextMoqParam)
+}
+
+// BulkIndexStatsCalls gets all the calls that were made to BulkIndexStats.
+// Check the length with:
+//
+//	len(mockedClient.BulkIndexStatsCalls())
+func (mock *ClientMock) BulkIndexStatsCalls() []struct {
+	ContextMoqParam context.Context
+} {
+	var calls []struct {
+		ContextMoqParam context.Context
+	}
+	mock.lockBulkIndexStats.RLock()
+	calls = mock.calls.BulkIndexStats
+	mock.lockBulkIndexStats.RUnlock()
+	return calls
+}
+
+// BulkIndexTryAdd calls BulkIndexTryAddFunc.
+func (mock *ClientMock) BulkIndexTryAdd(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
+	if mock.BulkIndexTryAddFunc == nil {
+		panic("ClientMock.BulkIndexTryAddFunc: method is nil but Client.BulkIndexTryAdd was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		Action     client.BulkIndexerAction
+		Index      string
+		DocumentID string
+		Document   []byte
+		OnSuccess  client.SuccessFunc
+		OnFailure  client.FailureFunc
+	}{
+		Ctx:        ctx,
+		Action:     action,
+		Index:      index,
+		DocumentID: documentID,
+		Document:   document,
+		OnSuccess:  onSuccess,
+		OnFailure:  onFailure,
+	}
+	mock.lockBulkIndexTryAdd.Lock()
+	mock.calls.BulkIndexTryAdd = append(mock.calls.BulkIndexTryAdd, callInfo)
+	mock.lockBulkIndexTryAdd.Unlock()
+	return mock.BulkIndexTryAddFunc(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+// BulkIndexTryAddCalls gets all the calls that were made to BulkIndexTryAdd.
+// Check the length with:
+//
+//	len(mockedClient.BulkIndexTryAddCalls())
+func (mock *ClientMock) BulkIndexTryAddCalls() []struct {
+	Ctx        context.Context
+	Action     client.BulkIndexerAction
+	Index      string
+	DocumentID string
+	Document   []byte
+	OnSuccess  client.SuccessFunc
+	OnFailure  client.FailureFunc
+} {
+	var calls []struct {
+		Ctx        context.Context
+		Action     client.BulkIndexerAction
+		Index      string
+		DocumentID string
+		Document   []byte
+		OnSuccess  client.SuccessFunc
+		OnFailure  client.FailureFunc
+	}
+	mock.lockBulkIndexTryAdd.RLock()
+	calls = mock.calls.BulkIndexTryAdd
+	mock.lockBulkIndexTryAdd.RUnlock()
+	return calls
+}
+
 // BulkUpdate calls BulkUpdateFunc.
 func (mock *ClientMock) BulkUpdate(ctx context.Context, indexName string, url string, settings []byte) ([]byte, error) {
 	if mock.BulkUpdateFunc == nil {
@@ -504,6 +973,42 @@ func (mock *ClientMock) BulkUpdateCalls() []struct {
 	return calls
 }
 
+// CancelTask calls CancelTaskFunc.
+func (mock *ClientMock) CancelTask(ctx context.Context, taskID string) (*client.TasksResponse, error) {
+	if mock.CancelTaskFunc == nil {
+		panic("ClientMock.CancelTaskFunc: method is nil but Client.CancelTask was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		TaskID string
+	}{
+		Ctx:    ctx,
+		TaskID: taskID,
+	}
+	mock.lockCancelTask.Lock()
+	mock.calls.CancelTask = append(mock.calls.CancelTask, callInfo)
+	mock.lockCancelTask.Unlock()
+	return mock.CancelTaskFunc(ctx, taskID)
+}
+
+// CancelTaskCalls gets all the calls that were made to CancelTask.
+// Check the length with:
+//
+//	len(mockedClient.CancelTaskCalls())
+func (mock *ClientMock) CancelTaskCalls() []struct {
+	Ctx    context.Context
+	TaskID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		TaskID string
+	}
+	mock.lockCancelTask.RLock()
+	calls = mock.calls.CancelTask
+	mock.lockCancelTask.RUnlock()
+	return calls
+}
+
 // Checker calls CheckerFunc.
 func (mock *ClientMock) Checker(ctx context.Context, state *health.CheckState) error {
 	if mock.CheckerFunc == nil {
@@ -540,25 +1045,137 @@ func (mock *ClientMock) CheckerCalls() []struct {
 	return calls
 }
 
-// Count calls CountFunc.
-func (mock *ClientMock) Count(ctx context.Context, count client.Count) ([]byte, error) {
-	if mock.CountFunc == nil {
-		panic("ClientMock.CountFunc: method is nil but Client.Count was just called")
+// ClearScroll calls ClearScrollFunc.
+func (mock *ClientMock) ClearScroll(ctx context.Context, scrollIDs []string) error {
+	if mock.ClearScrollFunc == nil {
+		panic("ClientMock.ClearScrollFunc: method is nil but Client.ClearScroll was just called")
 	}
 	callInfo := struct {
-		Ctx   context.Context
-		Count client.Count
+		Ctx       context.Context
+		ScrollIDs []string
 	}{
-		Ctx:   ctx,
-		Count: count,
+		Ctx:       ctx,
+		ScrollIDs: scrollIDs,
 	}
-	mock.lockCount.Lock()
-	mock.calls.Count = append(mock.calls.Count, callInfo)
-	mock.lockCount.Unlock()
-	return mock.CountFunc(ctx, count)
+	mock.lockClearScroll.Lock()
+	mock.calls.ClearScroll = append(mock.calls.ClearScroll, callInfo)
+	mock.lockClearScroll.Unlock()
+	return mock.ClearScrollFunc(ctx, scrollIDs)
 }
 
-// CountCalls gets all the calls that were made to Count.
+// ClearScrollCalls gets all the calls that were made to ClearScroll.
+// Check the length with:
+//
+//	len(mockedClient.ClearScrollCalls())
+func (mock *ClientMock) ClearScrollCalls() []struct {
+	Ctx       context.Context
+	ScrollIDs []string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		ScrollIDs []string
+	}
+	mock.lockClearScroll.RLock()
+	calls = mock.calls.ClearScroll
+	mock.lockClearScroll.RUnlock()
+	return calls
+}
+
+// ClosePointInTime calls ClosePointInTimeFunc.
+func (mock *ClientMock) ClosePointInTime(ctx context.Context, pitID string) error {
+	if mock.ClosePointInTimeFunc == nil {
+		panic("ClientMock.ClosePointInTimeFunc: method is nil but Client.ClosePointInTime was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		PitID string
+	}{
+		Ctx:   ctx,
+		PitID: pitID,
+	}
+	mock.lockClosePointInTime.Lock()
+	mock.calls.ClosePointInTime = append(mock.calls.ClosePointInTime, callInfo)
+	mock.lockClosePointInTime.Unlock()
+	return mock.ClosePointInTimeFunc(ctx, pitID)
+}
+
+// ClosePointInTimeCalls gets all the calls that were made to ClosePointInTime.
+// Check the length with:
+//
+//	len(mockedClient.ClosePointInTimeCalls())
+func (mock *ClientMock) ClosePointInTimeCalls() []struct {
+	Ctx   context.Context
+	PitID string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		PitID string
+	}
+	mock.lockClosePointInTime.RLock()
+	calls = mock.calls.ClosePointInTime
+	mock.lockClosePointInTime.RUnlock()
+	return calls
+}
+
+// ClusterHealth calls ClusterHealthFunc.
+func (mock *ClientMock) ClusterHealth(ctx context.Context, indices []string, opts *client.ClusterHealthOptions) (*client.ClusterHealthResponse, error) {
+	if mock.ClusterHealthFunc == nil {
+		panic("ClientMock.ClusterHealthFunc: method is nil but Client.ClusterHealth was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Indices []string
+		Opts    *client.ClusterHealthOptions
+	}{
+		Ctx:     ctx,
+		Indices: indices,
+		Opts:    opts,
+	}
+	mock.lockClusterHealth.Lock()
+	mock.calls.ClusterHealth = append(mock.calls.ClusterHealth, callInfo)
+	mock.lockClusterHealth.Unlock()
+	return mock.ClusterHealthFunc(ctx, indices, opts)
+}
+
+// ClusterHealthCalls gets all the calls that were made to ClusterHealth.
+// Check the length with:
+//
+//	len(mockedClient.ClusterHealthCalls())
+func (mock *ClientMock) ClusterHealthCalls() []struct {
+	Ctx     context.Context
+	Indices []string
+	Opts    *client.ClusterHealthOptions
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Indices []string
+		Opts    *client.ClusterHealthOptions
+	}
+	mock.lockClusterHealth.RLock()
+	calls = mock.calls.ClusterHealth
+	mock.lockClusterHealth.RUnlock()
+	return calls
+}
+
+// Count calls CountFunc.
+func (mock *ClientMock) Count(ctx context.Context, count client.Count) ([]byte, error) {
+	if mock.CountFunc == nil {
+		panic("ClientMock.CountFunc: method is nil but Client.Count was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Count client.Count
+	}{
+		Ctx:   ctx,
+		Count: count,
+	}
+	mock.lockCount.Lock()
+	mock.calls.Count = append(mock.calls.Count, callInfo)
+	mock.lockCount.Unlock()
+	return mock.CountFunc(ctx, count)
+}
+
+// CountCalls gets all the calls that were made to Count.
 // Check the length with:
 //
 //	len(mockedClient.CountCalls())
@@ -653,7 +1270,7 @@ func (mock *ClientMock) CreateIndexCalls() []struct {
 }
 
 // DeleteDocument calls DeleteDocumentFunc.
-func (mock *ClientMock) DeleteDocument(ctx context.Context, indexName string, documentID string) error {
+func (mock *ClientMock) DeleteDocument(ctx context.Context, indexName string, documentID string, opts *client.DeleteDocumentOptions) (*client.WriteResult, error) {
 	if mock.DeleteDocumentFunc == nil {
 		panic("ClientMock.DeleteDocumentFunc: method is nil but Client.DeleteDocument was just called")
 	}
@@ -661,15 +1278,17 @@ func (mock *ClientMock) DeleteDocument(ctx context.Context, indexName string, do
 		Ctx        context.Context
 		IndexName  string
 		DocumentID string
+		Opts       *client.DeleteDocumentOptions
 	}{
 		Ctx:        ctx,
 		IndexName:  indexName,
 		DocumentID: documentID,
+		Opts:       opts,
 	}
 	mock.lockDeleteDocument.Lock()
 	mock.calls.DeleteDocument = append(mock.calls.DeleteDocument, callInfo)
 	mock.lockDeleteDocument.Unlock()
-	return mock.DeleteDocumentFunc(ctx, indexName, documentID)
+	return mock.DeleteDocumentFunc(ctx, indexName, documentID, opts)
 }
 
 // DeleteDocumentCalls gets all the calls that were made to DeleteDocument.
@@ -680,11 +1299,13 @@ func (mock *ClientMock) DeleteDocumentCalls() []struct {
 	Ctx        context.Context
 	IndexName  string
 	DocumentID string
+	Opts       *client.DeleteDocumentOptions
 } {
 	var calls []struct {
 		Ctx        context.Context
 		IndexName  string
 		DocumentID string
+		Opts       *client.DeleteDocumentOptions
 	}
 	mock.lockDeleteDocument.RLock()
 	calls = mock.calls.DeleteDocument
@@ -872,6 +1493,50 @@ func (mock *ClientMock) GetAliasCalls() []struct {
 	return calls
 }
 
+// GetDocument calls GetDocumentFunc.
+func (mock *ClientMock) GetDocument(ctx context.Context, indexName string, documentID string, opts *client.GetDocumentOptions) (*client.GetResult, error) {
+	if mock.GetDocumentFunc == nil {
+		panic("ClientMock.GetDocumentFunc: method is nil but Client.GetDocument was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		IndexName  string
+		DocumentID string
+		Opts       *client.GetDocumentOptions
+	}{
+		Ctx:        ctx,
+		IndexName:  indexName,
+		DocumentID: documentID,
+		Opts:       opts,
+	}
+	mock.lockGetDocument.Lock()
+	mock.calls.GetDocument = append(mock.calls.GetDocument, callInfo)
+	mock.lockGetDocument.Unlock()
+	return mock.GetDocumentFunc(ctx, indexName, documentID, opts)
+}
+
+// GetDocumentCalls gets all the calls that were made to GetDocument.
+// Check the length with:
+//
+//	len(mockedClient.GetDocumentCalls())
+func (mock *ClientMock) GetDocumentCalls() []struct {
+	Ctx        context.Context
+	IndexName  string
+	DocumentID string
+	Opts       *client.GetDocumentOptions
+} {
+	var calls []struct {
+		Ctx        context.Context
+		IndexName  string
+		DocumentID string
+		Opts       *client.GetDocumentOptions
+	}
+	mock.lockGetDocument.RLock()
+	calls = mock.calls.GetDocument
+	mock.lockGetDocument.RUnlock()
+	return calls
+}
+
 // GetIndices calls GetIndicesFunc.
 func (mock *ClientMock) GetIndices(ctx context.Context, indexPatterns []string) ([]byte, error) {
 	if mock.GetIndicesFunc == nil {
@@ -908,6 +1573,122 @@ func (mock *ClientMock) GetIndicesCalls() []struct {
 	return calls
 }
 
+// GetTask calls GetTaskFunc.
+func (mock *ClientMock) GetTask(ctx context.Context, taskID string, opts *client.GetTaskOptions) (*client.TaskResponse, error) {
+	if mock.GetTaskFunc == nil {
+		panic("ClientMock.GetTaskFunc: method is nil but Client.GetTask was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		TaskID string
+		Opts   *client.GetTaskOptions
+	}{
+		Ctx:    ctx,
+		TaskID: taskID,
+		Opts:   opts,
+	}
+	mock.lockGetTask.Lock()
+	mock.calls.GetTask = append(mock.calls.GetTask, callInfo)
+	mock.lockGetTask.Unlock()
+	return mock.GetTaskFunc(ctx, taskID, opts)
+}
+
+// GetTaskCalls gets all the calls that were made to GetTask.
+// Check the length with:
+//
+//	len(mockedClient.GetTaskCalls())
+func (mock *ClientMock) GetTaskCalls() []struct {
+	Ctx    context.Context
+	TaskID string
+	Opts   *client.GetTaskOptions
+} {
+	var calls []struct {
+		Ctx    context.Context
+		TaskID string
+		Opts   *client.GetTaskOptions
+	}
+	mock.lockGetTask.RLock()
+	calls = mock.calls.GetTask
+	mock.lockGetTask.RUnlock()
+	return calls
+}
+
+// ListTasks calls ListTasksFunc.
+func (mock *ClientMock) ListTasks(ctx context.Context, opts *client.ListTasksOptions) (*client.TasksResponse, error) {
+	if mock.ListTasksFunc == nil {
+		panic("ClientMock.ListTasksFunc: method is nil but Client.ListTasks was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Opts *client.ListTasksOptions
+	}{
+		Ctx:  ctx,
+		Opts: opts,
+	}
+	mock.lockListTasks.Lock()
+	mock.calls.ListTasks = append(mock.calls.ListTasks, callInfo)
+	mock.lockListTasks.Unlock()
+	return mock.ListTasksFunc(ctx, opts)
+}
+
+// ListTasksCalls gets all the calls that were made to ListTasks.
+// Check the length with:
+//
+//	len(mockedClient.ListTasksCalls())
+func (mock *ClientMock) ListTasksCalls() []struct {
+	Ctx  context.Context
+	Opts *client.ListTasksOptions
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Opts *client.ListTasksOptions
+	}
+	mock.lockListTasks.RLock()
+	calls = mock.calls.ListTasks
+	mock.lockListTasks.RUnlock()
+	return calls
+}
+
+// MultiGet calls MultiGetFunc.
+func (mock *ClientMock) MultiGet(ctx context.Context, docs []client.MultiGetDocument, opts *client.MultiGetOptions) (*client.MultiGetResponse, error) {
+	if mock.MultiGetFunc == nil {
+		panic("ClientMock.MultiGetFunc: method is nil but Client.MultiGet was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Docs []client.MultiGetDocument
+		Opts *client.MultiGetOptions
+	}{
+		Ctx:  ctx,
+		Docs: docs,
+		Opts: opts,
+	}
+	mock.lockMultiGet.Lock()
+	mock.calls.MultiGet = append(mock.calls.MultiGet, callInfo)
+	mock.lockMultiGet.Unlock()
+	return mock.MultiGetFunc(ctx, docs, opts)
+}
+
+// MultiGetCalls gets all the calls that were made to MultiGet.
+// Check the length with:
+//
+//	len(mockedClient.MultiGetCalls())
+func (mock *ClientMock) MultiGetCalls() []struct {
+	Ctx  context.Context
+	Docs []client.MultiGetDocument
+	Opts *client.MultiGetOptions
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Docs []client.MultiGetDocument
+		Opts *client.MultiGetOptions
+	}
+	mock.lockMultiGet.RLock()
+	calls = mock.calls.MultiGet
+	mock.lockMultiGet.RUnlock()
+	return calls
+}
+
 // MultiSearch calls MultiSearchFunc.
 func (mock *ClientMock) MultiSearch(ctx context.Context, searches []client.Search, queryParams *client.QueryParams) ([]byte, error) {
 	if mock.MultiSearchFunc == nil {
@@ -949,19 +1730,21 @@ func (mock *ClientMock) MultiSearchCalls() []struct {
 }
 
 // NewBulkIndexer calls NewBulkIndexerFunc.
-func (mock *ClientMock) NewBulkIndexer(contextMoqParam context.Context) error {
+func (mock *ClientMock) NewBulkIndexer(ctx context.Context, cfg *client.BulkIndexerConfig) error {
 	if mock.NewBulkIndexerFunc == nil {
 		panic("ClientMock.NewBulkIndexerFunc: method is nil but Client.NewBulkIndexer was just called")
 	}
 	callInfo := struct {
-		ContextMoqParam context.Context
+		Ctx context.Context
+		Cfg *client.BulkIndexerConfig
 	}{
-		ContextMoqParam: contextMoqParam,
+		Ctx: ctx,
+		Cfg: cfg,
 	}
 	mock.lockNewBulkIndexer.Lock()
 	mock.calls.NewBulkIndexer = append(mock.calls.NewBulkIndexer, callInfo)
 	mock.lockNewBulkIndexer.Unlock()
-	return mock.NewBulkIndexerFunc(contextMoqParam)
+	return mock.NewBulkIndexerFunc(ctx, cfg)
 }
 
 // NewBulkIndexerCalls gets all the calls that were made to NewBulkIndexer.
@@ -969,10 +1752,12 @@ func (mock *ClientMock) NewBulkIndexer(contextMoqParam context.Context) error {
 //
 //	len(mockedClient.NewBulkIndexerCalls())
 func (mock *ClientMock) NewBulkIndexerCalls() []struct {
-	ContextMoqParam context.Context
+	Ctx context.Context
+	Cfg *client.BulkIndexerConfig
 } {
 	var calls []struct {
-		ContextMoqParam context.Context
+		Ctx context.Context
+		Cfg *client.BulkIndexerConfig
 	}
 	mock.lockNewBulkIndexer.RLock()
 	calls = mock.calls.NewBulkIndexer
@@ -980,6 +1765,282 @@ func (mock *ClientMock) NewBulkIndexerCalls() []struct {
 	return calls
 }
 
+// OpenBulkIndexer calls OpenBulkIndexerFunc.
+func (mock *ClientMock) OpenBulkIndexer(ctx context.Context, name string, cfg *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+	if mock.OpenBulkIndexerFunc == nil {
+		panic("ClientMock.OpenBulkIndexerFunc: method is nil but Client.OpenBulkIndexer was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Name string
+		Cfg  *client.BulkIndexerConfig
+	}{
+		Ctx:  ctx,
+		Name: name,
+		Cfg:  cfg,
+	}
+	mock.lockOpenBulkIndexer.Lock()
+	mock.calls.OpenBulkIndexer = append(mock.calls.OpenBulkIndexer, callInfo)
+	mock.lockOpenBulkIndexer.Unlock()
+	return mock.OpenBulkIndexerFunc(ctx, name, cfg)
+}
+
+// OpenBulkIndexerCalls gets all the calls that were made to OpenBulkIndexer.
+// Check the length with:
+//
+//	len(mockedClient.OpenBulkIndexerCalls())
+func (mock *ClientMock) OpenBulkIndexerCalls() []struct {
+	Ctx  context.Context
+	Name string
+	Cfg  *client.BulkIndexerConfig
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Name string
+		Cfg  *client.BulkIndexerConfig
+	}
+	mock.lockOpenBulkIndexer.RLock()
+	calls = mock.calls.OpenBulkIndexer
+	mock.lockOpenBulkIndexer.RUnlock()
+	return calls
+}
+
+// OpenPointInTime calls OpenPointInTimeFunc.
+func (mock *ClientMock) OpenPointInTime(ctx context.Context, indices []string, keepAlive time.Duration) (string, error) {
+	if mock.OpenPointInTimeFunc == nil {
+		panic("ClientMock.OpenPointInTimeFunc: method is nil but Client.OpenPointInTime was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Indices   []string
+		KeepAlive time.Duration
+	}{
+		Ctx:       ctx,
+		Indices:   indices,
+		KeepAlive: keepAlive,
+	}
+	mock.lockOpenPointInTime.Lock()
+	mock.calls.OpenPointInTime = append(mock.calls.OpenPointInTime, callInfo)
+	mock.lockOpenPointInTime.Unlock()
+	return mock.OpenPointInTimeFunc(ctx, indices, keepAlive)
+}
+
+// OpenPointInTimeCalls gets all the calls that were made to OpenPointInTime.
+// Check the length with:
+//
+//	len(mockedClient.OpenPointInTimeCalls())
+func (mock *ClientMock) OpenPointInTimeCalls() []struct {
+	Ctx       context.Context
+	Indices   []string
+	KeepAlive time.Duration
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Indices   []string
+		KeepAlive time.Duration
+	}
+	mock.lockOpenPointInTime.RLock()
+	calls = mock.calls.OpenPointInTime
+	mock.lockOpenPointInTime.RUnlock()
+	return calls
+}
+
+// RefreshIndices calls RefreshIndicesFunc.
+func (mock *ClientMock) RefreshIndices(ctx context.Context, indices []string) error {
+	if mock.RefreshIndicesFunc == nil {
+		panic("ClientMock.RefreshIndicesFunc: method is nil but Client.RefreshIndices was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Indices []string
+	}{
+		Ctx:     ctx,
+		Indices: indices,
+	}
+	mock.lockRefreshIndices.Lock()
+	mock.calls.RefreshIndices = append(mock.calls.RefreshIndices, callInfo)
+	mock.lockRefreshIndices.Unlock()
+	return mock.RefreshIndicesFunc(ctx, indices)
+}
+
+// RefreshIndicesCalls gets all the calls that were made to RefreshIndices.
+// Check the length with:
+//
+//	len(mockedClient.RefreshIndicesCalls())
+func (mock *ClientMock) RefreshIndicesCalls() []struct {
+	Ctx     context.Context
+	Indices []string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Indices []string
+	}
+	mock.lockRefreshIndices.RLock()
+	calls = mock.calls.RefreshIndices
+	mock.lockRefreshIndices.RUnlock()
+	return calls
+}
+
+// Reindex calls ReindexFunc.
+func (mock *ClientMock) Reindex(ctx context.Context, reindex client.Reindex, opts *client.ReindexOptions) (*client.BulkByScrollResponse, error) {
+	if mock.ReindexFunc == nil {
+		panic("ClientMock.ReindexFunc: method is nil but Client.Reindex was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Reindex client.Reindex
+		Opts    *client.ReindexOptions
+	}{
+		Ctx:     ctx,
+		Reindex: reindex,
+		Opts:    opts,
+	}
+	mock.lockReindex.Lock()
+	mock.calls.Reindex = append(mock.calls.Reindex, callInfo)
+	mock.lockReindex.Unlock()
+	return mock.ReindexFunc(ctx, reindex, opts)
+}
+
+// ReindexCalls gets all the calls that were made to Reindex.
+// Check the length with:
+//
+//	len(mockedClient.ReindexCalls())
+func (mock *ClientMock) ReindexCalls() []struct {
+	Ctx     context.Context
+	Reindex client.Reindex
+	Opts    *client.ReindexOptions
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Reindex client.Reindex
+		Opts    *client.ReindexOptions
+	}
+	mock.lockReindex.RLock()
+	calls = mock.calls.Reindex
+	mock.lockReindex.RUnlock()
+	return calls
+}
+
+// RethrottleTask calls RethrottleTaskFunc.
+func (mock *ClientMock) RethrottleTask(ctx context.Context, taskID string, requestsPerSecond int) (*client.TasksResponse, error) {
+	if mock.RethrottleTaskFunc == nil {
+		panic("ClientMock.RethrottleTaskFunc: method is nil but Client.RethrottleTask was just called")
+	}
+	callInfo := struct {
+		Ctx               context.Context
+		TaskID            string
+		RequestsPerSecond int
+	}{
+		Ctx:               ctx,
+		TaskID:            taskID,
+		RequestsPerSecond: requestsPerSecond,
+	}
+	mock.lockRethrottleTask.Lock()
+	mock.calls.RethrottleTask = append(mock.calls.RethrottleTask, callInfo)
+	mock.lockRethrottleTask.Unlock()
+	return mock.RethrottleTaskFunc(ctx, taskID, requestsPerSecond)
+}
+
+// RethrottleTaskCalls gets all the calls that were made to RethrottleTask.
+// Check the length with:
+//
+//	len(mockedClient.RethrottleTaskCalls())
+func (mock *ClientMock) RethrottleTaskCalls() []struct {
+	Ctx               context.Context
+	TaskID            string
+	RequestsPerSecond int
+} {
+	var calls []struct {
+		Ctx               context.Context
+		TaskID            string
+		RequestsPerSecond int
+	}
+	mock.lockRethrottleTask.RLock()
+	calls = mock.calls.RethrottleTask
+	mock.lockRethrottleTask.RUnlock()
+	return calls
+}
+
+// Scroll calls ScrollFunc.
+func (mock *ClientMock) Scroll(ctx context.Context, search client.Search, keepAlive time.Duration) ([]byte, error) {
+	if mock.ScrollFunc == nil {
+		panic("ClientMock.ScrollFunc: method is nil but Client.Scroll was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Search    client.Search
+		KeepAlive time.Duration
+	}{
+		Ctx:       ctx,
+		Search:    search,
+		KeepAlive: keepAlive,
+	}
+	mock.lockScroll.Lock()
+	mock.calls.Scroll = append(mock.calls.Scroll, callInfo)
+	mock.lockScroll.Unlock()
+	return mock.ScrollFunc(ctx, search, keepAlive)
+}
+
+// ScrollCalls gets all the calls that were made to Scroll.
+// Check the length with:
+//
+//	len(mockedClient.ScrollCalls())
+func (mock *ClientMock) ScrollCalls() []struct {
+	Ctx       context.Context
+	Search    client.Search
+	KeepAlive time.Duration
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Search    client.Search
+		KeepAlive time.Duration
+	}
+	mock.lockScroll.RLock()
+	calls = mock.calls.Scroll
+	mock.lockScroll.RUnlock()
+	return calls
+}
+
+// ScrollNext calls ScrollNextFunc.
+func (mock *ClientMock) ScrollNext(ctx context.Context, scrollID string, keepAlive time.Duration) ([]byte, error) {
+	if mock.ScrollNextFunc == nil {
+		panic("ClientMock.ScrollNextFunc: method is nil but Client.ScrollNext was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		ScrollID  string
+		KeepAlive time.Duration
+	}{
+		Ctx:       ctx,
+		ScrollID:  scrollID,
+		KeepAlive: keepAlive,
+	}
+	mock.lockScrollNext.Lock()
+	mock.calls.ScrollNext = append(mock.calls.ScrollNext, callInfo)
+	mock.lockScrollNext.Unlock()
+	return mock.ScrollNextFunc(ctx, scrollID, keepAlive)
+}
+
+// ScrollNextCalls gets all the calls that were made to ScrollNext.
+// Check the length with:
+//
+//	len(mockedClient.ScrollNextCalls())
+func (mock *ClientMock) ScrollNextCalls() []struct {
+	Ctx       context.Context
+	ScrollID  string
+	KeepAlive time.Duration
+} {
+	var calls []struct {
+		Ctx       context.Context
+		ScrollID  string
+		KeepAlive time.Duration
+	}
+	mock.lockScrollNext.RLock()
+	calls = mock.calls.ScrollNext
+	mock.lockScrollNext.RUnlock()
+	return calls
+}
+
 // Search calls SearchFunc.
 func (mock *ClientMock) Search(ctx context.Context, search client.Search) ([]byte, error) {
 	if mock.SearchFunc == nil {
@@ -1016,6 +2077,42 @@ func (mock *ClientMock) SearchCalls() []struct {
 	return calls
 }
 
+// UpdateAliasActions calls UpdateAliasActionsFunc.
+func (mock *ClientMock) UpdateAliasActions(ctx context.Context, actions []client.AliasAction) error {
+	if mock.UpdateAliasActionsFunc == nil {
+		panic("ClientMock.UpdateAliasActionsFunc: method is nil but Client.UpdateAliasActions was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Actions []client.AliasAction
+	}{
+		Ctx:     ctx,
+		Actions: actions,
+	}
+	mock.lockUpdateAliasActions.Lock()
+	mock.calls.UpdateAliasActions = append(mock.calls.UpdateAliasActions, callInfo)
+	mock.lockUpdateAliasActions.Unlock()
+	return mock.UpdateAliasActionsFunc(ctx, actions)
+}
+
+// UpdateAliasActionsCalls gets all the calls that were made to UpdateAliasActions.
+// Check the length with:
+//
+//	len(mockedClient.UpdateAliasActionsCalls())
+func (mock *ClientMock) UpdateAliasActionsCalls() []struct {
+	Ctx     context.Context
+	Actions []client.AliasAction
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Actions []client.AliasAction
+	}
+	mock.lockUpdateAliasActions.RLock()
+	calls = mock.calls.UpdateAliasActions
+	mock.lockUpdateAliasActions.RUnlock()
+	return calls
+}
+
 // UpdateAliases calls UpdateAliasesFunc.
 func (mock *ClientMock) UpdateAliases(ctx context.Context, alias string, removeIndices []string, addIndices []string) error {
 	if mock.UpdateAliasesFunc == nil {
@@ -1059,3 +2156,358 @@ func (mock *ClientMock) UpdateAliasesCalls() []struct {
 	mock.lockUpdateAliases.RUnlock()
 	return calls
 }
+
+// UpdateByQuery calls UpdateByQueryFunc.
+func (mock *ClientMock) UpdateByQuery(ctx context.Context, indices []string, update client.UpdateByQuery, opts *client.UpdateByQueryOptions) (*client.BulkByScrollResponse, error) {
+	if mock.UpdateByQueryFunc == nil {
+		panic("ClientMock.UpdateByQueryFunc: method is nil but Client.UpdateByQuery was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Indices []string
+		Update  client.UpdateByQuery
+		Opts    *client.UpdateByQueryOptions
+	}{
+		Ctx:     ctx,
+		Indices: indices,
+		Update:  update,
+		Opts:    opts,
+	}
+	mock.lockUpdateByQuery.Lock()
+	mock.calls.UpdateByQuery = append(mock.calls.UpdateByQuery, callInfo)
+	mock.lockUpdateByQuery.Unlock()
+	return mock.UpdateByQueryFunc(ctx, indices, update, opts)
+}
+
+// UpdateByQueryCalls gets all the calls that were made to UpdateByQuery.
+// Check the length with:
+//
+//	len(mockedClient.UpdateByQueryCalls())
+func (mock *ClientMock) UpdateByQueryCalls() []struct {
+	Ctx     context.Context
+	Indices []string
+	Update  client.UpdateByQuery
+	Opts    *client.UpdateByQueryOptions
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Indices []string
+		Update  client.UpdateByQuery
+		Opts    *client.UpdateByQueryOptions
+	}
+	mock.lockUpdateByQuery.RLock()
+	calls = mock.calls.UpdateByQuery
+	mock.lockUpdateByQuery.RUnlock()
+	return calls
+}
+
+// UpdateDocument calls UpdateDocumentFunc.
+func (mock *ClientMock) UpdateDocument(ctx context.Context, indexName string, documentID string, update client.DocumentUpdate, opts *client.UpdateDocumentOptions) (*client.WriteResult, error) {
+	if mock.UpdateDocumentFunc == nil {
+		panic("ClientMock.UpdateDocumentFunc: method is nil but Client.UpdateDocument was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		IndexName  string
+		DocumentID string
+		Update     client.DocumentUpdate
+		Opts       *client.UpdateDocumentOptions
+	}{
+		Ctx:        ctx,
+		IndexName:  indexName,
+		DocumentID: documentID,
+		Update:     update,
+		Opts:       opts,
+	}
+	mock.lockUpdateDocument.Lock()
+	mock.calls.UpdateDocument = append(mock.calls.UpdateDocument, callInfo)
+	mock.lockUpdateDocument.Unlock()
+	return mock.UpdateDocumentFunc(ctx, indexName, documentID, update, opts)
+}
+
+// UpdateDocumentCalls gets all the calls that were made to UpdateDocument.
+// Check the length with:
+//
+//	len(mockedClient.UpdateDocumentCalls())
+func (mock *ClientMock) UpdateDocumentCalls() []struct {
+	Ctx        context.Context
+	IndexName  string
+	DocumentID string
+	Update     client.DocumentUpdate
+	Opts       *client.UpdateDocumentOptions
+} {
+	var calls []struct {
+		Ctx        context.Context
+		IndexName  string
+		DocumentID string
+		Update     client.DocumentUpdate
+		Opts       *client.UpdateDocumentOptions
+	}
+	mock.lockUpdateDocument.RLock()
+	calls = mock.calls.UpdateDocument
+	mock.lockUpdateDocument.RUnlock()
+	return calls
+}
+
+// Ensure, that BulkIndexerMock does implement client.BulkIndexer.
+// If this is not the case, regenerate this file with moq.
+var _ client.BulkIndexer = &BulkIndexerMock{}
+
+// BulkIndexerMock is a mock implementation of client.BulkIndexer.
+//
+//	func TestSomethingThatUsesBulkIndexer(t *testing.T) {
+//
+//		// make and configure a mocked client.BulkIndexer
+//		mockedBulkIndexer := &BulkIndexerMock{
+//			AddFunc: func(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
+//				panic("mock out the Add method")
+//			},
+//			CloseFunc: func(ctx context.Context) error {
+//				panic("mock out the Close method")
+//			},
+//			StatsFunc: func() client.BulkIndexerStats {
+//				panic("mock out the Stats method")
+//			},
+//			TryAddFunc: func(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
+//				panic("mock out the TryAdd method")
+//			},
+//		}
+//
+//		// use mockedBulkIndexer in code that requires client.BulkIndexer
+//		// and then make assertions.
+//
+//	}
+type BulkIndexerMock struct {
+	// AddFunc mocks the Add method.
+	AddFunc func(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error
+
+	// CloseFunc mocks the Close method.
+	CloseFunc func(ctx context.Context) error
+
+	// StatsFunc mocks the Stats method.
+	StatsFunc func() client.BulkIndexerStats
+
+	// TryAddFunc mocks the TryAdd method.
+	TryAddFunc func(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Add holds details about calls to the Add method.
+		Add []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Action is the action argument value.
+			Action client.BulkIndexerAction
+			// Index is the index argument value.
+			Index string
+			// DocumentID is the documentID argument value.
+			DocumentID string
+			// Document is the document argument value.
+			Document []byte
+			// OnSuccess is the onSuccess argument value.
+			OnSuccess client.SuccessFunc
+			// OnFailure is the onFailure argument value.
+			OnFailure client.FailureFunc
+		}
+		// Close holds details about calls to the Close method.
+		Close []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// Stats holds details about calls to the Stats method.
+		Stats []struct {
+		}
+		// TryAdd holds details about calls to the TryAdd method.
+		TryAdd []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Action is the action argument value.
+			Action client.BulkIndexerAction
+			// Index is the index argument value.
+			Index string
+			// DocumentID is the documentID argument value.
+			DocumentID string
+			// Document is the document argument value.
+			Document []byte
+			// OnSuccess is the onSuccess argument value.
+			OnSuccess client.SuccessFunc
+			// OnFailure is the onFailure argument value.
+			OnFailure client.FailureFunc
+		}
+	}
+	lockAdd    sync.RWMutex
+	lockClose  sync.RWMutex
+	lockStats  sync.RWMutex
+	lockTryAdd sync.RWMutex
+}
+
+// Add calls AddFunc.
+func (mock *BulkIndexerMock) Add(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
+	if mock.AddFunc == nil {
+		panic("BulkIndexerMock.AddFunc: method is nil but BulkIndexer.Add was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		Action     client.BulkIndexerAction
+		Index      string
+		DocumentID string
+		Document   []byte
+		OnSuccess  client.SuccessFunc
+		OnFailure  client.FailureFunc
+	}{
+		Ctx:        ctx,
+		Action:     action,
+		Index:      index,
+		DocumentID: documentID,
+		Document:   document,
+		OnSuccess:  onSuccess,
+		OnFailure:  onFailure,
+	}
+	mock.lockAdd.Lock()
+	mock.calls.Add = append(mock.calls.Add, callInfo)
+	mock.lockAdd.Unlock()
+	return mock.AddFunc(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+// AddCalls gets all the calls that were made to Add.
+// Check the length with:
+//
+//	len(mockedBulkIndexer.AddCalls())
+func (mock *BulkIndexerMock) AddCalls() []struct {
+	Ctx        context.Context
+	Action     client.BulkIndexerAction
+	Index      string
+	DocumentID string
+	Document   []byte
+	OnSuccess  client.SuccessFunc
+	OnFailure  client.FailureFunc
+} {
+	var calls []struct {
+		Ctx        context.Context
+		Action     client.BulkIndexerAction
+		Index      string
+		DocumentID string
+		Document   []byte
+		OnSuccess  client.SuccessFunc
+		OnFailure  client.FailureFunc
+	}
+	mock.lockAdd.RLock()
+	calls = mock.calls.Add
+	mock.lockAdd.RUnlock()
+	return calls
+}
+
+// Close calls CloseFunc.
+func (mock *BulkIndexerMock) Close(ctx context.Context) error {
+	if mock.CloseFunc == nil {
+		panic("BulkIndexerMock.CloseFunc: method is nil but BulkIndexer.Close was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockClose.Lock()
+	mock.calls.Close = append(mock.calls.Close, callInfo)
+	mock.lockClose.Unlock()
+	return mock.CloseFunc(ctx)
+}
+
+// CloseCalls gets all the calls that were made to Close.
+// Check the length with:
+//
+//	len(mockedBulkIndexer.CloseCalls())
+func (mock *BulkIndexerMock) CloseCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockClose.RLock()
+	calls = mock.calls.Close
+	mock.lockClose.RUnlock()
+	return calls
+}
+
+// Stats calls StatsFunc.
+func (mock *BulkIndexerMock) Stats() client.BulkIndexerStats {
+	if mock.StatsFunc == nil {
+		panic("BulkIndexerMock.StatsFunc: method is nil but BulkIndexer.Stats was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockStats.Lock()
+	mock.calls.Stats = append(mock.calls.Stats, callInfo)
+	mock.lockStats.Unlock()
+	return mock.StatsFunc()
+}
+
+// StatsCalls gets all the calls that were made to Stats.
+// Check the length with:
+//
+//	len(mockedBulkIndexer.StatsCalls())
+func (mock *BulkIndexerMock) StatsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockStats.RLock()
+	calls = mock.calls.Stats
+	mock.lockStats.RUnlock()
+	return calls
+}
+
+// TryAdd calls TryAddFunc.
+func (mock *BulkIndexerMock) TryAdd(ctx context.Context, action client.BulkIndexerAction, index string, documentID string, document []byte, onSuccess client.SuccessFunc, onFailure client.FailureFunc) error {
+	if mock.TryAddFunc == nil {
+		panic("BulkIndexerMock.TryAddFunc: method is nil but BulkIndexer.TryAdd was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		Action     client.BulkIndexerAction
+		Index      string
+		DocumentID string
+		Document   []byte
+		OnSuccess  client.SuccessFunc
+		OnFailure  client.FailureFunc
+	}{
+		Ctx:        ctx,
+		Action:     action,
+		Index:      index,
+		DocumentID: documentID,
+		Document:   document,
+		OnSuccess:  onSuccess,
+		OnFailure:  onFailure,
+	}
+	mock.lockTryAdd.Lock()
+	mock.calls.TryAdd = append(mock.calls.TryAdd, callInfo)
+	mock.lockTryAdd.Unlock()
+	return mock.TryAddFunc(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+// TryAddCalls gets all the calls that were made to TryAdd.
+// Check the length with:
+//
+//	len(mockedBulkIndexer.TryAddCalls())
+func (mock *BulkIndexerMock) TryAddCalls() []struct {
+	Ctx        context.Context
+	Action     client.BulkIndexerAction
+	Index      string
+	DocumentID string
+	Document   []byte
+	OnSuccess  client.SuccessFunc
+	OnFailure  client.FailureFunc
+} {
+	var calls []struct {
+		Ctx        context.Context
+		Action     client.BulkIndexerAction
+		Index      string
+		DocumentID string
+		Document   []byte
+		OnSuccess  client.SuccessFunc
+		OnFailure  client.FailureFunc
+	}
+	mock.lockTryAdd.RLock()
+	calls = mock.calls.TryAdd
+	mock.lockTryAdd.RUnlock()
+	return calls
+}
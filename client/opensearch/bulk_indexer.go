@@ -0,0 +1,137 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+	opensearchv2 "github.com/opensearch-project/opensearch-go/v2"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchutil"
+)
+
+const (
+	Create = client.BulkIndexerAction("create")
+	Delete = client.BulkIndexerAction("delete")
+	Index  = client.BulkIndexerAction("index")
+	Update = client.BulkIndexerAction("update")
+)
+
+// bulkIndexer adds items to a bulk indexer of the client library. It is wrapped by clientutil.WrapBulkIndexer,
+// which retries items, sends them to the dead letter sink and reports progress.
+type bulkIndexer struct {
+	bi opensearchutil.BulkIndexer
+}
+
+// newBulkIndexer creates a new bulk indexer.
+func newBulkIndexer(osClient *opensearchv2.Client, cfg *client.BulkIndexerConfig) (*bulkIndexer, error) {
+	if osClient == nil {
+		return nil, errors.New("opensearch client should not be nil")
+	}
+
+	c := cfg.WithDefaults()
+	bi, err := opensearchutil.NewBulkIndexer(opensearchutil.BulkIndexerConfig{
+		Client:        osClient,
+		NumWorkers:    c.NumWorkers,
+		FlushBytes:    c.FlushBytes,
+		FlushInterval: c.FlushInterval,
+		Index:         c.Index,
+		Pipeline:      c.Pipeline,
+		Refresh:       string(c.Refresh),
+		Timeout:       c.Timeout,
+		OnError:       c.OnError,
+		OnFlushStart:  c.OnFlushStart,
+		OnFlushEnd:    c.OnFlushEnd,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bulkIndexer{bi: bi}, nil
+}
+
+// Add adds an item to the indexer, waiting until its queue has room for it. It returns an error when the item cannot
+// be added. The callbacks of the item get its result.
+//
+// You must call the Close() method after you're done adding items.
+func (b *bulkIndexer) Add(ctx context.Context, item clientutil.Item) error {
+	bulkIndexerItem := opensearchutil.BulkIndexerItem{
+		Action:     string(item.Action),
+		Body:       item.Body,
+		DocumentID: item.DocumentID,
+		Index:      item.Index,
+	}
+
+	// The client callbacks are expressed in terms of go-elasticsearch types, so the
+	// opensearch item and response are converted before being handed over.
+	if onSuccess := item.OnSuccess; onSuccess != nil {
+		bulkIndexerItem.OnSuccess = func(ctx context.Context, item opensearchutil.BulkIndexerItem, res opensearchutil.BulkIndexerResponseItem) {
+			onSuccess(ctx, toESBulkIndexerItem(item), toESBulkIndexerResponseItem(res))
+		}
+	}
+
+	if onFailure := item.OnFailure; onFailure != nil {
+		bulkIndexerItem.OnFailure = func(ctx context.Context, item opensearchutil.BulkIndexerItem, res opensearchutil.BulkIndexerResponseItem, err error) {
+			onFailure(ctx, toESBulkIndexerItem(item), toESBulkIndexerResponseItem(res), err)
+		}
+	}
+
+	return b.bi.Add(ctx, bulkIndexerItem)
+}
+
+// Close waits until all added items are flushed and closes the indexer.
+func (b *bulkIndexer) Close(ctx context.Context) error {
+	return b.bi.Close(ctx)
+}
+
+// Stats returns the counts of items handled by the indexer since it was created.
+func (b *bulkIndexer) Stats() client.BulkIndexerStats {
+	stats := b.bi.Stats()
+
+	return client.BulkIndexerStats{
+		NumAdded:    stats.NumAdded,
+		NumFlushed:  stats.NumFlushed,
+		NumFailed:   stats.NumFailed,
+		NumIndexed:  stats.NumIndexed,
+		NumCreated:  stats.NumCreated,
+		NumUpdated:  stats.NumUpdated,
+		NumDeleted:  stats.NumDeleted,
+		NumRequests: stats.NumRequests,
+	}
+}
+
+// toESBulkIndexerItem converts an opensearch bulk indexer item into the equivalent go-elasticsearch item
+func toESBulkIndexerItem(item opensearchutil.BulkIndexerItem) esutil.BulkIndexerItem {
+	return esutil.BulkIndexerItem{
+		Index:           item.Index,
+		Action:          item.Action,
+		DocumentID:      item.DocumentID,
+		Body:            item.Body,
+		RetryOnConflict: item.RetryOnConflict,
+	}
+}
+
+// toESBulkIndexerResponseItem converts an opensearch bulk response item into the equivalent go-elasticsearch item
+func toESBulkIndexerResponseItem(res opensearchutil.BulkIndexerResponseItem) esutil.BulkIndexerResponseItem {
+	esRes := esutil.BulkIndexerResponseItem{
+		Index:      res.Index,
+		DocumentID: res.DocumentID,
+		Version:    res.Version,
+		Result:     res.Result,
+		Status:     res.Status,
+		SeqNo:      res.SeqNo,
+		PrimTerm:   res.PrimTerm,
+	}
+
+	esRes.Shards.Total = res.Shards.Total
+	esRes.Shards.Successful = res.Shards.Successful
+	esRes.Shards.Failed = res.Shards.Failed
+
+	esRes.Error.Type = res.Error.Type
+	esRes.Error.Reason = res.Error.Reason
+	esRes.Error.Cause.Type = res.Error.Cause.Type
+	esRes.Error.Cause.Reason = res.Error.Cause.Reason
+
+	return esRes
+}
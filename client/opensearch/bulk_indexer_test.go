@@ -0,0 +1,356 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+	opensearchv2 "github.com/opensearch-project/opensearch-go/v2"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewBulkIndexer(t *testing.T) {
+	Convey("Given non nil opensearch client", t, func() {
+		client := &opensearchv2.Client{}
+
+		Convey("When calling newBulkIndexer", func() {
+			expectedBulkIndexer := &bulkIndexer{}
+
+			bulkIndexer, err := newBulkIndexer(client, nil)
+
+			Convey("Then a new bulk indexer is returned", func() {
+				So(err, ShouldBeNil)
+				So(bulkIndexer, ShouldHaveSameTypeAs, expectedBulkIndexer)
+			})
+		})
+	})
+
+	Convey("Given client is nil", t, func() {
+		var client *opensearchv2.Client
+
+		Convey("When calling newBulkIndexer", func() {
+			bulkIndexer, err := newBulkIndexer(client, nil)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldResemble, errors.New("opensearch client should not be nil"))
+				So(bulkIndexer, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestBulkIndexerMethods(t *testing.T) {
+	testCtx := context.Background()
+	indexName := "test123"
+
+	Convey("Given a valid bulk indexer", t, func() {
+		bulkIndexer, err := newBulkIndexer(&opensearchv2.Client{}, nil)
+		if err != nil {
+			t.Errorf("failed to setup bulk indexer for test")
+		}
+
+		Convey("When calling Add method with nil callbacks", func() {
+			err := bulkIndexer.Add(testCtx, clientutil.Item{Action: Create, Index: indexName, DocumentID: "123", Body: bytes.NewReader([]byte{})})
+
+			Convey("Then item is added to the bulk indexer without errors", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When calling Close method", func() {
+			err := bulkIndexer.Close(testCtx)
+
+			Convey("Then the bulkindexer was closed without errors", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestConvertBulkIndexerTypes(t *testing.T) {
+	Convey("Given an opensearch bulk indexer item and response", t, func() {
+		item := opensearchutil.BulkIndexerItem{
+			Index:      "test-index",
+			Action:     "create",
+			DocumentID: "123",
+			Body:       bytes.NewReader([]byte(`{}`)),
+		}
+
+		res := opensearchutil.BulkIndexerResponseItem{
+			Index:      "test-index",
+			DocumentID: "123",
+			Status:     409,
+		}
+		res.Error.Type = "version_conflict_engine_exception"
+		res.Error.Reason = "document already exists"
+
+		Convey("When they are converted to go-elasticsearch types", func() {
+			esItem := toESBulkIndexerItem(item)
+			esRes := toESBulkIndexerResponseItem(res)
+
+			Convey("Then the fields are preserved", func() {
+				So(esItem.Index, ShouldEqual, "test-index")
+				So(esItem.Action, ShouldEqual, "create")
+				So(esItem.DocumentID, ShouldEqual, "123")
+				So(esRes.Status, ShouldEqual, 409)
+				So(esRes.Error.Type, ShouldEqual, "version_conflict_engine_exception")
+				So(esRes.Error.Reason, ShouldEqual, "document already exists")
+			})
+		})
+	})
+}
+
+func TestBulkIndexerConfig(t *testing.T) {
+	testCtx := context.Background()
+	resBody := `{"took":1,"errors":false,"items":[{"index":{"_index":"default-index","_id":"1","status":201,"result":"created"}}]}`
+
+	Convey("Given a bulk indexer with a config", t, func() {
+		var path string
+		var query url.Values
+		recordRequest := func(req *http.Request) {
+			path, query = req.URL.Path, req.URL.Query()
+		}
+
+		var flushesStarted, flushesEnded int
+		bulkIndexer, err := newBulkIndexer(newMockClient(http.StatusOK, resBody, recordRequest), &client.BulkIndexerConfig{
+			NumWorkers: 1,
+			Index:      "default-index",
+			Pipeline:   "my-pipeline",
+			Refresh:    client.RefreshWaitFor,
+			Timeout:    time.Minute,
+			OnFlushStart: func(ctx context.Context) context.Context {
+				flushesStarted++
+				return ctx
+			},
+			OnFlushEnd: func(context.Context) {
+				flushesEnded++
+			},
+		})
+		So(err, ShouldBeNil)
+
+		Convey("When an item without an index is added and the indexer closed", func() {
+			So(bulkIndexer.Add(testCtx, clientutil.Item{Action: Index, DocumentID: "1", Body: bytes.NewReader([]byte(`{}`))}), ShouldBeNil)
+			So(bulkIndexer.Close(testCtx), ShouldBeNil)
+
+			Convey("Then the request is sent to the default index with the configured parameters", func() {
+				So(path, ShouldEqual, "/default-index/_bulk")
+				So(query.Get("pipeline"), ShouldEqual, "my-pipeline")
+				So(query.Get("refresh"), ShouldEqual, "wait_for")
+				So(query.Get("timeout"), ShouldEqual, "60000ms")
+			})
+
+			Convey("Then the flush callbacks are called", func() {
+				So(flushesStarted, ShouldEqual, 1)
+				So(flushesEnded, ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestBulkIndexerStats(t *testing.T) {
+	testCtx := context.Background()
+	resBody := `{"took":1,"errors":true,"items":[` +
+		`{"create":{"_index":"my-index","_id":"1","status":201,"result":"created"}},` +
+		`{"create":{"_index":"my-index","_id":"2","status":409,"error":{"type":"version_conflict_engine_exception","reason":"document already exists"}}}]}`
+
+	Convey("Given a bulk indexer with a maximum failure ratio, a progress callback and a dead letter sink", t, func() {
+		var progress []client.BulkIndexerStats
+		var deadLetters []client.DeadLetter
+		mockClient := newMockClient(http.StatusOK, resBody, nil)
+		testClient := &Client{osClient: mockClient, noRetryClient: mockClient}
+		bulkIndexer, err := testClient.OpenBulkIndexer(testCtx, "test", &client.BulkIndexerConfig{
+			NumWorkers:      1,
+			MaxFailureRatio: 0.25,
+			OnProgress: func(_ context.Context, stats client.BulkIndexerStats) {
+				progress = append(progress, stats)
+			},
+			DeadLetter: client.DeadLetterSinkFunc(func(_ context.Context, letter client.DeadLetter) error {
+				deadLetters = append(deadLetters, letter)
+				return nil
+			}),
+		})
+		So(err, ShouldBeNil)
+
+		Convey("When one of two items fails", func() {
+			So(bulkIndexer.Add(testCtx, Create, "my-index", "1", []byte(`{}`), nil, nil), ShouldBeNil)
+			So(bulkIndexer.Add(testCtx, Create, "my-index", "2", []byte(`{}`), nil, nil), ShouldBeNil)
+			err := bulkIndexer.Close(testCtx)
+
+			Convey("Then the stats count the items and the request", func() {
+				stats := bulkIndexer.Stats()
+				So(stats.NumAdded, ShouldEqual, 2)
+				So(stats.NumFlushed, ShouldEqual, 1)
+				So(stats.NumCreated, ShouldEqual, 1)
+				So(stats.NumFailed, ShouldEqual, 1)
+				So(stats.NumRequests, ShouldEqual, 1)
+			})
+
+			Convey("Then the final stats are reported on close", func() {
+				So(progress, ShouldNotBeEmpty)
+				So(progress[len(progress)-1], ShouldResemble, bulkIndexer.Stats())
+			})
+
+			Convey("Then the failed item is sent to the dead letter sink with its error", func() {
+				So(deadLetters, ShouldHaveLength, 1)
+				So(deadLetters[0].Action, ShouldEqual, Create)
+				So(deadLetters[0].DocumentID, ShouldEqual, "2")
+				So(deadLetters[0].Status, ShouldEqual, http.StatusConflict)
+				So(deadLetters[0].Error.Type, ShouldEqual, "version_conflict_engine_exception")
+			})
+
+			Convey("Then closing the indexer returns an error as the failure ratio is exceeded", func() {
+				So(errors.Is(err, client.ErrBulkFailureRatioExceeded), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestBulkIndexerRequestFailure(t *testing.T) {
+	testCtx := context.Background()
+	resBody := `{"error":{"type":"es_rejected_execution_exception","reason":"rejected execution of bulk"},"status":503}`
+
+	Convey("Given a bulk indexer with room for 10 bytes of documents whose bulk requests fail with a 503", t, func() {
+		transport := &mockRoundTripper{
+			roundTripFunc: func(req *http.Request) *http.Response {
+				return &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Body:       io.NopCloser(strings.NewReader(resBody)),
+					Header:     make(http.Header),
+				}
+			},
+		}
+		testClient, err := NewClientWithConfig(client.Config{Address: "http://localhost:9200", Transport: transport})
+		So(err, ShouldBeNil)
+		var deadLetters []client.DeadLetter
+		bulkIndexer, err := testClient.OpenBulkIndexer(testCtx, "test", &client.BulkIndexerConfig{
+			NumWorkers:       1,
+			FlushBytes:       1,
+			MaxBufferedBytes: 10,
+			DeadLetter: client.DeadLetterSinkFunc(func(_ context.Context, letter client.DeadLetter) error {
+				deadLetters = append(deadLetters, letter)
+				return nil
+			}),
+		})
+		So(err, ShouldBeNil)
+
+		failures := make(chan error, 3)
+		onFailure := func(_ context.Context, _ esutil.BulkIndexerItem, _ esutil.BulkIndexerResponseItem, err error) {
+			failures <- err
+		}
+
+		Convey("When an item is added", func() {
+			So(bulkIndexer.Add(testCtx, Index, "my-index", "1", []byte(`{"n":1}`), nil, onFailure), ShouldBeNil)
+
+			Convey("Then it fails with the error of the response", func() {
+				var err error
+				select {
+				case err = <-failures:
+				case <-time.After(5 * time.Second):
+				}
+				var esErr *esError.ESError
+				So(errors.As(err, &esErr), ShouldBeTrue)
+				So(esErr.StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+				So(esErr.Type, ShouldEqual, "es_rejected_execution_exception")
+
+				Convey("And it is sent to the dead letter sink with the error of the response and no status", func() {
+					So(deadLetters, ShouldHaveLength, 1)
+					So(deadLetters[0].DocumentID, ShouldEqual, "1")
+					So(deadLetters[0].Status, ShouldEqual, 0)
+					So(deadLetters[0].Error.Type, ShouldEqual, "es_rejected_execution_exception")
+					So(deadLetters[0].Error.Reason, ShouldEqual, "rejected execution of bulk")
+				})
+
+				Convey("And its room in the buffer is released", func() {
+					So(bulkIndexer.TryAdd(testCtx, Index, "my-index", "2", []byte(`{"n":2}`), nil, onFailure), ShouldBeNil)
+
+					timeoutCtx, cancel := context.WithTimeout(testCtx, 5*time.Second)
+					defer cancel()
+					So(bulkIndexer.Add(timeoutCtx, Index, "my-index", "3", []byte(`{"n":3}`), nil, onFailure), ShouldBeNil)
+
+					So(bulkIndexer.Close(testCtx), ShouldBeNil)
+					So(failures, ShouldHaveLength, 2)
+					So(bulkIndexer.Stats().NumFailed, ShouldEqual, 3)
+				})
+			})
+		})
+	})
+}
+
+func TestOpenBulkIndexer(t *testing.T) {
+	testCtx := context.Background()
+	resBody := `{"took":1,"errors":false,"items":[{"index":{"_id":"1","status":201,"result":"created"}}]}`
+
+	Convey("Given a client with two named bulk indexers for different indices", t, func() {
+		var mu sync.Mutex
+		var paths []string
+		recordRequest := func(req *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			paths = append(paths, req.URL.Path)
+		}
+		mockClient := newMockClient(http.StatusOK, resBody, recordRequest)
+		testClient := &Client{osClient: mockClient, noRetryClient: mockClient}
+
+		products, err := testClient.OpenBulkIndexer(testCtx, "products", &client.BulkIndexerConfig{Index: "products"})
+		So(err, ShouldBeNil)
+		orders, err := testClient.OpenBulkIndexer(testCtx, "orders", &client.BulkIndexerConfig{Index: "orders"})
+		So(err, ShouldBeNil)
+
+		Convey("When an item is added to each and both are closed", func() {
+			So(products.Add(testCtx, Index, "", "1", []byte(`{}`), nil, nil), ShouldBeNil)
+			So(orders.Add(testCtx, Index, "", "1", []byte(`{}`), nil, nil), ShouldBeNil)
+			So(products.Close(testCtx), ShouldBeNil)
+			So(orders.Close(testCtx), ShouldBeNil)
+
+			Convey("Then each indexer flushes its own item with its own settings", func() {
+				So(paths, ShouldHaveLength, 2)
+				So(paths, ShouldContain, "/products/_bulk")
+				So(paths, ShouldContain, "/orders/_bulk")
+				So(products.Stats().NumFlushed, ShouldEqual, 1)
+				So(orders.Stats().NumFlushed, ShouldEqual, 1)
+			})
+
+			Convey("Then adding to a closed indexer returns an error", func() {
+				err := products.Add(testCtx, Index, "", "2", []byte(`{}`), nil, nil)
+				So(errors.Is(err, client.ErrBulkIndexerClosed), ShouldBeTrue)
+			})
+		})
+
+		Convey("When an indexer is opened with the name of one that is open", func() {
+			_, err := testClient.OpenBulkIndexer(testCtx, "products", nil)
+
+			Convey("Then an error is returned", func() {
+				So(errors.Is(err, client.ErrBulkIndexerOpen), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the default indexer is created twice", func() {
+			So(testClient.NewBulkIndexer(testCtx, nil), ShouldBeNil)
+			err := testClient.NewBulkIndexer(testCtx, nil)
+
+			Convey("Then the second is rejected rather than replacing the first", func() {
+				So(errors.Is(err, client.ErrBulkIndexerOpen), ShouldBeTrue)
+			})
+		})
+
+		Convey("When an indexer is opened without a name", func() {
+			_, err := testClient.OpenBulkIndexer(testCtx, "", nil)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
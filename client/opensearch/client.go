@@ -0,0 +1,1499 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/internal/clientutil"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	opensearchv2 "github.com/opensearch-project/opensearch-go/v2"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+)
+
+const (
+	bulkIndexerClientShouldNotBeNilErrMsg = "bulk indexer client should not be nil"
+)
+
+type Client struct {
+	bulkIndexers  clientutil.BulkIndexerRegistry
+	osClient      *opensearchv2.Client
+	noRetryClient *opensearchv2.Client
+	retryPolicy   client.RetryPolicy
+	indexes       []string
+	address       string
+}
+
+// NewClient returns a new OpenSearch client
+func NewClient(osURL string, transport http.RoundTripper) (*Client, error) {
+	return NewClientWithConfig(client.Config{
+		Address:   osURL,
+		Transport: transport,
+	})
+}
+
+// NewClientWithConfig returns a new OpenSearch client, retrying failed requests according to the
+// retry settings in cfg. The Checker reports the cluster as critical if any of cfg.Indexes do not exist.
+func NewClientWithConfig(cfg client.Config) (*Client, error) {
+	parsedURL, err := url.ParseRequestURI(cfg.Address)
+	if err != nil {
+		return nil, errors.New("failed to specify valid opensearch url")
+	}
+
+	retryPolicy := client.NewRetryPolicy(cfg)
+
+	newClient, err := opensearchv2.NewClient(opensearchv2.Config{
+		Addresses:     []string{parsedURL.String()},
+		Transport:     cfg.Transport,
+		MaxRetries:    retryPolicy.MaxRetries,
+		DisableRetry:  retryPolicy.Disabled(),
+		RetryOnStatus: retryPolicy.RetryOnStatus,
+		RetryBackoff:  retryPolicy.Backoff,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests which are not safe to replay are sent without the transport retries, see doWithoutReplay. Its
+	// transport records why bulk requests fail as a whole, which the bulk indexers report for their items.
+	noRetryClient, err := opensearchv2.NewClient(opensearchv2.Config{
+		Addresses:    []string{parsedURL.String()},
+		Transport:    clientutil.NewFlushTransport(cfg.Transport, "opensearch"),
+		DisableRetry: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		osClient:      newClient,
+		noRetryClient: noRetryClient,
+		retryPolicy:   retryPolicy,
+		indexes:       cfg.Indexes,
+		address:       parsedURL.String(),
+	}, nil
+}
+
+// GetAlias returns a list of indices.
+func (cli *Client) GetAlias(ctx context.Context) ([]byte, error) {
+	res, err := cli.osClient.Indices.GetAlias(cli.osClient.Indices.GetAlias.WithContext(ctx))
+	if err != nil {
+		return nil, esError.StatusError{Err: err, Code: getStatusCode(res)}
+	}
+	defer res.Body.Close()
+
+	if err = checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to retrieve aliases: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return data, nil
+}
+
+// GetIndices returns information about one or more indices.
+func (cli *Client) GetIndices(ctx context.Context, indexPatterns []string) ([]byte, error) {
+	res, err := cli.osClient.Indices.Get(indexPatterns, cli.osClient.Indices.Get.WithContext(ctx))
+	if err != nil {
+		return nil, esError.StatusError{Err: err, Code: getStatusCode(res)}
+	}
+	defer res.Body.Close()
+
+	if err = checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to retrieve indices: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return data, nil
+}
+
+// CreateIndex creates an index with optional settings and mappings.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/index-apis/create-index/.
+func (cli *Client) CreateIndex(ctx context.Context, indexName string, indexSettings []byte) error {
+	res, err := cli.osClient.Indices.Create(
+		indexName,
+		cli.osClient.Indices.Create.WithBody(bytes.NewReader(indexSettings)),
+		cli.osClient.Indices.Create.WithContext(ctx),
+	)
+	if err != nil {
+		return esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to create index: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return nil
+}
+
+// DeleteIndex deletes an index.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/index-apis/delete-index/.
+func (cli *Client) DeleteIndex(ctx context.Context, indexName string) error {
+	return cli.DeleteIndices(ctx, []string{indexName})
+}
+
+// DeleteIndices deletes one or more indices.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/index-apis/delete-index/.
+func (cli *Client) DeleteIndices(ctx context.Context, indices []string) error {
+	res, err := cli.osClient.Indices.Delete(indices, cli.osClient.Indices.Delete.WithContext(ctx))
+	if err != nil {
+		return esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to delete index: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return nil
+}
+
+// Count returns number of documents matching a query.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/count/.
+func (cli *Client) Count(ctx context.Context, count client.Count) ([]byte, error) {
+	req := opensearchapi.CountRequest{
+		Body: bytes.NewReader(count.Query),
+	}
+
+	res, err := req.Do(ctx, cli.osClient)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err = checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to count indicies: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return data, nil
+}
+
+// CountIndices returns number of documents in the given indices.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/count/.
+func (cli *Client) CountIndices(ctx context.Context, indices []string) ([]byte, error) {
+	req := opensearchapi.CountRequest{
+		Index: indices,
+	}
+
+	res, err := req.Do(ctx, cli.osClient)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err = checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to count indices: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return data, nil
+}
+
+// AddDocument adds a document to the index specified. By default the document is created, failing if it
+// already exists. With the Upsert option any existing document is replaced, or with UpsertMerge the document
+// is merged into the existing document.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/document-apis/index-document/.
+func (cli *Client) AddDocument(ctx context.Context, indexName, documentID string, document []byte, options *client.AddDocumentOptions) (*client.WriteResult, error) {
+	if options == nil {
+		options = &client.AddDocumentOptions{}
+	}
+
+	var res *opensearchapi.Response
+	var err error
+	switch {
+	case options.Upsert && options.UpsertMode == client.UpsertMerge:
+		if options.Pipeline != "" {
+			return nil, esError.StatusError{
+				Err: errors.New("pipeline option cannot be used with a merge upsert"),
+			}
+		}
+
+		return cli.UpdateDocument(ctx, indexName, documentID, client.DocumentUpdate{Doc: document, DocAsUpsert: true}, &client.UpdateDocumentOptions{
+			Refresh:            options.Refresh,
+			Routing:            options.Routing,
+			ConcurrencyControl: options.ConcurrencyControl,
+		})
+	case options.Upsert:
+		req := opensearchapi.IndexRequest{
+			Index:         indexName,
+			DocumentID:    documentID,
+			OpType:        "index",
+			Refresh:       string(options.Refresh),
+			Routing:       options.Routing,
+			Pipeline:      options.Pipeline,
+			IfSeqNo:       intPtr(options.IfSeqNo),
+			IfPrimaryTerm: intPtr(options.IfPrimaryTerm),
+			Version:       intPtr(options.Version),
+			VersionType:   options.VersionTypeOrDefault(),
+		}
+		newRequest := func() opensearchapi.Request {
+			req.Body = bytes.NewReader(document)
+			return req
+		}
+
+		if options.ConcurrencyControl.IsSet() {
+			res, err = cli.doWithoutReplay(ctx, newRequest)
+		} else {
+			res, err = newRequest().Do(ctx, cli.osClient)
+		}
+	default:
+		if options.IfSeqNo != nil || options.IfPrimaryTerm != nil {
+			return nil, esError.StatusError{
+				Err: errors.New("if_seq_no and if_primary_term cannot be used when creating a document, use the upsert option"),
+			}
+		}
+
+		req := opensearchapi.CreateRequest{
+			Index:       indexName,
+			DocumentID:  documentID,
+			Refresh:     string(options.Refresh),
+			Routing:     options.Routing,
+			Pipeline:    options.Pipeline,
+			Version:     intPtr(options.Version),
+			VersionType: options.VersionTypeOrDefault(),
+		}
+		res, err = cli.doWithoutReplay(ctx, func() opensearchapi.Request {
+			req.Body = bytes.NewReader(document)
+			return req
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to add document: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return decodeWriteResult(res)
+}
+
+// DeleteDocument deletes a document from the given index using the document ID (e.g. URI).
+// See full documentation at https://opensearch.org/docs/latest/api-reference/document-apis/delete-document/.
+func (cli *Client) DeleteDocument(ctx context.Context, indexName, documentID string, options *client.DeleteDocumentOptions) (*client.WriteResult, error) {
+	if options == nil {
+		options = &client.DeleteDocumentOptions{}
+	}
+
+	req := opensearchapi.DeleteRequest{
+		Index:         indexName,
+		DocumentID:    documentID,
+		Refresh:       string(options.Refresh),
+		Routing:       options.Routing,
+		IfSeqNo:       intPtr(options.IfSeqNo),
+		IfPrimaryTerm: intPtr(options.IfPrimaryTerm),
+		Version:       intPtr(options.Version),
+		VersionType:   options.VersionTypeOrDefault(),
+	}
+
+	var res *opensearchapi.Response
+	var err error
+	if options.ConcurrencyControl.IsSet() {
+		res, err = cli.doWithoutReplay(ctx, func() opensearchapi.Request { return req })
+	} else {
+		res, err = req.Do(ctx, cli.osClient)
+	}
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to send delete request: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("delete request failed: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return decodeWriteResult(res)
+}
+
+// UpdateDocument updates a document, either merging update.Doc into it or running update.Script against it.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/document-apis/update-document/.
+func (cli *Client) UpdateDocument(ctx context.Context, indexName, documentID string, update client.DocumentUpdate, options *client.UpdateDocumentOptions) (*client.WriteResult, error) {
+	if options == nil {
+		options = &client.UpdateDocumentOptions{}
+	}
+
+	if options.Version != nil {
+		return nil, esError.StatusError{
+			Err: errors.New("external versioning cannot be used when updating a document, use if_seq_no and if_primary_term"),
+		}
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build update request: %w", err),
+		}
+	}
+
+	req := opensearchapi.UpdateRequest{
+		Index:           indexName,
+		DocumentID:      documentID,
+		Refresh:         string(options.Refresh),
+		Routing:         options.Routing,
+		RetryOnConflict: options.RetryOnConflict,
+		IfSeqNo:         intPtr(options.IfSeqNo),
+		IfPrimaryTerm:   intPtr(options.IfPrimaryTerm),
+	}
+	newRequest := func() opensearchapi.Request {
+		req.Body = bytes.NewReader(body)
+		return req
+	}
+
+	// A script may not be idempotent, such as one incrementing a counter, and a conditional update that was
+	// applied would fail with a conflict if replayed, so neither is replayed after an ambiguous failure
+	var res *opensearchapi.Response
+	if update.Script != nil || options.IfSeqNo != nil || options.IfPrimaryTerm != nil {
+		res, err = cli.doWithoutReplay(ctx, newRequest)
+	} else {
+		res, err = newRequest().Do(ctx, cli.osClient)
+	}
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to update document: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return decodeWriteResult(res)
+}
+
+// UpdateByQuery updates every document in the given indices that matches a query, running the script of the update
+// against each one. With WaitForCompletion set to false the update runs as a task and only its ID is returned.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/document-apis/update-by-query/.
+func (cli *Client) UpdateByQuery(ctx context.Context, indices []string, update client.UpdateByQuery, options *client.UpdateByQueryOptions) (*client.BulkByScrollResponse, error) {
+	if options == nil {
+		options = &client.UpdateByQueryOptions{}
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build update by query request: %w", err),
+		}
+	}
+
+	req := opensearchapi.UpdateByQueryRequest{
+		Index:             indices,
+		WaitForCompletion: options.WaitForCompletion,
+		RequestsPerSecond: options.RequestsPerSecond,
+		MaxDocs:           options.MaxDocs,
+		Routing:           options.Routing,
+	}
+	if options.ProceedOnConflicts {
+		req.Conflicts = "proceed"
+	}
+	if options.Slices != "" {
+		req.Slices = options.Slices
+	}
+	if options.Refresh {
+		req.Refresh = &options.Refresh
+	}
+
+	// Every matching document is updated again if the request is replayed, running the script twice, so it is not
+	// replayed after an ambiguous failure
+	res, err := cli.doWithoutReplay(ctx, func() opensearchapi.Request {
+		req.Body = bytes.NewReader(body)
+		return req
+	})
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("update-by-query failed: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var result client.BulkByScrollResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode update by query response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &result, nil
+}
+
+// GetDocument gets a document by ID. If the document does not exist the result has Found set to false.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/document-apis/get-documents/.
+func (cli *Client) GetDocument(ctx context.Context, indexName, documentID string, options *client.GetDocumentOptions) (*client.GetResult, error) {
+	if options == nil {
+		options = &client.GetDocumentOptions{}
+	}
+
+	res, err := opensearchapi.GetRequest{
+		Index:          indexName,
+		DocumentID:     documentID,
+		Routing:        options.Routing,
+		Realtime:       options.Realtime,
+		SourceIncludes: options.SourceIncludes,
+		SourceExcludes: options.SourceExcludes,
+	}.Do(ctx, cli.osClient)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	// A missing document is a 404 with the found flag unset, whereas a missing index is a 404 error response
+	if res.IsError() {
+		if esErr := esError.NewESError("opensearch", res.StatusCode, data); res.StatusCode != http.StatusNotFound || esErr.Type != "" {
+			return nil, esError.StatusError{
+				Err:  fmt.Errorf("error occured while trying to get document: %w", esErr),
+				Code: getStatusCode(res),
+			}
+		}
+	}
+
+	var result client.GetResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode get response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &result, nil
+}
+
+// MultiGet gets several documents by ID, which may be in different indices, in a single request.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/document-apis/multi-get/.
+func (cli *Client) MultiGet(ctx context.Context, docs []client.MultiGetDocument, options *client.MultiGetOptions) (*client.MultiGetResponse, error) {
+	if options == nil {
+		options = &client.MultiGetOptions{}
+	}
+
+	body, err := json.Marshal(struct {
+		Docs []client.MultiGetDocument `json:"docs"`
+	}{Docs: docs})
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build multi get request: %w", err),
+		}
+	}
+
+	res, err := opensearchapi.MgetRequest{
+		Body:           bytes.NewReader(body),
+		Realtime:       options.Realtime,
+		SourceIncludes: options.SourceIncludes,
+		SourceExcludes: options.SourceExcludes,
+	}.Do(ctx, cli.osClient)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to multi get documents: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var result client.MultiGetResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode multi get response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &result, nil
+}
+
+// DeleteDocumentByQuery deletes documents from the given index using the provided search query.
+func (cli *Client) DeleteDocumentByQuery(ctx context.Context, search client.Search) error {
+	req := opensearchapi.DeleteByQueryRequest{
+		Index: []string{search.Header.Index},
+		Body:  bytes.NewReader(search.Query),
+	}
+
+	res, err := req.Do(ctx, cli.osClient)
+	if err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("failed to send delete-by-query request: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("delete-by-query failed: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return nil
+}
+
+// Explain returns information about why a specific document matches (or doesn't match) a query.
+func (cli *Client) Explain(ctx context.Context, documentID string, search client.Search) ([]byte, error) {
+	req := opensearchapi.ExplainRequest{
+		Index:      search.Header.Index,
+		DocumentID: documentID,
+		Body:       bytes.NewReader(search.Query),
+	}
+
+	res, err := req.Do(ctx, cli.osClient)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err = checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to call explain api: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	return data, nil
+}
+
+// Search returns results matching a query.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/search/.
+func (cli *Client) Search(ctx context.Context, search client.Search) ([]byte, error) {
+	req := opensearchapi.SearchRequest{
+		Body: bytes.NewReader(search.Query),
+	}
+	// A search of a point in time must not name an index
+	if search.Header.Index != "" {
+		req.Index = []string{search.Header.Index}
+	}
+
+	res, err := req.Do(ctx, cli.osClient)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err = checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to search documents: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return data, nil
+}
+
+// MultiSearch allows to execute several search operations in one request.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/multi-search/.
+func (cli *Client) MultiSearch(ctx context.Context, searches []client.Search, queryParams *client.QueryParams) ([]byte, error) {
+	body, err := convertToMultilineSearches(searches)
+	if err != nil {
+		return nil, err
+	}
+	req := opensearchapi.MsearchRequest{
+		Body: bytes.NewReader(body),
+	}
+	if queryParams != nil && queryParams.EnableTotalHitsCounter != nil {
+		req.RestTotalHitsAsInt = queryParams.EnableTotalHitsCounter
+	}
+
+	res, err := req.Do(ctx, cli.osClient)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err = checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to multi search documents: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return data, nil
+}
+
+// UpdateAliases atomically removes an alias from removeIndices and adds it to addIndices.
+func (cli *Client) UpdateAliases(ctx context.Context, alias string, removeIndices, addIndices []string) error {
+	return cli.UpdateAliasActions(ctx, client.AliasActions(alias, removeIndices, addIndices))
+}
+
+// UpdateAliasActions performs the alias actions in a single atomic request, so that either all or none of them
+// are applied. See full documentation at https://opensearch.org/docs/latest/api-reference/index-apis/alias/.
+func (cli *Client) UpdateAliasActions(ctx context.Context, actions []client.AliasAction) error {
+	update, err := clientutil.NewAliasActionsBody(actions)
+	if err != nil {
+		return esError.StatusError{
+			Err: fmt.Errorf("failed to build alias actions: %w", err),
+		}
+	}
+
+	res, err := cli.osClient.Indices.UpdateAliases(bytes.NewReader(update), cli.osClient.Indices.UpdateAliases.WithContext(ctx))
+	if err != nil {
+		return esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to update aliases: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var result client.UpdateAliasesResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("failed to decode update aliases response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	if err := result.Err(); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to update aliases: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return nil
+}
+
+// BulkUpdate allows to perform multiple index/update/delete operations in a single request.
+// The osURL parameter is not used to send the request, which always goes to the address the client was created with,
+// and an error is returned if it is set to a different address.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/document-apis/bulk/.
+//
+// Deprecated: the payload must be assembled by hand and the raw response is returned even if items failed.
+// Use Bulk with actions added to a client.BulkRequestBuilder, which splits them across requests and returns the
+// result of every item, instead.
+//
+//nolint:revive // context of osURL is important here.
+func (cli *Client) BulkUpdate(ctx context.Context, indexName, osURL string, payload []byte) ([]byte, error) {
+	if err := clientutil.CheckAddress(cli.address, osURL); err != nil {
+		return nil, err
+	}
+
+	// The payload may hold create actions, so it is not replayed after an ambiguous failure
+	res, err := cli.doWithoutReplay(ctx, func() opensearchapi.Request {
+		return opensearchapi.BulkRequest{
+			Index: indexName,
+			Body:  bytes.NewReader(payload),
+		}
+	})
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err = checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to bulk update document: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return data, nil
+}
+
+// Bulk sends the actions added to bulk, in one or more requests as split by the builder, and returns the result of
+// every action. If any action failed the response is returned along with an error matching
+// client.ErrBulkItemsFailed, so failures cannot be missed. If a request fails the results of the requests already
+// sent are returned with its error.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/document-apis/bulk/.
+func (cli *Client) Bulk(ctx context.Context, bulk *client.BulkRequestBuilder, options *client.BulkOptions) (*client.BulkResponse, error) {
+	if options == nil {
+		options = &client.BulkOptions{}
+	}
+
+	return clientutil.SendBulkRequests(ctx, bulk, func(ctx context.Context, body []byte) ([]byte, error) {
+		// The actions may include creates, so a request is not replayed after an ambiguous failure
+		res, err := cli.doWithoutReplay(ctx, func() opensearchapi.Request {
+			return opensearchapi.BulkRequest{
+				Index:    options.Index,
+				Body:     bytes.NewReader(body),
+				Refresh:  string(options.Refresh),
+				Pipeline: options.Pipeline,
+				Timeout:  options.Timeout,
+			}
+		})
+		if err != nil {
+			return nil, esError.StatusError{
+				Err:  err,
+				Code: getStatusCode(res),
+			}
+		}
+		defer res.Body.Close()
+
+		if err := checkForError(res); err != nil {
+			return nil, esError.StatusError{
+				Err:  fmt.Errorf("error occured while trying to send bulk request: %w", err),
+				Code: getStatusCode(res),
+			}
+		}
+
+		data, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, esError.StatusError{
+				Err:  err,
+				Code: getStatusCode(res),
+			}
+		}
+
+		return data, nil
+	})
+}
+
+// ClusterHealth returns the health of the cluster, or of the given indices, optionally waiting for a status.
+// If the status is not reached before the timeout the health is returned with TimedOut set, rather than an error.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/cluster-api/cluster-health/.
+func (cli *Client) ClusterHealth(ctx context.Context, indices []string, options *client.ClusterHealthOptions) (*client.ClusterHealthResponse, error) {
+	if options == nil {
+		options = &client.ClusterHealthOptions{}
+	}
+
+	res, err := opensearchapi.ClusterHealthRequest{
+		Index:         indices,
+		WaitForStatus: string(options.WaitForStatus),
+		Timeout:       options.Timeout,
+	}.Do(ctx, cli.osClient)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	// A request timeout is how the cluster reports that the status was not reached
+	if res.StatusCode != http.StatusRequestTimeout {
+		if err := checkForError(res); err != nil {
+			return nil, esError.StatusError{
+				Err:  fmt.Errorf("error occured while trying to get cluster health: %w", err),
+				Code: getStatusCode(res),
+			}
+		}
+	}
+
+	var health client.ClusterHealthResponse
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode cluster health response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &health, nil
+}
+
+// RefreshIndices refreshes the given indices, making every change to them visible to search.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/index-apis/refresh/.
+func (cli *Client) RefreshIndices(ctx context.Context, indices []string) error {
+	res, err := opensearchapi.IndicesRefreshRequest{Index: indices}.Do(ctx, cli.osClient)
+	if err != nil {
+		return esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to refresh indices: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return nil
+}
+
+// Reindex copies documents from the source of reindex into its destination index on the cluster, which is much
+// faster than reading them through Search and writing them back with the bulk indexer. With WaitForCompletion set
+// to false the reindex runs as a task and only its ID is returned.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/document-apis/reindex/.
+func (cli *Client) Reindex(ctx context.Context, reindex client.Reindex, options *client.ReindexOptions) (*client.BulkByScrollResponse, error) {
+	if options == nil {
+		options = &client.ReindexOptions{}
+	}
+
+	body, err := json.Marshal(reindex)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build reindex request: %w", err),
+		}
+	}
+
+	req := opensearchapi.ReindexRequest{
+		WaitForCompletion: options.WaitForCompletion,
+		RequestsPerSecond: options.RequestsPerSecond,
+		MaxDocs:           options.MaxDocs,
+	}
+	if options.Slices != "" {
+		req.Slices = options.Slices
+	}
+	if options.Refresh {
+		req.Refresh = &options.Refresh
+	}
+
+	// A replayed reindex would run alongside the first into the same destination, such as when a proxy times out
+	// a long synchronous reindex with a 504, so it is not replayed after an ambiguous failure
+	res, err := cli.doWithoutReplay(ctx, func() opensearchapi.Request {
+		req.Body = bytes.NewReader(body)
+		return req
+	})
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to reindex: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var result client.BulkByScrollResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode reindex response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &result, nil
+}
+
+// GetTask returns the status of a task and, once it has completed, its result.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/tasks/.
+func (cli *Client) GetTask(ctx context.Context, taskID string, options *client.GetTaskOptions) (*client.TaskResponse, error) {
+	if options == nil {
+		options = &client.GetTaskOptions{}
+	}
+
+	req := opensearchapi.TasksGetRequest{
+		TaskID:  taskID,
+		Timeout: options.Timeout,
+	}
+	if options.WaitForCompletion {
+		req.WaitForCompletion = &options.WaitForCompletion
+	}
+
+	res, err := req.Do(ctx, cli.osClient)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to get task: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var task client.TaskResponse
+	if err := json.NewDecoder(res.Body).Decode(&task); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode get task response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &task, nil
+}
+
+// ListTasks lists the tasks running on the cluster.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/tasks/.
+func (cli *Client) ListTasks(ctx context.Context, options *client.ListTasksOptions) (*client.TasksResponse, error) {
+	if options == nil {
+		options = &client.ListTasksOptions{}
+	}
+
+	req := opensearchapi.TasksListRequest{
+		Actions:      options.Actions,
+		ParentTaskID: options.ParentTaskID,
+		GroupBy:      "none",
+	}
+	if options.Detailed {
+		req.Detailed = &options.Detailed
+	}
+
+	res, err := req.Do(ctx, cli.osClient)
+	return cli.decodeTasksResponse(res, err, "list tasks")
+}
+
+// CancelTask cancels a task. Cancellation is asynchronous: the task may still be running when this returns.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/tasks/.
+func (cli *Client) CancelTask(ctx context.Context, taskID string) (*client.TasksResponse, error) {
+	res, err := opensearchapi.TasksCancelRequest{TaskID: taskID}.Do(ctx, cli.osClient)
+	return cli.decodeTasksResponse(res, err, "cancel task")
+}
+
+// RethrottleTask changes the requests per second of a running reindex, update by query or delete by query task.
+// A value of -1 removes the throttling. Speeding a task up takes effect straight away, while slowing it down takes
+// effect after the current batch completes.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/document-apis/reindex/.
+func (cli *Client) RethrottleTask(ctx context.Context, taskID string, requestsPerSecond int) (*client.TasksResponse, error) {
+	// The reindex, update by query and delete by query rethrottle endpoints share one action, which rethrottles
+	// any of the three types of task
+	res, err := opensearchapi.ReindexRethrottleRequest{
+		TaskID:            taskID,
+		RequestsPerSecond: &requestsPerSecond,
+	}.Do(ctx, cli.osClient)
+	return cli.decodeTasksResponse(res, err, "rethrottle task")
+}
+
+// decodeTasksResponse checks and decodes the response to a tasks request, returning any node or task failures
+// as an error
+func (cli *Client) decodeTasksResponse(res *opensearchapi.Response, err error, operation string) (*client.TasksResponse, error) {
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to %s: %w", operation, err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var tasks client.TasksResponse
+	if err := json.NewDecoder(res.Body).Decode(&tasks); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("failed to decode %s response: %w", operation, err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	if err := tasks.Err(); err != nil {
+		return &tasks, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to %s: %w", operation, err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return &tasks, nil
+}
+
+// Scroll runs a search, keeping a scroll context alive for keepAlive so that the following pages of hits can be
+// read with ScrollNext. The response holds the first page of hits and the ID of the scroll, which must be cleared
+// with ClearScroll once it is no longer needed.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/scroll/.
+func (cli *Client) Scroll(ctx context.Context, search client.Search, keepAlive time.Duration) ([]byte, error) {
+	res, err := opensearchapi.SearchRequest{
+		Index:  []string{search.Header.Index},
+		Body:   bytes.NewReader(search.Query),
+		Scroll: keepAlive,
+	}.Do(ctx, cli.osClient)
+	return cli.readScrollResponse(res, err, "open scroll")
+}
+
+// ScrollNext returns the next page of hits of a scroll, extending its keepalive by keepAlive. The page is empty
+// once every hit has been returned.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/scroll/.
+func (cli *Client) ScrollNext(ctx context.Context, scrollID string, keepAlive time.Duration) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"scroll_id": scrollID})
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build scroll request: %w", err),
+		}
+	}
+
+	res, err := opensearchapi.ScrollRequest{
+		Body:   bytes.NewReader(body),
+		Scroll: keepAlive,
+	}.Do(ctx, cli.osClient)
+	return cli.readScrollResponse(res, err, "scroll")
+}
+
+// ClearScroll clears scroll contexts, freeing the resources held by them on the cluster. Scrolls that have
+// already expired are ignored.
+// See full documentation at https://opensearch.org/docs/latest/api-reference/scroll/.
+func (cli *Client) ClearScroll(ctx context.Context, scrollIDs []string) error {
+	body, err := json.Marshal(map[string][]string{"scroll_id": scrollIDs})
+	if err != nil {
+		return esError.StatusError{
+			Err: fmt.Errorf("failed to build clear scroll request: %w", err),
+		}
+	}
+
+	res, err := opensearchapi.ClearScrollRequest{Body: bytes.NewReader(body)}.Do(ctx, cli.osClient)
+	if err != nil {
+		return esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	// Not found is returned when none of the scrolls were found, because they have already expired
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to clear scroll: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return nil
+}
+
+// readScrollResponse checks and reads the response to a scroll request
+func (cli *Client) readScrollResponse(res *opensearchapi.Response, err error, operation string) ([]byte, error) {
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to %s: %w", operation, err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return data, nil
+}
+
+// OpenPointInTime opens a point in time on the given indices, keeping it alive for keepAlive, and returns its ID.
+// Searches using the point in time see the indices as they were when it was opened. It must be closed with
+// ClosePointInTime once it is no longer needed.
+// See full documentation at https://opensearch.org/docs/latest/search-plugins/searching-data/point-in-time-api/.
+func (cli *Client) OpenPointInTime(ctx context.Context, indices []string, keepAlive time.Duration) (string, error) {
+	// The point in time request of opensearch-go decodes the response body before it can be checked for an error,
+	// so the request is performed directly
+	query := url.Values{"keep_alive": {fmt.Sprintf("%dms", keepAlive.Milliseconds())}}
+	path := "/" + strings.Join(indices, ",") + "/_search/point_in_time?" + query.Encode()
+	res, err := cli.perform(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return "", esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	if err := checkForError(res); err != nil {
+		return "", esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to open point in time: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	var pit struct {
+		PitID string `json:"pit_id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&pit); err != nil {
+		return "", esError.StatusError{
+			Err:  fmt.Errorf("failed to decode open point in time response: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return pit.PitID, nil
+}
+
+// ClosePointInTime closes a point in time, freeing the resources held by it on the cluster. A point in time that
+// has already expired is ignored.
+// See full documentation at https://opensearch.org/docs/latest/search-plugins/searching-data/point-in-time-api/.
+func (cli *Client) ClosePointInTime(ctx context.Context, pitID string) error {
+	body, err := json.Marshal(map[string][]string{"pit_id": {pitID}})
+	if err != nil {
+		return esError.StatusError{
+			Err: fmt.Errorf("failed to build close point in time request: %w", err),
+		}
+	}
+
+	res, err := cli.perform(ctx, http.MethodDelete, "/_search/point_in_time", body)
+	if err != nil {
+		return esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+	defer res.Body.Close()
+
+	// Not found is returned when the point in time has already expired
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if err := checkForError(res); err != nil {
+		return esError.StatusError{
+			Err:  fmt.Errorf("error occured while trying to close point in time: %w", err),
+			Code: getStatusCode(res),
+		}
+	}
+
+	return nil
+}
+
+// perform sends a request that has no equivalent in opensearchapi through the client's transport
+func (cli *Client) perform(ctx context.Context, method, path string, body []byte) (*opensearchapi.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := cli.osClient.Perform(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &opensearchapi.Response{
+		StatusCode: res.StatusCode,
+		Body:       res.Body,
+		Header:     res.Header,
+	}, nil
+}
+
+// NewBulkIndexer creates the default bulk indexer of the client, used by BulkIndexAdd, BulkIndexClose and
+// BulkIndexStats, configured by cfg. A nil cfg uses the defaults. An error is returned if the default bulk
+// indexer is still open.
+func (cli *Client) NewBulkIndexer(ctx context.Context, cfg *client.BulkIndexerConfig) error {
+	_, err := cli.openBulkIndexer(ctx, "", cfg)
+	return err
+}
+
+// OpenBulkIndexer creates a bulk indexer configured by cfg and registers it under name, returning it. A nil cfg
+// uses the defaults. Each indexer opened is independent, so that separate indexers can be run at once, for
+// example one per target index with different settings. An error is returned if name is empty, as that is the
+// default bulk indexer created by NewBulkIndexer, or if the indexer registered under name is still open.
+func (cli *Client) OpenBulkIndexer(ctx context.Context, name string, cfg *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+	if name == "" {
+		return nil, esError.StatusError{
+			Err: errors.New("bulk indexer name should not be empty"),
+		}
+	}
+
+	return cli.openBulkIndexer(ctx, name, cfg)
+}
+
+func (cli *Client) openBulkIndexer(ctx context.Context, name string, cfg *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+	return cli.bulkIndexers.Open(name, func() (client.BulkIndexer, error) {
+		bulkIndexer, err := clientutil.WrapBulkIndexer(ctx, cfg, func(cfg client.BulkIndexerConfig) (clientutil.Indexer, error) {
+			// A replayed request would apply its items twice, reporting creates that succeeded as conflicts, so the
+			// requests are not retried by the transport. Items rejected with a 429 or 503 are retried by the wrapper.
+			bulkIndexer, err := newBulkIndexer(cli.noRetryClient, &cfg)
+			if err != nil {
+				return nil, err
+			}
+
+			return bulkIndexer, nil
+		})
+		if err != nil {
+			return nil, esError.StatusError{
+				Err:  err,
+				Code: http.StatusInternalServerError,
+			}
+		}
+
+		return bulkIndexer, nil
+	})
+}
+
+// defaultBulkIndexer returns the bulk indexer created by NewBulkIndexer
+func (cli *Client) defaultBulkIndexer() (client.BulkIndexer, error) {
+	bulkIndexer, ok := cli.bulkIndexers.Get("")
+	if !ok {
+		return nil, esError.StatusError{
+			Err:  errors.New(bulkIndexerClientShouldNotBeNilErrMsg),
+			Code: http.StatusInternalServerError,
+		}
+	}
+
+	return bulkIndexer, nil
+}
+
+// BulkIndexAdd Add adds an item to the default indexer. It returns an error when the item cannot be added.
+// Use the OnSuccess and OnFailure callbacks to get the operation result for the item.
+//
+// You must call the Close() method after you're done adding items.
+//
+// It is safe for concurrent use. When it's called from goroutines,
+// they must finish before the call to Close, eg. using sync.WaitGroup.
+func (cli *Client) BulkIndexAdd(
+	ctx context.Context,
+	action client.BulkIndexerAction,
+	index,
+	documentID string,
+	document []byte,
+	onSuccess client.SuccessFunc,
+	onFailure client.FailureFunc,
+) error {
+	bulkIndexer, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return err
+	}
+
+	return bulkIndexer.Add(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+// BulkIndexTryAdd adds an item to the default indexer as BulkIndexAdd does, except that it returns an error
+// matching client.ErrBulkIndexerFull straight away, rather than waiting, when the buffer of the indexer is full.
+func (cli *Client) BulkIndexTryAdd(
+	ctx context.Context,
+	action client.BulkIndexerAction,
+	index,
+	documentID string,
+	document []byte,
+	onSuccess client.SuccessFunc,
+	onFailure client.FailureFunc,
+) error {
+	bulkIndexer, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return err
+	}
+
+	return bulkIndexer.TryAdd(ctx, action, index, documentID, document, onSuccess, onFailure)
+}
+
+// Close waits until all added items are flushed and closes the default indexer.
+func (cli *Client) BulkIndexClose(ctx context.Context) error {
+	bulkIndexer, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return err
+	}
+
+	return bulkIndexer.Close(ctx)
+}
+
+// BulkIndexStats returns the counts of items handled by the default bulk indexer since it was created.
+func (cli *Client) BulkIndexStats(_ context.Context) (client.BulkIndexerStats, error) {
+	bulkIndexer, err := cli.defaultBulkIndexer()
+	if err != nil {
+		return client.BulkIndexerStats{}, err
+	}
+
+	return bulkIndexer.Stats(), nil
+}
+
+func convertToMultilineSearches(searches []client.Search) (body []byte, err error) {
+	for _, search := range searches {
+		headerByte, err := json.Marshal(search.Header)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, headerByte...)
+		body = append(body, '\n')
+		body = append(body, search.Query...)
+		body = append(body, '\n')
+	}
+	return body, nil
+}
+
+// getStatusCode returns the response StatusCode, or 0 if res is nil
+func getStatusCode(res *opensearchapi.Response) int {
+	if res == nil {
+		return 0
+	}
+	return res.StatusCode
+}
+
+// decodeWriteResult decodes the response to a single document write
+func decodeWriteResult(res *opensearchapi.Response) (*client.WriteResult, error) {
+	result, err := clientutil.DecodeWriteResult(res.Body)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  err,
+			Code: getStatusCode(res),
+		}
+	}
+
+	return result, nil
+}
+
+// intPtr converts an optional int64 option into the optional int used by the request structs
+func intPtr(i *int64) *int {
+	if i == nil {
+		return nil
+	}
+
+	v := int(*i)
+	return &v
+}
+
+// checkForError checks if the provided opensearch response contains an error.
+// if it does, it is read and returned as an *esError.ESError
+func checkForError(res *opensearchapi.Response) error {
+	if res == nil {
+		return errors.New("nil opensearch api response")
+	}
+
+	if !res.IsError() {
+		return nil
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read opensearch response body for an error case: %w", err)
+	}
+
+	return esError.NewESError("opensearch", res.StatusCode, resBody)
+}
@@ -0,0 +1,184 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// MsgHealthy Check message returned when opensearch is healthy and the required indexes exist
+const MsgHealthy = "opensearch is healthy and the required indexes exist"
+
+// HealthStatus - iota enum of possible health states returned by OpenSearch API
+type HealthStatus int
+
+// Possible values for the HealthStatus
+const (
+	HealthGreen = iota
+	HealthYellow
+	HealthRed
+)
+
+var healthValues = []string{"green", "yellow", "red"}
+
+func (hs HealthStatus) String() string {
+	return healthValues[hs]
+}
+
+// List of errors
+var (
+	ErrorUnexpectedStatusCode   = errors.New("unexpected status code from api")
+	ErrorParsingBody            = errors.New("error parsing cluster health response body")
+	ErrorClusterAtRisk          = errors.New("opensearch cluster state yellow but functional. Data might be at risk, check your replica shards")
+	ErrorUnhealthyClusterStatus = errors.New("error cluster health red. Cluster is unhealthy")
+	ErrorInvalidHealthStatus    = errors.New("error invalid health status returned")
+	ErrorIndexDoesNotExist      = errors.New("error index does not exist in cluster")
+	ErrorInternalServer         = errors.New("error internal server error")
+)
+
+// ClusterHealth represents the response from the opensearch cluster health check
+type ClusterHealth struct {
+	Status string `json:"status"`
+}
+
+// Checker checks health of OpenSearch, if the required indexes exist and updates the provided CheckState accordingly.
+func (cli *Client) Checker(ctx context.Context, state *health.CheckState) error {
+	if state == nil {
+		state = &health.CheckState{}
+	}
+
+	statusCode, err := cli.healthcheck(ctx)
+	if err != nil && err != ErrorClusterAtRisk {
+		if updateErr := state.Update(health.StatusCritical, err.Error(), statusCode); updateErr != nil {
+			log.Warn(ctx, "unable to update health state", log.FormatErrors([]error{updateErr}))
+		}
+
+		return nil
+	}
+
+	if len(cli.indexes) > 0 {
+		if indexStatusCode, indexErr := cli.indexcheck(ctx); indexErr != nil {
+			if updateErr := state.Update(health.StatusCritical, indexErr.Error(), indexStatusCode); updateErr != nil {
+				log.Warn(ctx, "unable to update health state", log.FormatErrors([]error{updateErr}))
+			}
+
+			return nil
+		}
+	}
+
+	// OpenSearch cluster configuration should not determine if the health check should fail
+	// The application will still be able to communicate to the opensearch cluster - hence opensearch
+	// responding with 200 staus code in response
+	if err == ErrorClusterAtRisk {
+		if updateErr := state.Update(health.StatusOK, err.Error(), statusCode); updateErr != nil {
+			log.Warn(ctx, "unable to update health state", log.FormatErrors([]error{updateErr}))
+		}
+
+		return nil
+	}
+
+	if updateErr := state.Update(health.StatusOK, MsgHealthy, statusCode); updateErr != nil {
+		log.Warn(ctx, "unable to update health state", log.FormatErrors([]error{updateErr}))
+	}
+
+	return nil
+}
+
+// healthcheck calls opensearch to check its health status. This call implements only the logic,
+// without providing the Check object, and it's aimed for internal use.
+func (cli *Client) healthcheck(ctx context.Context) (code int, err error) {
+	resp, err := cli.osClient.Cluster.Health()
+	if err != nil {
+		log.Error(ctx, "failed to call opensearch", err)
+		return 500, err
+	}
+	defer resp.Body.Close()
+
+	logData := log.Data{"http_code": resp.StatusCode}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
+		log.Error(ctx, "unexpected status code returned in response", ErrorUnexpectedStatusCode)
+		return resp.StatusCode, ErrorUnexpectedStatusCode
+	}
+
+	jsonBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error(ctx, "failed to read response body from call to opensearch", err)
+		return resp.StatusCode, ErrorUnexpectedStatusCode
+	}
+
+	var clusterHealth ClusterHealth
+	err = json.Unmarshal(jsonBody, &clusterHealth)
+	if err != nil {
+		log.Error(ctx, "json unmarshal error", ErrorParsingBody)
+		return resp.StatusCode, ErrorParsingBody
+	}
+
+	logData["cluster_health"] = clusterHealth.Status
+	switch clusterHealth.Status {
+	case healthValues[HealthGreen]:
+		return resp.StatusCode, nil
+	case healthValues[HealthYellow]:
+		log.Error(ctx, "yellow health status", ErrorClusterAtRisk)
+		return resp.StatusCode, ErrorClusterAtRisk
+	case healthValues[HealthRed]:
+		log.Error(ctx, "red health status", ErrorUnhealthyClusterStatus)
+		return resp.StatusCode, ErrorUnhealthyClusterStatus
+	default:
+		log.Error(ctx, "invalid health status", ErrorInvalidHealthStatus)
+	}
+
+	return resp.StatusCode, ErrorInvalidHealthStatus
+}
+
+// indexcheck calls opensearch to check if the required indexes, or aliases, from the client exist.
+// Cluster health for a missing index blocks until the request times out and returns a 408, so the
+// index exists API is used instead.
+func (cli *Client) indexcheck(ctx context.Context) (int, error) {
+	// Check handles each index, making sure the response body is always closed
+	check := func(index string) (int, error) {
+		resp, err := cli.osClient.Indices.Exists([]string{index}, cli.osClient.Indices.Exists.WithContext(ctx))
+		if err != nil {
+			log.Error(ctx, "failed to call opensearch", err)
+			return 500, err
+		}
+		defer resp.Body.Close()
+
+		switch resp.StatusCode {
+		case 200:
+			return 200, nil
+		case 404:
+			log.Error(ctx, "index does not exist", ErrorIndexDoesNotExist, log.Data{"index": index})
+			return resp.StatusCode, ErrorIndexDoesNotExist
+		default:
+			log.Error(ctx, "unexpected status code returned in response", ErrorUnexpectedStatusCode)
+			return resp.StatusCode, ErrorUnexpectedStatusCode
+		}
+	}
+
+	// Check all indexes so that every missing index is reported. Any other failure is returned straight away.
+	var missing []string
+	for _, index := range cli.indexes {
+		code, err := check(index)
+		if errors.Is(err, ErrorIndexDoesNotExist) {
+			missing = append(missing, index)
+			continue
+		}
+		if err != nil {
+			return code, err
+		}
+	}
+
+	if len(missing) > 0 {
+		return http.StatusNotFound, fmt.Errorf("%w: %s", ErrorIndexDoesNotExist, strings.Join(missing, ", "))
+	}
+
+	// if all indexes are successful, return 200 and no error
+	return 200, nil
+}
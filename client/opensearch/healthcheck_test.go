@@ -0,0 +1,76 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newClusterTransport returns a transport for a green cluster holding the given indexes or aliases
+func newClusterTransport(existing ...string) *mockRoundTripper {
+	return &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			statusCode := http.StatusOK
+			if req.Method == http.MethodHead {
+				index := strings.TrimPrefix(req.URL.Path, "/")
+				statusCode = http.StatusNotFound
+				for _, name := range existing {
+					if name == index {
+						statusCode = http.StatusOK
+					}
+				}
+			}
+
+			return &http.Response{
+				StatusCode: statusCode,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"green"}`)),
+				Header:     make(http.Header),
+			}
+		},
+	}
+}
+
+func TestCheckerRequiredIndexes(t *testing.T) {
+	Convey("Given a client configured with required indexes and aliases", t, func() {
+		cfg := client.Config{
+			Address: "http://localhost:9200",
+			Indexes: []string{"index-1", "missing-1", "search-alias", "missing-2"},
+		}
+
+		Convey("When some of them do not exist and Checker is called", func() {
+			cfg.Transport = newClusterTransport("index-1", "search-alias")
+			cli, err := NewClientWithConfig(cfg)
+			So(err, ShouldBeNil)
+
+			state := health.NewCheckState("elasticsearch")
+			So(cli.Checker(context.Background(), state), ShouldBeNil)
+
+			Convey("Then the check state is critical and names every missing index", func() {
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.StatusCode(), ShouldEqual, http.StatusNotFound)
+				So(state.Message(), ShouldEqual, "error index does not exist in cluster: missing-1, missing-2")
+			})
+		})
+
+		Convey("When all of them exist and Checker is called", func() {
+			cfg.Transport = newClusterTransport("index-1", "missing-1", "search-alias", "missing-2")
+			cli, err := NewClientWithConfig(cfg)
+			So(err, ShouldBeNil)
+
+			state := health.NewCheckState("elasticsearch")
+			So(cli.Checker(context.Background(), state), ShouldBeNil)
+
+			Convey("Then the check state is healthy", func() {
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldEqual, MsgHealthy)
+			})
+		})
+	})
+}
@@ -0,0 +1,213 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newSequenceTransport returns a transport that responds with each status code in turn, recording the request bodies
+func newSequenceTransport(statusCodes []int, bodies *[]string) *mockRoundTripper {
+	return &mockRoundTripper{
+		roundTripFunc: func(req *http.Request) *http.Response {
+			body := []byte{}
+			if req.Body != nil {
+				body, _ = io.ReadAll(req.Body)
+			}
+			*bodies = append(*bodies, string(body))
+
+			statusCode := statusCodes[len(statusCodes)-1]
+			if len(*bodies) <= len(statusCodes) {
+				statusCode = statusCodes[len(*bodies)-1]
+			}
+
+			return &http.Response{
+				StatusCode: statusCode,
+				Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+				Header:     make(http.Header),
+			}
+		},
+	}
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a client with retries configured", t, func() {
+		var bodies []string
+		newClient := func(statusCodes ...int) *Client {
+			cli, err := NewClientWithConfig(client.Config{
+				Address:         "http://localhost:9200",
+				Transport:       newSequenceTransport(statusCodes, &bodies),
+				MaxRetries:      2,
+				MinRetryBackoff: time.Millisecond,
+				MaxRetryBackoff: time.Millisecond,
+			})
+			So(err, ShouldBeNil)
+			return cli
+		}
+
+		Convey("When a search fails with a 503 and then succeeds", func() {
+			_, err := newClient(http.StatusServiceUnavailable, http.StatusOK).Search(ctx, client.Search{Query: []byte(`{}`)})
+
+			Convey("Then the request is retried", func() {
+				So(err, ShouldBeNil)
+				So(bodies, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When a search keeps failing with a 429", func() {
+			_, err := newClient(http.StatusTooManyRequests).Search(ctx, client.Search{Query: []byte(`{}`)})
+
+			Convey("Then the request is attempted MaxRetries more times before failing", func() {
+				So(err, ShouldNotBeNil)
+				So(bodies, ShouldHaveLength, 3)
+			})
+		})
+
+		Convey("When a document is created and the request fails with a 503", func() {
+			_, err := newClient(http.StatusServiceUnavailable, http.StatusCreated).AddDocument(ctx, "my-index", "1", []byte(`{"a":1}`), nil)
+
+			Convey("Then the request is not replayed, as it may have been applied", func() {
+				So(err, ShouldNotBeNil)
+				So(bodies, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When a document is created and the request is rejected with a 429", func() {
+			_, err := newClient(http.StatusTooManyRequests, http.StatusCreated).AddDocument(ctx, "my-index", "1", []byte(`{"a":1}`), nil)
+
+			Convey("Then the request is retried with the same body", func() {
+				So(err, ShouldBeNil)
+				So(bodies, ShouldResemble, []string{`{"a":1}`, `{"a":1}`})
+			})
+		})
+
+		Convey("When a document is updated with a script and the request fails with a 503", func() {
+			update := client.DocumentUpdate{Script: &client.Script{Source: "ctx._source.count += 1"}}
+			_, err := newClient(http.StatusServiceUnavailable, http.StatusOK).UpdateDocument(ctx, "my-index", "1", update, nil)
+
+			Convey("Then the request is not replayed, as the script may already have run", func() {
+				So(err, ShouldNotBeNil)
+				So(bodies, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When a document is updated with if_seq_no and the request fails with a 503", func() {
+			seqNo, primaryTerm := int64(3), int64(1)
+			options := &client.UpdateDocumentOptions{ConcurrencyControl: client.ConcurrencyControl{IfSeqNo: &seqNo, IfPrimaryTerm: &primaryTerm}}
+			_, err := newClient(http.StatusServiceUnavailable, http.StatusOK).UpdateDocument(ctx, "my-index", "1", client.DocumentUpdate{Doc: []byte(`{"a":1}`)}, options)
+
+			Convey("Then the request is not replayed, as it would conflict with itself if it was applied", func() {
+				So(err, ShouldNotBeNil)
+				So(bodies, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When a document is upserted with if_seq_no and the request fails with a 502", func() {
+			seqNo, primaryTerm := int64(3), int64(1)
+			options := &client.AddDocumentOptions{Upsert: true, ConcurrencyControl: client.ConcurrencyControl{IfSeqNo: &seqNo, IfPrimaryTerm: &primaryTerm}}
+			_, err := newClient(http.StatusBadGateway, http.StatusOK).AddDocument(ctx, "my-index", "1", []byte(`{"a":1}`), options)
+
+			Convey("Then the request is not replayed, as it would conflict with itself if it was applied", func() {
+				So(err, ShouldNotBeNil)
+				So(bodies, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When a document is upserted without conditions and the request fails with a 502", func() {
+			_, err := newClient(http.StatusBadGateway, http.StatusOK).AddDocument(ctx, "my-index", "1", []byte(`{"a":1}`), &client.AddDocumentOptions{Upsert: true})
+
+			Convey("Then the request is retried, as indexing the document again changes nothing", func() {
+				So(err, ShouldBeNil)
+				So(bodies, ShouldResemble, []string{`{"a":1}`, `{"a":1}`})
+			})
+		})
+
+		Convey("When a document is deleted with an external version and the request fails with a 504", func() {
+			version := int64(7)
+			options := &client.DeleteDocumentOptions{ConcurrencyControl: client.ConcurrencyControl{Version: &version}}
+			_, err := newClient(http.StatusGatewayTimeout, http.StatusOK).DeleteDocument(ctx, "my-index", "1", options)
+
+			Convey("Then the request is not replayed, as it would conflict with itself if it was applied", func() {
+				So(err, ShouldNotBeNil)
+				So(bodies, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When a document is deleted without conditions and the request fails with a 504", func() {
+			_, err := newClient(http.StatusGatewayTimeout, http.StatusOK).DeleteDocument(ctx, "my-index", "1", nil)
+
+			Convey("Then the request is retried", func() {
+				So(err, ShouldBeNil)
+				So(bodies, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When a document is updated with a partial document and the request fails with a 503", func() {
+			_, err := newClient(http.StatusServiceUnavailable, http.StatusOK).UpdateDocument(ctx, "my-index", "1", client.DocumentUpdate{Doc: []byte(`{"a":1}`)}, nil)
+
+			Convey("Then the request is retried, as merging the document again changes nothing", func() {
+				So(err, ShouldBeNil)
+				So(bodies, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When an update by query fails with a 503", func() {
+			update := client.UpdateByQuery{Script: &client.Script{Source: "ctx._source.count += 1"}}
+			_, err := newClient(http.StatusServiceUnavailable, http.StatusOK).UpdateByQuery(ctx, []string{"my-index"}, update, nil)
+
+			Convey("Then the request is not replayed, as the documents may already have been updated", func() {
+				So(err, ShouldNotBeNil)
+				So(bodies, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When a reindex fails with a 504", func() {
+			reindex := client.Reindex{Source: client.ReindexSource{Index: []string{"old"}}, Dest: client.ReindexDest{Index: "new"}}
+			_, err := newClient(http.StatusGatewayTimeout, http.StatusOK).Reindex(ctx, reindex, nil)
+
+			Convey("Then the request is not replayed, as the reindex may still be running", func() {
+				So(err, ShouldNotBeNil)
+				So(bodies, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When a request of a bulk indexer fails with a 503", func() {
+			cli := newClient(http.StatusServiceUnavailable, http.StatusOK)
+			indexer, err := cli.OpenBulkIndexer(ctx, "retries", &client.BulkIndexerConfig{NumWorkers: 1})
+			So(err, ShouldBeNil)
+			So(indexer.Add(ctx, "create", "my-index", "1", []byte(`{"a":1}`), nil, nil), ShouldBeNil)
+			So(indexer.Close(ctx), ShouldBeNil)
+
+			Convey("Then the request is not replayed, as its items may already have been applied", func() {
+				So(bodies, ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a client with retries disabled", t, func() {
+		var bodies []string
+		cli, err := NewClientWithConfig(client.Config{
+			Address:    "http://localhost:9200",
+			Transport:  newSequenceTransport([]int{http.StatusServiceUnavailable, http.StatusOK}, &bodies),
+			MaxRetries: -1,
+		})
+		So(err, ShouldBeNil)
+
+		Convey("When a search fails with a 503", func() {
+			_, err := cli.Search(ctx, client.Search{Query: []byte(`{}`)})
+
+			Convey("Then the request is not retried", func() {
+				So(err, ShouldNotBeNil)
+				So(bodies, ShouldHaveLength, 1)
+			})
+		})
+	})
+}
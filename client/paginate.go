@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+)
+
+// DefaultPointInTimeKeepAlive is how long a point in time is kept alive between pages by default
+const DefaultPointInTimeKeepAlive = time.Minute
+
+// DefaultPageSize is the number of hits in a page by default
+const DefaultPageSize = 10
+
+// closePointInTimeTimeout bounds how long closing a point in time may take once the context of the paginator is done
+const closePointInTimeTimeout = 10 * time.Second
+
+// ErrInvalidCursor is matched, with errors.Is, by the error returned when a cursor token cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PaginatorOptions are the options for NewPaginator and ResumePaginator. A paginator must be resumed with the same
+// options it was created with, so that each page follows on from the last.
+type PaginatorOptions struct {
+	Query      json.RawMessage   // The query clause, e.g. {"match":{"title":"cpi"}}, defaults to matching every document
+	Sort       []json.RawMessage // The sort, e.g. [{"release_date":"desc"}], defaults to relevance
+	Tiebreaker string            // A field unique to each document, sorted on last so that no two hits tie. Needed for elasticsearch 7.10 and opensearch, which have no implicit tiebreaker
+	Size       int               // The number of hits in a page, defaults to DefaultPageSize
+	KeepAlive  time.Duration     // How long the point in time is kept alive between pages, defaults to DefaultPointInTimeKeepAlive
+}
+
+// Cursor is the position of a paginator: its point in time and the sort values of the last hit returned
+type Cursor struct {
+	PitID       string            `json:"pit"`
+	SearchAfter []json.RawMessage `json:"after,omitempty"`
+}
+
+// Token encodes the cursor as an opaque, URL safe string, so that it can be returned in API responses
+func (c Cursor) Token() string {
+	b, _ := json.Marshal(c) //nolint:errcheck // a cursor always marshals, as its sort values are valid JSON
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseCursor decodes a token returned by Cursor.Token. An error with a bad request status, matching
+// ErrInvalidCursor, is returned if the token is not a valid cursor.
+func ParseCursor(token string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("%w: %v", ErrInvalidCursor, err),
+			Code: http.StatusBadRequest,
+		}
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("%w: %v", ErrInvalidCursor, err),
+			Code: http.StatusBadRequest,
+		}
+	}
+
+	if c.PitID == "" {
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("%w: missing point in time", ErrInvalidCursor),
+			Code: http.StatusBadRequest,
+		}
+	}
+
+	return &c, nil
+}
+
+// Page is a page of hits returned by a Paginator
+type Page[T any] struct {
+	Hits  []Hit[T]
+	Total Total
+	Next  string // The cursor token for the next page, or empty if this is the last page
+}
+
+// Paginator pages through the hits of a search of a point in time using search_after, decoding the source of
+// each hit into T. Unlike a scroll, it can be resumed from a cursor token by another request, so it suits
+// pagination in APIs, and every page reflects the indices as they were when the point in time was opened.
+//
+// The point in time is closed once the last page has been returned. A paginator that is abandoned before then
+// should be closed with Close, or else the point in time is left open until it expires. A Paginator is not safe
+// for concurrent use.
+type Paginator[T any] struct {
+	cli    Client
+	opts   PaginatorOptions
+	cursor Cursor
+	done   bool
+}
+
+// paginatorSearch is the body of the search for a page
+type paginatorSearch struct {
+	Query       json.RawMessage   `json:"query,omitempty"`
+	Size        int               `json:"size"`
+	Sort        []json.RawMessage `json:"sort"`
+	PIT         paginatorPIT      `json:"pit"`
+	SearchAfter []json.RawMessage `json:"search_after,omitempty"`
+}
+
+type paginatorPIT struct {
+	ID        string `json:"id"`
+	KeepAlive string `json:"keep_alive"`
+}
+
+// pageSortValues holds the sort values of the hits of a page, kept raw so that large numbers are not rounded
+type pageSortValues struct {
+	Hits struct {
+		Hits []struct {
+			Sort []json.RawMessage `json:"sort"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// NewPaginator opens a point in time on the given indices and returns a paginator over the hits of the search
+// described by opts, starting at the first page.
+func NewPaginator[T any](ctx context.Context, cli Client, indices []string, opts *PaginatorOptions) (*Paginator[T], error) {
+	p := newPaginator[T](cli, opts)
+
+	pitID, err := cli.OpenPointInTime(ctx, indices, p.opts.KeepAlive)
+	if err != nil {
+		return nil, err
+	}
+	p.cursor.PitID = pitID
+
+	return p, nil
+}
+
+// ResumePaginator returns a paginator that carries on from a cursor token, as returned in Page.Next. The options
+// must be the same as those the paginator was created with.
+func ResumePaginator[T any](cli Client, token string, opts *PaginatorOptions) (*Paginator[T], error) {
+	cursor, err := ParseCursor(token)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPaginator[T](cli, opts)
+	p.cursor = *cursor
+
+	return p, nil
+}
+
+func newPaginator[T any](cli Client, opts *PaginatorOptions) *Paginator[T] {
+	p := &Paginator[T]{cli: cli}
+	if opts != nil {
+		p.opts = *opts
+	}
+
+	if p.opts.Size <= 0 {
+		p.opts.Size = DefaultPageSize
+	}
+	if p.opts.KeepAlive <= 0 {
+		p.opts.KeepAlive = DefaultPointInTimeKeepAlive
+	}
+
+	p.opts.Sort = append([]json.RawMessage{}, p.opts.Sort...)
+	if len(p.opts.Sort) == 0 {
+		p.opts.Sort = append(p.opts.Sort, json.RawMessage(`{"_score":"desc"}`))
+	}
+	if p.opts.Tiebreaker != "" {
+		tiebreaker, _ := json.Marshal(map[string]string{p.opts.Tiebreaker: "asc"}) //nolint:errcheck // a map of strings always marshals
+		p.opts.Sort = append(p.opts.Sort, tiebreaker)
+	}
+
+	return p
+}
+
+// Next returns the next page of hits. A page with fewer hits than the page size is the last, and once it has
+// been returned the point in time is closed, with any failure to close it returned along with the page.
+// Further calls return io.EOF.
+func (p *Paginator[T]) Next(ctx context.Context) (*Page[T], error) {
+	if p.done {
+		return nil, io.EOF
+	}
+
+	body, err := json.Marshal(paginatorSearch{
+		Query:       p.opts.Query,
+		Size:        p.opts.Size,
+		Sort:        p.opts.Sort,
+		PIT:         paginatorPIT{ID: p.cursor.PitID, KeepAlive: formatDuration(p.opts.KeepAlive)},
+		SearchAfter: p.cursor.SearchAfter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build search request: %w", err)
+	}
+
+	// A search of a point in time must not name an index, so the header is left empty
+	data, err := p.cli.Search(ctx, Search{Query: body})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := decodeResponse[SearchResponse[T]](data, "search")
+	if err != nil {
+		return nil, err
+	}
+
+	sorts, err := decodeResponse[pageSortValues](data, "search")
+	if err != nil {
+		return nil, err
+	}
+
+	// The point in time ID may change between searches, and the latest one must be used
+	if res.PitID != "" {
+		p.cursor.PitID = res.PitID
+	}
+
+	page := &Page[T]{
+		Hits:  res.Hits.Hits,
+		Total: res.Hits.Total,
+	}
+
+	if len(page.Hits) < p.opts.Size {
+		return page, p.Close(ctx)
+	}
+
+	p.cursor.SearchAfter = sorts.Hits.Hits[len(sorts.Hits.Hits)-1].Sort
+	page.Next = p.cursor.Token()
+
+	return page, nil
+}
+
+// Cursor returns the current position of the paginator, from which the next page is returned
+func (p *Paginator[T]) Cursor() Cursor {
+	return p.cursor
+}
+
+// Close closes the point in time, if it is open. It is closed even if ctx is done. It is safe to call Close
+// more than once.
+func (p *Paginator[T]) Close(ctx context.Context) error {
+	p.done = true
+
+	if p.cursor.PitID == "" {
+		return nil
+	}
+
+	pitID := p.cursor.PitID
+	p.cursor.PitID = ""
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), closePointInTimeTimeout)
+	defer cancel()
+
+	return p.cli.ClosePointInTime(ctx, pitID)
+}
@@ -0,0 +1,153 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/mocks"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPaginator(t *testing.T) {
+	ctx := context.Background()
+	opts := &client.PaginatorOptions{
+		Sort: []json.RawMessage{json.RawMessage(`{"n":"desc"}`)},
+		Size: 10,
+	}
+
+	Convey("Given an index with 25 documents", t, func() {
+		esClient := newScrollClient(25)
+
+		Convey("When every page is requested from a new paginator", func() {
+			p, err := client.NewPaginator[testScrollDocument](ctx, esClient, []string{"docs"}, opts)
+			So(err, ShouldBeNil)
+
+			var sizes []int
+			var tokens []string
+			var seen []int
+			for {
+				page, err := p.Next(ctx)
+				So(err, ShouldBeNil)
+				sizes = append(sizes, len(page.Hits))
+				tokens = append(tokens, page.Next)
+				for _, hit := range page.Hits {
+					seen = append(seen, hit.Source.N)
+				}
+				if page.Next == "" {
+					break
+				}
+			}
+
+			Convey("Then every document is returned once, in order, a page at a time", func() {
+				So(sizes, ShouldResemble, []int{10, 10, 5})
+				So(seen, ShouldHaveLength, 25)
+				So(seen[0], ShouldEqual, 25)
+				So(seen[24], ShouldEqual, 1)
+			})
+
+			Convey("Then a cursor token is returned for every page but the last", func() {
+				So(tokens[0], ShouldNotBeEmpty)
+				So(tokens[1], ShouldNotBeEmpty)
+				So(tokens[2], ShouldBeEmpty)
+			})
+
+			Convey("Then the point in time is closed after the last page", func() {
+				So(esClient.OpenPointsInTime(), ShouldBeEmpty)
+
+				_, err := p.Next(ctx)
+				So(err, ShouldEqual, io.EOF)
+			})
+		})
+
+		Convey("When a paginator is resumed from the cursor token of the first page", func() {
+			first, err := client.NewPaginator[testScrollDocument](ctx, esClient, []string{"docs"}, opts)
+			So(err, ShouldBeNil)
+			page, err := first.Next(ctx)
+			So(err, ShouldBeNil)
+
+			resumed, err := client.ResumePaginator[testScrollDocument](esClient, page.Next, opts)
+			So(err, ShouldBeNil)
+			next, err := resumed.Next(ctx)
+
+			Convey("Then the second page is returned", func() {
+				So(err, ShouldBeNil)
+				So(next.Hits, ShouldHaveLength, 10)
+				So(next.Hits[0].Source.N, ShouldEqual, 15)
+				So(next.Total.Value, ShouldEqual, 25)
+			})
+
+			Convey("Then Close closes the point in time", func() {
+				So(resumed.Close(ctx), ShouldBeNil)
+				So(esClient.OpenPointsInTime(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a cluster that returns a new point in time ID with each page", t, func() {
+		var bodies []string
+		esClient := &mocks.ClientMock{
+			OpenPointInTimeFunc: func(context.Context, []string, time.Duration) (string, error) {
+				return "pit-1", nil
+			},
+			SearchFunc: func(_ context.Context, search client.Search) ([]byte, error) {
+				bodies = append(bodies, string(search.Query))
+				return []byte(`{"pit_id":"pit-2","hits":{"total":{"value":3},"hits":[` +
+					`{"_id":"a","_source":{"n":1},"sort":[1700000000000,9007199254740993]}]}}`), nil
+			},
+			ClosePointInTimeFunc: func(context.Context, string) error { return nil },
+		}
+
+		Convey("When two pages are requested with a tiebreaker", func() {
+			p, err := client.NewPaginator[testScrollDocument](ctx, esClient, []string{"docs"}, &client.PaginatorOptions{
+				Query:      json.RawMessage(`{"term":{"type":"bulletin"}}`),
+				Sort:       []json.RawMessage{json.RawMessage(`{"release_date":"desc"}`)},
+				Tiebreaker: "id",
+				Size:       1,
+				KeepAlive:  5 * time.Minute,
+			})
+			So(err, ShouldBeNil)
+
+			page, err := p.Next(ctx)
+			So(err, ShouldBeNil)
+			_, err = p.Next(ctx)
+			So(err, ShouldBeNil)
+
+			Convey("Then the first search uses the opened point in time and the tiebreaker sort", func() {
+				So(esClient.OpenPointInTimeCalls()[0].KeepAlive, ShouldEqual, 5*time.Minute)
+				So(bodies[0], ShouldEqual, `{"query":{"term":{"type":"bulletin"}},"size":1,`+
+					`"sort":[{"release_date":"desc"},{"id":"asc"}],"pit":{"id":"pit-1","keep_alive":"300000ms"}}`)
+				So(esClient.SearchCalls()[0].Search.Header.Index, ShouldBeEmpty)
+			})
+
+			Convey("Then the next search uses the latest point in time and the exact sort values of the last hit", func() {
+				So(bodies[1], ShouldContainSubstring, `"pit":{"id":"pit-2","keep_alive":"300000ms"}`)
+				So(bodies[1], ShouldContainSubstring, `"search_after":[1700000000000,9007199254740993]`)
+			})
+
+			Convey("Then the cursor token decodes to the position after the page", func() {
+				cursor, err := client.ParseCursor(page.Next)
+				So(err, ShouldBeNil)
+				So(cursor.PitID, ShouldEqual, "pit-2")
+				So(cursor.SearchAfter, ShouldHaveLength, 2)
+				So(string(cursor.SearchAfter[1]), ShouldEqual, "9007199254740993")
+			})
+		})
+	})
+
+	Convey("Given a cursor token that is not valid", t, func() {
+		Convey("When a paginator is resumed from it", func() {
+			_, err := client.ResumePaginator[testScrollDocument](&mocks.ClientMock{}, "not-a-cursor", nil)
+
+			Convey("Then a bad request error matching ErrInvalidCursor is returned", func() {
+				So(errors.Is(err, client.ErrInvalidCursor), ShouldBeTrue)
+				So(esError.ErrorStatus(err), ShouldEqual, 400)
+			})
+		})
+	})
+}
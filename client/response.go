@@ -0,0 +1,459 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+)
+
+// Values of Total.Relation
+const (
+	TotalRelationEqual          = "eq"
+	TotalRelationGreaterOrEqual = "gte"
+)
+
+// SearchResponse is the response to a search, with the _source of each hit decoded into T
+type SearchResponse[T any] struct {
+	Took         int             `json:"took"`
+	TimedOut     bool            `json:"timed_out"`
+	Shards       Shards          `json:"_shards"`
+	Hits         Hits[T]         `json:"hits"`
+	Aggregations json.RawMessage `json:"aggregations,omitempty"`
+	ScrollID     string          `json:"_scroll_id,omitempty"`
+	PitID        string          `json:"pit_id,omitempty"`
+}
+
+// Shards summarises the shards that took part in a request
+type Shards struct {
+	Total      int `json:"total"`
+	Successful int `json:"successful"`
+	Skipped    int `json:"skipped"`
+	Failed     int `json:"failed"`
+}
+
+// Hits holds the documents matching a search
+type Hits[T any] struct {
+	Total    Total    `json:"total"`
+	MaxScore *float64 `json:"max_score"`
+	Hits     []Hit[T] `json:"hits"`
+}
+
+// Total is the number of documents matching a search. When the count is not tracked exactly Relation is
+// TotalRelationGreaterOrEqual and Value is a lower bound.
+type Total struct {
+	Value    int64  `json:"value"`
+	Relation string `json:"relation"`
+}
+
+// UnmarshalJSON decodes a total in either object form or, as returned with rest_total_hits_as_int, as a number
+func (t *Total) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] != '{' && !bytes.Equal(data, []byte("null")) {
+		t.Relation = TotalRelationEqual
+		return json.Unmarshal(data, &t.Value)
+	}
+
+	type total Total
+	return json.Unmarshal(data, (*total)(t))
+}
+
+// Hit is a single document matching a search
+type Hit[T any] struct {
+	Index     string                     `json:"_index"`
+	ID        string                     `json:"_id"`
+	Score     *float64                   `json:"_score"`
+	Source    T                          `json:"_source"`
+	Highlight map[string][]string        `json:"highlight,omitempty"`
+	Fields    map[string]json.RawMessage `json:"fields,omitempty"`
+	Sort      []interface{}              `json:"sort,omitempty"`
+}
+
+// MultiSearchResponse is the response to a multi search, holding a response for each search in request order
+type MultiSearchResponse[T any] struct {
+	Took      int                  `json:"took"`
+	Responses []MultiSearchItem[T] `json:"responses"`
+}
+
+// MultiSearchItem is the response to one search of a multi search. A failed search has its Error set instead of hits.
+type MultiSearchItem[T any] struct {
+	SearchResponse[T]
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// CountResponse is the response to a count
+type CountResponse struct {
+	Count  int64  `json:"count"`
+	Shards Shards `json:"_shards"`
+}
+
+// ExplainResponse is the response to an explain request
+type ExplainResponse struct {
+	Index       string          `json:"_index"`
+	ID          string          `json:"_id"`
+	Matched     bool            `json:"matched"`
+	Explanation json.RawMessage `json:"explanation,omitempty"`
+}
+
+// WriteResult is the result of writing a single document. SeqNo and PrimaryTerm can be passed back in the
+// ConcurrencyControl of a later write to make it conditional on the document not having changed.
+type WriteResult struct {
+	Index       string `json:"_index"`
+	ID          string `json:"_id"`
+	Version     int64  `json:"_version"`
+	SeqNo       int64  `json:"_seq_no"`
+	PrimaryTerm int64  `json:"_primary_term"`
+	Result      string `json:"result"`
+}
+
+// GetResult is a document fetched by ID. Found is false, with the other fields left empty, if the document does not exist.
+type GetResult struct {
+	Index       string          `json:"_index"`
+	ID          string          `json:"_id"`
+	Version     int64           `json:"_version"`
+	SeqNo       int64           `json:"_seq_no"`
+	PrimaryTerm int64           `json:"_primary_term"`
+	Routing     string          `json:"_routing,omitempty"`
+	Found       bool            `json:"found"`
+	Source      json.RawMessage `json:"_source,omitempty"`
+}
+
+// MultiGetResponse is the response to a multi get, holding a result for each requested document in request order
+type MultiGetResponse struct {
+	Docs []MultiGetItem `json:"docs"`
+}
+
+// MultiGetItem is the result for one document of a multi get. Error is set if the document could not be got,
+// for example because its index does not exist.
+type MultiGetItem struct {
+	GetResult
+	Error *esError.ErrorCause `json:"error,omitempty"`
+}
+
+// ClusterHealthResponse is the response to a cluster health request. TimedOut is set when the status waited for
+// was not reached before the timeout.
+type ClusterHealthResponse struct {
+	ClusterName         string        `json:"cluster_name"`
+	Status              ClusterStatus `json:"status"`
+	TimedOut            bool          `json:"timed_out"`
+	NumberOfNodes       int           `json:"number_of_nodes"`
+	ActivePrimaryShards int           `json:"active_primary_shards"`
+	ActiveShards        int           `json:"active_shards"`
+	RelocatingShards    int           `json:"relocating_shards"`
+	InitializingShards  int           `json:"initializing_shards"`
+	UnassignedShards    int           `json:"unassigned_shards"`
+}
+
+// UpdateAliasesResponse is the response to an update aliases request. Newer clusters report each action in
+// ActionResults, and set Errors rather than failing the request when only some of the actions fail.
+type UpdateAliasesResponse struct {
+	Acknowledged  bool                `json:"acknowledged"`
+	Errors        bool                `json:"errors"`
+	ActionResults []AliasActionResult `json:"action_results,omitempty"`
+	Raw           json.RawMessage     `json:"-"` // The response body, used to describe errors that are not reported per action
+}
+
+// UnmarshalJSON decodes the response, keeping the body in Raw
+func (r *UpdateAliasesResponse) UnmarshalJSON(data []byte) error {
+	type updateAliasesResponse UpdateAliasesResponse
+	var res updateAliasesResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	*r = UpdateAliasesResponse(res)
+	r.Raw = bytes.Clone(data)
+
+	return nil
+}
+
+// AliasActionResult is the result of a single action of an update aliases request
+type AliasActionResult struct {
+	Action struct {
+		Type    AliasActionType `json:"type"`
+		Indices []string        `json:"indices"`
+		Aliases []string        `json:"aliases"`
+	} `json:"action"`
+	Status int                 `json:"status"`
+	Error  *esError.ErrorCause `json:"error,omitempty"`
+}
+
+// Err returns an error describing the failed actions, or nil if every action succeeded
+func (r UpdateAliasesResponse) Err() error {
+	if !r.Errors {
+		return nil
+	}
+
+	var failures []string
+	for _, result := range r.ActionResults {
+		if result.Error == nil {
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("%s %v %v: %s: %s", result.Action.Type, result.Action.Indices,
+			result.Action.Aliases, result.Error.Type, result.Error.Reason))
+	}
+
+	if len(failures) == 0 {
+		return fmt.Errorf("alias update reported errors: %s", r.Raw)
+	}
+
+	return fmt.Errorf("alias actions failed: %s", strings.Join(failures, "; "))
+}
+
+// TaskResponse is the response to a get task request. Once the task has completed, Response holds the result of
+// a reindex, update by query or delete by query, and Error holds the reason the task failed, if it did.
+type TaskResponse struct {
+	Completed bool                  `json:"completed"`
+	Task      TaskInfo              `json:"task"`
+	Response  *BulkByScrollResponse `json:"response,omitempty"`
+	Error     *esError.ErrorCause   `json:"error,omitempty"`
+}
+
+// TaskInfo describes a task running on a node of the cluster
+type TaskInfo struct {
+	Node               string      `json:"node"`
+	ID                 int64       `json:"id"`
+	Type               string      `json:"type"`
+	Action             string      `json:"action"`
+	Description        string      `json:"description,omitempty"`
+	StartTimeInMillis  int64       `json:"start_time_in_millis"`
+	RunningTimeInNanos int64       `json:"running_time_in_nanos"`
+	Cancellable        bool        `json:"cancellable"`
+	Cancelled          bool        `json:"cancelled,omitempty"`
+	ParentTaskID       string      `json:"parent_task_id,omitempty"`
+	Status             *TaskStatus `json:"status,omitempty"`
+}
+
+// TaskID returns the ID of the task, in the node:id form used by the task APIs
+func (t TaskInfo) TaskID() string {
+	return fmt.Sprintf("%s:%d", t.Node, t.ID)
+}
+
+// TaskStatus is the progress of a reindex, update by query or delete by query task
+type TaskStatus struct {
+	Total                int64   `json:"total"`
+	Updated              int64   `json:"updated"`
+	Created              int64   `json:"created"`
+	Deleted              int64   `json:"deleted"`
+	Batches              int64   `json:"batches"`
+	VersionConflicts     int64   `json:"version_conflicts"`
+	Noops                int64   `json:"noops"`
+	Retries              Retries `json:"retries"`
+	ThrottledMillis      int64   `json:"throttled_millis"`
+	RequestsPerSecond    float64 `json:"requests_per_second"` // -1 when not throttled
+	ThrottledUntilMillis int64   `json:"throttled_until_millis"`
+}
+
+// TasksResponse is the response to a list, cancel or rethrottle tasks request, holding the tasks it applied to
+type TasksResponse struct {
+	Tasks        []TaskInfo           `json:"tasks"`
+	NodeFailures []esError.ErrorCause `json:"node_failures,omitempty"`
+	TaskFailures []TaskFailure        `json:"task_failures,omitempty"`
+}
+
+// TaskFailure is a failure to apply a request to a task
+type TaskFailure struct {
+	TaskID int64               `json:"task_id"`
+	NodeID string              `json:"node_id"`
+	Status string              `json:"status"`
+	Reason *esError.ErrorCause `json:"reason,omitempty"`
+}
+
+// UnmarshalJSON decodes the tasks whether they are listed, as with group_by=none, or grouped by node or parent
+func (r *TasksResponse) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Tasks json.RawMessage `json:"tasks"`
+		Nodes map[string]struct {
+			Tasks map[string]TaskInfo `json:"tasks"`
+		} `json:"nodes"`
+		NodeFailures []esError.ErrorCause `json:"node_failures"`
+		TaskFailures []TaskFailure        `json:"task_failures"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*r = TasksResponse{NodeFailures: raw.NodeFailures, TaskFailures: raw.TaskFailures}
+
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(raw.Tasks), []byte("[")):
+		if err := json.Unmarshal(raw.Tasks, &r.Tasks); err != nil {
+			return err
+		}
+	case len(raw.Tasks) > 0 && !bytes.Equal(raw.Tasks, []byte("null")):
+		var byParent map[string]TaskInfo
+		if err := json.Unmarshal(raw.Tasks, &byParent); err != nil {
+			return err
+		}
+		for _, task := range byParent {
+			r.Tasks = append(r.Tasks, task)
+		}
+	}
+
+	for _, node := range raw.Nodes {
+		for _, task := range node.Tasks {
+			r.Tasks = append(r.Tasks, task)
+		}
+	}
+
+	// Grouped tasks are held in maps, so they are sorted to give a stable order
+	sort.Slice(r.Tasks, func(i, j int) bool {
+		if r.Tasks[i].Node != r.Tasks[j].Node {
+			return r.Tasks[i].Node < r.Tasks[j].Node
+		}
+		return r.Tasks[i].ID < r.Tasks[j].ID
+	})
+
+	return nil
+}
+
+// Err returns an error describing the node and task failures, or nil if there were none
+func (r TasksResponse) Err() error {
+	var failures []string
+	for _, failure := range r.NodeFailures {
+		failures = append(failures, fmt.Sprintf("%s: %s", failure.Type, failure.Reason))
+	}
+
+	for _, failure := range r.TaskFailures {
+		reason := failure.Status
+		if failure.Reason != nil {
+			reason = failure.Reason.Type + ": " + failure.Reason.Reason
+		}
+		failures = append(failures, fmt.Sprintf("task %s:%d: %s", failure.NodeID, failure.TaskID, reason))
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("task request failed: %s", strings.Join(failures, "; "))
+}
+
+// BulkByScrollResponse is the response to an update by query or a reindex. When the request is not waited for,
+// only Task is set, holding the ID of the task running it.
+type BulkByScrollResponse struct {
+	Task             string           `json:"task,omitempty"`
+	Took             int64            `json:"took"`
+	TimedOut         bool             `json:"timed_out"`
+	Total            int64            `json:"total"`
+	Updated          int64            `json:"updated"`
+	Created          int64            `json:"created"`
+	Deleted          int64            `json:"deleted"`
+	Batches          int64            `json:"batches"`
+	VersionConflicts int64            `json:"version_conflicts"`
+	Noops            int64            `json:"noops"`
+	Retries          Retries          `json:"retries"`
+	ThrottledMillis  int64            `json:"throttled_millis"`
+	Failures         []ByQueryFailure `json:"failures"`
+}
+
+// Err returns an error matching ErrTaskFailures that describes the failures, or nil if there were none
+func (r BulkByScrollResponse) Err() error {
+	if len(r.Failures) == 0 {
+		return nil
+	}
+
+	first := r.Failures[0]
+	reason := fmt.Sprintf("status %d", first.Status)
+	switch {
+	case first.Cause != nil:
+		reason = fmt.Sprintf("%s: %s", first.Cause.Type, first.Cause.Reason)
+	case first.Reason != nil:
+		reason = fmt.Sprintf("%s: %s", first.Reason.Type, first.Reason.Reason)
+	}
+
+	location := first.Index
+	if first.ID != "" {
+		location = fmt.Sprintf("document %s in %s", first.ID, first.Index)
+	}
+
+	return fmt.Errorf("%w: %d failures, the first being %s with %s", ErrTaskFailures, len(r.Failures), location, reason)
+}
+
+// Retries counts the bulk and search requests retried while running a request
+type Retries struct {
+	Bulk   int64 `json:"bulk"`
+	Search int64 `json:"search"`
+}
+
+// ByQueryFailure is a failure to update a document, or a search failure, during a by query request
+type ByQueryFailure struct {
+	Index  string              `json:"index"`
+	ID     string              `json:"id,omitempty"`
+	Status int                 `json:"status,omitempty"`
+	Cause  *esError.ErrorCause `json:"cause,omitempty"`  // set for a document failure
+	Shard  *int                `json:"shard,omitempty"`  // set for a search failure
+	Reason *esError.ErrorCause `json:"reason,omitempty"` // set for a search failure
+}
+
+// SearchTyped performs a search with cli, decoding the _source of each hit into T
+func SearchTyped[T any](ctx context.Context, cli Client, search Search) (*SearchResponse[T], error) {
+	data, err := cli.Search(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResponse[SearchResponse[T]](data, "search")
+}
+
+// MultiSearchTyped performs a multi search with cli, decoding the _source of each hit into T
+func MultiSearchTyped[T any](ctx context.Context, cli Client, searches []Search, queryParams *QueryParams) (*MultiSearchResponse[T], error) {
+	data, err := cli.MultiSearch(ctx, searches, queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResponse[MultiSearchResponse[T]](data, "multi search")
+}
+
+// CountTyped performs a count with cli and decodes the response
+func CountTyped(ctx context.Context, cli Client, count Count) (*CountResponse, error) {
+	data, err := cli.Count(ctx, count)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResponse[CountResponse](data, "count")
+}
+
+// ExplainTyped explains how the given document matches a search with cli and decodes the response
+func ExplainTyped(ctx context.Context, cli Client, documentID string, search Search) (*ExplainResponse, error) {
+	data, err := cli.Explain(ctx, documentID, search)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResponse[ExplainResponse](data, "explain")
+}
+
+// decodeResponse decodes the body of a response to the named request. An error response, which a client may
+// return as the body rather than as an error, is returned as an esError.StatusError wrapping the parsed
+// *esError.ESError, so that it can be matched with errors.Is and errors.As.
+func decodeResponse[R any](data []byte, request string) (*R, error) {
+	var errRes struct {
+		Error  json.RawMessage `json:"error"`
+		Status int             `json:"status"`
+	}
+	if err := json.Unmarshal(data, &errRes); err == nil && len(errRes.Error) > 0 && !bytes.Equal(errRes.Error, []byte("null")) {
+		status := errRes.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		return nil, esError.StatusError{
+			Err:  fmt.Errorf("error response to %s: %w", request, esError.NewESError("cluster", status, data)),
+			Code: status,
+		}
+	}
+
+	var res R
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("failed to decode %s response: %w", request, err)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,237 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/mocks"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testDocument struct {
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+}
+
+const testSearchResponse = `{
+	"took": 5,
+	"timed_out": false,
+	"_shards": {"total": 2, "successful": 2, "skipped": 0, "failed": 0},
+	"hits": {
+		"total": {"value": 10000, "relation": "gte"},
+		"max_score": 1.5,
+		"hits": [
+			{"_index": "my-index", "_id": "1", "_score": 1.5, "_source": {"title": "Inflation", "year": 2020}, "sort": [2020, "1"]},
+			{"_index": "my-index", "_id": "2", "_score": 0.5, "_source": {"title": "Trade", "year": 2021}, "highlight": {"title": ["<em>Trade</em>"]}}
+		]
+	},
+	"aggregations": {"years": {"buckets": []}}
+}`
+
+func TestSearchTyped(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a client returning a search response", t, func() {
+		cli := &mocks.ClientMock{
+			SearchFunc: func(ctx context.Context, search client.Search) ([]byte, error) {
+				return []byte(testSearchResponse), nil
+			},
+		}
+
+		Convey("When SearchTyped is called", func() {
+			res, err := client.SearchTyped[testDocument](ctx, cli, client.Search{Header: client.Header{Index: "my-index"}})
+
+			Convey("Then the response is decoded", func() {
+				So(err, ShouldBeNil)
+				So(cli.SearchCalls(), ShouldHaveLength, 1)
+				So(res.Took, ShouldEqual, 5)
+				So(res.TimedOut, ShouldBeFalse)
+				So(res.Shards, ShouldResemble, client.Shards{Total: 2, Successful: 2})
+				So(res.Hits.Total, ShouldResemble, client.Total{Value: 10000, Relation: client.TotalRelationGreaterOrEqual})
+				So(*res.Hits.MaxScore, ShouldEqual, 1.5)
+				So(string(res.Aggregations), ShouldEqual, `{"years": {"buckets": []}}`)
+			})
+
+			Convey("Then the _source of each hit is decoded into the given type", func() {
+				So(res.Hits.Hits, ShouldHaveLength, 2)
+				So(res.Hits.Hits[0].ID, ShouldEqual, "1")
+				So(res.Hits.Hits[0].Source, ShouldResemble, testDocument{Title: "Inflation", Year: 2020})
+				So(res.Hits.Hits[0].Sort, ShouldResemble, []interface{}{float64(2020), "1"})
+				So(res.Hits.Hits[1].Source, ShouldResemble, testDocument{Title: "Trade", Year: 2021})
+				So(res.Hits.Hits[1].Highlight["title"], ShouldResemble, []string{"<em>Trade</em>"})
+			})
+		})
+	})
+
+	Convey("Given a client returning a total as a number", t, func() {
+		cli := &mocks.ClientMock{
+			SearchFunc: func(ctx context.Context, search client.Search) ([]byte, error) {
+				return []byte(`{"hits":{"total":3,"hits":[]}}`), nil
+			},
+		}
+
+		Convey("When SearchTyped is called", func() {
+			res, err := client.SearchTyped[testDocument](ctx, cli, client.Search{})
+
+			Convey("Then the total is decoded as an exact count", func() {
+				So(err, ShouldBeNil)
+				So(res.Hits.Total, ShouldResemble, client.Total{Value: 3, Relation: client.TotalRelationEqual})
+			})
+		})
+	})
+
+	Convey("Given a client returning an error", t, func() {
+		searchErr := errors.New("search failed")
+		cli := &mocks.ClientMock{
+			SearchFunc: func(ctx context.Context, search client.Search) ([]byte, error) {
+				return nil, searchErr
+			},
+		}
+
+		Convey("When SearchTyped is called", func() {
+			res, err := client.SearchTyped[testDocument](ctx, cli, client.Search{})
+
+			Convey("Then the error is returned", func() {
+				So(res, ShouldBeNil)
+				So(err, ShouldEqual, searchErr)
+			})
+		})
+	})
+
+	Convey("Given a client returning an error response as the body", t, func() {
+		cli := &mocks.ClientMock{
+			SearchFunc: func(ctx context.Context, search client.Search) ([]byte, error) {
+				return []byte(`{"error":{"root_cause":[{"type":"index_not_found_exception","reason":"no such index [missing]","index":"missing"}],` +
+					`"type":"index_not_found_exception","reason":"no such index [missing]","index":"missing"},"status":404}`), nil
+			},
+		}
+
+		Convey("When SearchTyped is called", func() {
+			res, err := client.SearchTyped[testDocument](ctx, cli, client.Search{})
+
+			Convey("Then a StatusError with the status of the response is returned", func() {
+				So(res, ShouldBeNil)
+				var statusErr esError.StatusError
+				So(errors.As(err, &statusErr), ShouldBeTrue)
+				So(statusErr.Status(), ShouldEqual, 404)
+			})
+
+			Convey("Then the parsed error response can be matched", func() {
+				So(errors.Is(err, esError.ErrNotFound), ShouldBeTrue)
+				So(esError.IsIndexNotFound(err), ShouldBeTrue)
+
+				var esErr *esError.ESError
+				So(errors.As(err, &esErr), ShouldBeTrue)
+				So(esErr.Type, ShouldEqual, "index_not_found_exception")
+				So(esErr.Index, ShouldEqual, "missing")
+			})
+		})
+	})
+
+	Convey("Given a client returning an invalid response", t, func() {
+		cli := &mocks.ClientMock{
+			SearchFunc: func(ctx context.Context, search client.Search) ([]byte, error) {
+				return []byte(`{"hits":{"hits":[{"_source":"not an object"}]}}`), nil
+			},
+		}
+
+		Convey("When SearchTyped is called", func() {
+			_, err := client.SearchTyped[testDocument](ctx, cli, client.Search{})
+
+			Convey("Then a decoding error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldStartWith, "failed to decode search response")
+			})
+		})
+	})
+}
+
+func TestMultiSearchTyped(t *testing.T) {
+	Convey("Given a client returning a multi search response with a failed search", t, func() {
+		cli := &mocks.ClientMock{
+			MultiSearchFunc: func(ctx context.Context, searches []client.Search, queryParams *client.QueryParams) ([]byte, error) {
+				return []byte(`{"took":3,"responses":[
+					{"status":200,"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_id":"1","_source":{"title":"Inflation"}}]}},
+					{"status":404,"error":{"type":"index_not_found_exception"}}
+				]}`), nil
+			},
+		}
+
+		Convey("When MultiSearchTyped is called", func() {
+			res, err := client.MultiSearchTyped[testDocument](context.Background(), cli, []client.Search{{}, {}}, nil)
+
+			Convey("Then each response is decoded", func() {
+				So(err, ShouldBeNil)
+				So(res.Responses, ShouldHaveLength, 2)
+				So(res.Responses[0].Status, ShouldEqual, 200)
+				So(res.Responses[0].Hits.Hits[0].Source.Title, ShouldEqual, "Inflation")
+				So(res.Responses[1].Status, ShouldEqual, 404)
+				So(string(res.Responses[1].Error), ShouldEqual, `{"type":"index_not_found_exception"}`)
+			})
+		})
+	})
+}
+
+func TestCountAndExplainTyped(t *testing.T) {
+	Convey("Given a client returning count and explain responses", t, func() {
+		cli := &mocks.ClientMock{
+			CountFunc: func(ctx context.Context, count client.Count) ([]byte, error) {
+				return []byte(`{"count":42,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0}}`), nil
+			},
+			ExplainFunc: func(ctx context.Context, documentID string, search client.Search) ([]byte, error) {
+				return []byte(`{"_index":"my-index","_id":"1","matched":true,"explanation":{"value":1.0}}`), nil
+			},
+		}
+
+		Convey("When CountTyped is called", func() {
+			res, err := client.CountTyped(context.Background(), cli, client.Count{})
+
+			Convey("Then the count is decoded", func() {
+				So(err, ShouldBeNil)
+				So(res.Count, ShouldEqual, 42)
+				So(res.Shards.Successful, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When ExplainTyped is called", func() {
+			res, err := client.ExplainTyped(context.Background(), cli, "1", client.Search{})
+
+			Convey("Then the explanation is decoded", func() {
+				So(err, ShouldBeNil)
+				So(res.Matched, ShouldBeTrue)
+				So(res.ID, ShouldEqual, "1")
+				So(string(res.Explanation), ShouldEqual, `{"value":1.0}`)
+			})
+		})
+	})
+}
+
+func TestUpdateAliasesResponseErr(t *testing.T) {
+	Convey("Given an update aliases response with errors but no action results", t, func() {
+		body := `{"acknowledged":true,"errors":true}`
+		var res client.UpdateAliasesResponse
+		So(json.Unmarshal([]byte(body), &res), ShouldBeNil)
+
+		Convey("When Err is called", func() {
+			err := res.Err()
+
+			Convey("Then a generic error including the response body is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "alias update reported errors: "+body)
+			})
+		})
+	})
+
+	Convey("Given an update aliases response without errors", t, func() {
+		var res client.UpdateAliasesResponse
+		So(json.Unmarshal([]byte(`{"acknowledged":true}`), &res), ShouldBeNil)
+
+		Convey("Then Err returns nil", func() {
+			So(res.Err(), ShouldBeNil)
+		})
+	})
+}
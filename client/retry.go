@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is the number of retries made when Config.MaxRetries is zero
+	DefaultMaxRetries = 3
+	// DefaultMinRetryBackoff is the backoff before the first retry when Config.MinRetryBackoff is zero
+	DefaultMinRetryBackoff = 100 * time.Millisecond
+	// DefaultMaxRetryBackoff is the upper bound of the backoff between retries when Config.MaxRetryBackoff is zero
+	DefaultMaxRetryBackoff = 10 * time.Second
+)
+
+// DefaultRetryOnStatus are the response status codes retried when Config.RetryOnStatus is empty
+var DefaultRetryOnStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy describes how failed requests are retried by the clients
+type RetryPolicy struct {
+	MaxRetries    int
+	MinBackoff    time.Duration
+	MaxBackoff    time.Duration
+	RetryOnStatus []int
+}
+
+// NewRetryPolicy returns the retry policy described by the config, with defaults applied to any unset values.
+// A negative MaxRetries disables retries.
+func NewRetryPolicy(cfg Config) RetryPolicy {
+	policy := RetryPolicy{
+		MaxRetries:    cfg.MaxRetries,
+		MinBackoff:    cfg.MinRetryBackoff,
+		MaxBackoff:    cfg.MaxRetryBackoff,
+		RetryOnStatus: cfg.RetryOnStatus,
+	}
+
+	switch {
+	case policy.MaxRetries == 0:
+		policy.MaxRetries = DefaultMaxRetries
+	case policy.MaxRetries < 0:
+		policy.MaxRetries = 0
+	}
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = DefaultMinRetryBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultMaxRetryBackoff
+	}
+	if policy.MaxBackoff < policy.MinBackoff {
+		policy.MaxBackoff = policy.MinBackoff
+	}
+	if len(policy.RetryOnStatus) == 0 {
+		policy.RetryOnStatus = DefaultRetryOnStatus
+	}
+
+	return policy
+}
+
+// Disabled reports whether requests are never retried
+func (p RetryPolicy) Disabled() bool {
+	return p.MaxRetries <= 0
+}
+
+// Backoff returns how long to wait before the given retry attempt, starting at 1. The backoff doubles with
+// each attempt up to MaxBackoff, and a random jitter of up to half the backoff is taken off so that clients
+// retrying at the same time spread out.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	backoff := p.MaxBackoff
+	if shift := attempt - 1; shift < 63 && p.MinBackoff<<shift > 0 && p.MinBackoff<<shift < p.MaxBackoff {
+		backoff = p.MinBackoff << shift
+	}
+
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+
+	return backoff - rand.N(half) //nolint:gosec // jitter does not need a secure source
+}
+
+// Wait blocks for the backoff of the given retry attempt, returning early with the context error if ctx is done
+func (p RetryPolicy) Wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.Backoff(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ShouldRetryStatus reports whether a response with the given status code should be retried
+func (p RetryPolicy) ShouldRetryStatus(code int) bool {
+	return slices.Contains(p.RetryOnStatus, code)
+}
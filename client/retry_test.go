@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewRetryPolicy(t *testing.T) {
+	Convey("Given an empty config", t, func() {
+		Convey("When NewRetryPolicy is called", func() {
+			policy := NewRetryPolicy(Config{})
+
+			Convey("Then the defaults are used", func() {
+				So(policy.MaxRetries, ShouldEqual, DefaultMaxRetries)
+				So(policy.MinBackoff, ShouldEqual, DefaultMinRetryBackoff)
+				So(policy.MaxBackoff, ShouldEqual, DefaultMaxRetryBackoff)
+				So(policy.RetryOnStatus, ShouldResemble, []int{429, 502, 503, 504})
+				So(policy.Disabled(), ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a config with a negative MaxRetries", t, func() {
+		Convey("When NewRetryPolicy is called", func() {
+			policy := NewRetryPolicy(Config{MaxRetries: -1})
+
+			Convey("Then retries are disabled", func() {
+				So(policy.MaxRetries, ShouldEqual, 0)
+				So(policy.Disabled(), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a config with retry settings", t, func() {
+		cfg := Config{
+			MaxRetries:      5,
+			MinRetryBackoff: time.Second,
+			MaxRetryBackoff: time.Minute,
+			RetryOnStatus:   []int{503},
+		}
+
+		Convey("When NewRetryPolicy is called", func() {
+			policy := NewRetryPolicy(cfg)
+
+			Convey("Then the settings are used", func() {
+				So(policy.MaxRetries, ShouldEqual, 5)
+				So(policy.MinBackoff, ShouldEqual, time.Second)
+				So(policy.MaxBackoff, ShouldEqual, time.Minute)
+				So(policy.ShouldRetryStatus(503), ShouldBeTrue)
+				So(policy.ShouldRetryStatus(429), ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	Convey("Given a retry policy", t, func() {
+		policy := NewRetryPolicy(Config{MinRetryBackoff: 100 * time.Millisecond, MaxRetryBackoff: time.Second})
+
+		Convey("Then the backoff doubles with each attempt, less up to half for jitter", func() {
+			for attempt, expected := range map[int]time.Duration{
+				1: 100 * time.Millisecond,
+				2: 200 * time.Millisecond,
+				3: 400 * time.Millisecond,
+				4: 800 * time.Millisecond,
+			} {
+				for i := 0; i < 20; i++ {
+					backoff := policy.Backoff(attempt)
+					So(backoff, ShouldBeLessThanOrEqualTo, expected)
+					So(backoff, ShouldBeGreaterThan, expected/2)
+				}
+			}
+		})
+
+		Convey("Then the backoff is capped at the maximum", func() {
+			So(policy.Backoff(5), ShouldBeLessThanOrEqualTo, time.Second)
+			So(policy.Backoff(1000), ShouldBeGreaterThan, 500*time.Millisecond)
+		})
+
+		Convey("When Wait is called with a cancelled context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			Convey("Then it returns the context error", func() {
+				So(policy.Wait(ctx, 10), ShouldEqual, context.Canceled)
+			})
+		})
+	})
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+)
+
+// ScanOptions are the options for ParallelScan
+type ScanOptions struct {
+	Slices    int           // The number of slices the search is split into, which must be at least 1
+	Workers   int           // The most slices scrolled through at once, defaults to Slices
+	KeepAlive time.Duration // How long the scroll of each slice is kept alive between pages, defaults to DefaultScrollKeepAlive
+}
+
+// SliceError is the failure of one slice of a parallel scan
+type SliceError struct {
+	Slice int
+	Err   error
+}
+
+func (e *SliceError) Error() string {
+	return fmt.Sprintf("slice %d failed: %v", e.Slice, e.Err)
+}
+
+// Unwrap returns the error of the slice, so that errors.Is and errors.As can match it.
+func (e *SliceError) Unwrap() error {
+	return e.Err
+}
+
+// Scan is a parallel scan started by ParallelScan. The hits of every slice are merged into a single channel,
+// returned by Hits, which must be read until it is closed or else the context of the scan cancelled.
+type Scan[T any] struct {
+	hits chan Hit[T]
+	done chan struct{}
+
+	mu   sync.Mutex
+	errs []*SliceError
+}
+
+// sliceSearch is the slice clause of a sliced scroll
+type sliceSearch struct {
+	ID  int `json:"id"`
+	Max int `json:"max"`
+}
+
+// ParallelScan splits search into slices using sliced scroll and scrolls through up to opts.Workers of them at
+// once, sending every hit to the channel returned by Hits. Each slice is scrolled with a ScrollIterator, so its
+// page size is the size of the search and its scroll is always cleared.
+//
+// A slice that fails does not stop the others: its error is recorded as a SliceError and returned by Wait once
+// every slice has finished. Cancelling ctx stops every slice.
+func ParallelScan[T any](ctx context.Context, cli Client, search Search, opts *ScanOptions) (*Scan[T], error) {
+	if opts == nil {
+		opts = &ScanOptions{}
+	}
+
+	if opts.Slices < 1 {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("slices must be at least 1, got %d", opts.Slices),
+		}
+	}
+
+	workers := opts.Workers
+	if workers <= 0 || workers > opts.Slices {
+		workers = opts.Slices
+	}
+
+	searches, err := sliceSearches(search, opts.Slices)
+	if err != nil {
+		return nil, esError.StatusError{
+			Err: fmt.Errorf("failed to build sliced search: %w", err),
+		}
+	}
+
+	s := &Scan[T]{
+		hits: make(chan Hit[T], workers),
+		done: make(chan struct{}),
+	}
+
+	slices := make(chan int, opts.Slices)
+	for i := range opts.Slices {
+		slices <- i
+	}
+	close(slices)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for slice := range slices {
+				it := NewScrollIterator[T](cli, searches[slice], &ScrollOptions{KeepAlive: opts.KeepAlive})
+				if err := s.scroll(ctx, it); err != nil {
+					s.fail(slice, err)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(s.hits)
+		close(s.done)
+	}()
+
+	return s, nil
+}
+
+// sliceSearches returns a copy of search for each slice. A single slice is the search unchanged.
+func sliceSearches(search Search, slices int) ([]Search, error) {
+	if slices == 1 {
+		return []Search{search}, nil
+	}
+
+	body := make(map[string]json.RawMessage)
+	if len(search.Query) > 0 {
+		if err := json.Unmarshal(search.Query, &body); err != nil {
+			return nil, err
+		}
+	}
+
+	searches := make([]Search, slices)
+	for i := range searches {
+		slice, err := json.Marshal(sliceSearch{ID: i, Max: slices})
+		if err != nil {
+			return nil, err
+		}
+		body["slice"] = slice
+
+		query, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		searches[i] = Search{Header: search.Header, Query: query}
+	}
+
+	return searches, nil
+}
+
+// scroll sends every hit of a slice to the hits channel, stopping if ctx is done
+func (s *Scan[T]) scroll(ctx context.Context, it *ScrollIterator[T]) error {
+	defer it.Close(ctx) //nolint:errcheck // the scroll is cleared by the iterator unless the slice stops early, when a failure to clear it is not reported
+
+	for {
+		hits, err := it.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, hit := range hits {
+			select {
+			case s.hits <- hit:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func (s *Scan[T]) fail(slice int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errs = append(s.errs, &SliceError{Slice: slice, Err: err})
+}
+
+// Hits returns the channel of hits from every slice, which is closed once every slice has finished
+func (s *Scan[T]) Hits() <-chan Hit[T] {
+	return s.hits
+}
+
+// Wait waits for every slice to finish and returns the errors of the slices that failed, joined, or nil if
+// every slice succeeded. Hits must be read until it is closed, or the context of the scan cancelled, for Wait
+// to return.
+func (s *Scan[T]) Wait() error {
+	<-s.done
+
+	sliceErrs := s.Errors()
+	errs := make([]error, 0, len(sliceErrs))
+	for _, err := range sliceErrs {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Errors returns the errors of the slices that have failed so far, in slice order
+func (s *Scan[T]) Errors() []*SliceError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	errs := append([]*SliceError{}, s.errs...)
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Slice < errs[j].Slice })
+
+	return errs
+}
@@ -0,0 +1,138 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/mocks"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParallelScan(t *testing.T) {
+	ctx := context.Background()
+	search := client.Search{
+		Header: client.Header{Index: "docs"},
+		Query:  []byte(`{"size":4}`),
+	}
+
+	Convey("Given an index with 25 documents", t, func() {
+		esClient := newScrollClient(25)
+
+		Convey("When it is scanned in 3 slices by 2 workers", func() {
+			scan, err := client.ParallelScan[testScrollDocument](ctx, esClient, search, &client.ScanOptions{Slices: 3, Workers: 2})
+			So(err, ShouldBeNil)
+
+			var seen []int
+			for hit := range scan.Hits() {
+				seen = append(seen, hit.Source.N)
+			}
+			sort.Ints(seen)
+
+			Convey("Then every document is returned exactly once", func() {
+				So(scan.Wait(), ShouldBeNil)
+				So(seen, ShouldHaveLength, 25)
+				for i, n := range seen {
+					So(n, ShouldEqual, i+1)
+				}
+			})
+
+			Convey("Then every scroll is cleared", func() {
+				So(esClient.OpenScrolls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the scan is cancelled before the hits are read", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			scan, err := client.ParallelScan[testScrollDocument](ctx, esClient, search, &client.ScanOptions{Slices: 2})
+			So(err, ShouldBeNil)
+
+			cancel()
+			for range scan.Hits() {
+			}
+
+			Convey("Then the context error is reported and every scroll is cleared", func() {
+				So(errors.Is(scan.Wait(), context.Canceled), ShouldBeTrue)
+				So(esClient.OpenScrolls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a cluster where one slice fails", t, func() {
+		errShard := errors.New("shard failure")
+
+		var mu sync.Mutex
+		running, maxRunning := 0, 0
+		esClient := &mocks.ClientMock{
+			ScrollFunc: func(_ context.Context, search client.Search, _ time.Duration) ([]byte, error) {
+				mu.Lock()
+				running++
+				maxRunning = max(maxRunning, running)
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+
+				var body struct {
+					Slice struct {
+						ID  int `json:"id"`
+						Max int `json:"max"`
+					} `json:"slice"`
+				}
+				if err := json.Unmarshal(search.Query, &body); err != nil {
+					return nil, err
+				}
+				if body.Slice.ID == 1 {
+					return nil, errShard
+				}
+				return []byte(`{"hits":{"hits":[{"_id":"1","_source":{"n":1}}]}}`), nil
+			},
+			ScrollNextFunc: func(context.Context, string, time.Duration) ([]byte, error) {
+				return []byte(`{"hits":{"hits":[]}}`), nil
+			},
+		}
+
+		Convey("When it is scanned in 4 slices by 2 workers", func() {
+			scan, err := client.ParallelScan[testScrollDocument](ctx, esClient, search, &client.ScanOptions{Slices: 4, Workers: 2})
+			So(err, ShouldBeNil)
+
+			hits := 0
+			for range scan.Hits() {
+				hits++
+			}
+			err = scan.Wait()
+
+			Convey("Then the other slices are still scanned", func() {
+				So(hits, ShouldEqual, 3)
+				So(esClient.ScrollCalls(), ShouldHaveLength, 4)
+			})
+
+			Convey("Then no more than 2 slices are scrolled at once", func() {
+				So(maxRunning, ShouldBeLessThanOrEqualTo, 2)
+			})
+
+			Convey("Then the error of the failed slice is reported", func() {
+				So(errors.Is(err, errShard), ShouldBeTrue)
+				So(scan.Errors(), ShouldHaveLength, 1)
+				So(scan.Errors()[0].Slice, ShouldEqual, 1)
+				So(err.Error(), ShouldContainSubstring, "slice 1 failed")
+			})
+		})
+	})
+
+	Convey("Given no slices", t, func() {
+		Convey("When ParallelScan is called", func() {
+			_, err := client.ParallelScan[testScrollDocument](ctx, &mocks.ClientMock{}, search, nil)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
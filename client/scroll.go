@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"time"
+)
+
+// DefaultScrollKeepAlive is how long a scroll is kept alive between pages by default
+const DefaultScrollKeepAlive = time.Minute
+
+// clearScrollTimeout bounds how long clearing a scroll may take once the context of the iterator is done
+const clearScrollTimeout = 10 * time.Second
+
+// ScrollOptions are the options for NewScrollIterator
+type ScrollOptions struct {
+	KeepAlive time.Duration // How long the scroll is kept alive between pages, defaults to DefaultScrollKeepAlive
+}
+
+// ScrollIterator walks every hit of a search a page at a time using the scroll API, decoding the source of
+// each hit into T. The page size is the size of the search, which defaults to 10.
+//
+// The scroll is cleared once every hit has been returned, when an error occurs, including the context being
+// done, and on Close. Close should always be called, e.g. deferred, so that a scroll is never left open on the
+// cluster when the caller stops early. A ScrollIterator is not safe for concurrent use.
+type ScrollIterator[T any] struct {
+	cli       Client
+	search    Search
+	keepAlive time.Duration
+	scrollID  string
+	total     *Total
+	started   bool
+	done      bool
+}
+
+// NewScrollIterator returns an iterator over the hits of search. The scroll is not opened until the first call to Next.
+func NewScrollIterator[T any](cli Client, search Search, opts *ScrollOptions) *ScrollIterator[T] {
+	if opts == nil {
+		opts = &ScrollOptions{}
+	}
+
+	keepAlive := opts.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = DefaultScrollKeepAlive
+	}
+
+	return &ScrollIterator[T]{
+		cli:       cli,
+		search:    search,
+		keepAlive: keepAlive,
+	}
+}
+
+// Next returns the next page of hits, opening the scroll on the first call. Once every hit has been returned the
+// scroll is cleared and io.EOF is returned.
+func (it *ScrollIterator[T]) Next(ctx context.Context) ([]Hit[T], error) {
+	if it.done {
+		return nil, io.EOF
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, it.fail(ctx, err)
+	}
+
+	var data []byte
+	var err error
+	if !it.started {
+		it.started = true
+		data, err = it.cli.Scroll(ctx, it.search, it.keepAlive)
+	} else {
+		data, err = it.cli.ScrollNext(ctx, it.scrollID, it.keepAlive)
+	}
+	if err != nil {
+		return nil, it.fail(ctx, err)
+	}
+
+	res, err := decodeResponse[SearchResponse[T]](data, "scroll")
+	if err != nil {
+		return nil, it.fail(ctx, err)
+	}
+
+	// The scroll ID may change between pages, and the latest one must be used
+	if res.ScrollID != "" {
+		it.scrollID = res.ScrollID
+	}
+
+	if it.total == nil {
+		it.total = &res.Hits.Total
+	}
+
+	if len(res.Hits.Hits) == 0 {
+		if err := it.Close(ctx); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	return res.Hits.Hits, nil
+}
+
+// Total returns the total number of hits of the search, or nil if the scroll has not been opened yet
+func (it *ScrollIterator[T]) Total() *Total {
+	return it.total
+}
+
+// Close clears the scroll, if it is open. The scroll is cleared even if ctx is done. It is safe to call Close
+// more than once.
+func (it *ScrollIterator[T]) Close(ctx context.Context) error {
+	it.done = true
+
+	if it.scrollID == "" {
+		return nil
+	}
+
+	scrollID := it.scrollID
+	it.scrollID = ""
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), clearScrollTimeout)
+	defer cancel()
+
+	return it.cli.ClearScroll(ctx, []string{scrollID})
+}
+
+// Pages returns an iterator over the remaining pages of hits. The scroll is cleared when the loop ends, whether
+// every page has been returned, an error has been yielded or the loop has been broken out of.
+func (it *ScrollIterator[T]) Pages(ctx context.Context) iter.Seq2[[]Hit[T], error] {
+	return func(yield func([]Hit[T], error) bool) {
+		defer it.Close(ctx) //nolint:errcheck // a failure to clear the scroll cannot be reported once the loop has ended
+
+		for {
+			hits, err := it.Next(ctx)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(hits, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the remaining hits, one at a time. As with Pages, the scroll is cleared when the
+// loop ends.
+func (it *ScrollIterator[T]) All(ctx context.Context) iter.Seq2[Hit[T], error] {
+	return func(yield func(Hit[T], error) bool) {
+		for hits, err := range it.Pages(ctx) {
+			if err != nil {
+				yield(Hit[T]{}, err)
+				return
+			}
+			for _, hit := range hits {
+				if !yield(hit, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// fail clears the scroll after an error, returning the error along with any failure to clear it
+func (it *ScrollIterator[T]) fail(ctx context.Context, err error) error {
+	return errors.Join(err, it.Close(ctx))
+}
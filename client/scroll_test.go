@@ -0,0 +1,175 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/fake"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/mocks"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testScrollDocument struct {
+	N int `json:"n"`
+}
+
+// newScrollClient returns a fake client with n documents in the "docs" index, with IDs 1 to n
+func newScrollClient(n int) *fake.Client {
+	cli := fake.NewClient()
+	for i := 1; i <= n; i++ {
+		_, err := cli.AddDocument(context.Background(), "docs", fmt.Sprint(i), []byte(fmt.Sprintf(`{"n":%d}`, i)), nil)
+		So(err, ShouldBeNil)
+	}
+	return cli
+}
+
+func TestScrollIterator(t *testing.T) {
+	ctx := context.Background()
+	search := client.Search{
+		Header: client.Header{Index: "docs"},
+		Query:  []byte(`{"size":10,"sort":[{"n":"asc"}]}`),
+	}
+
+	Convey("Given an index with 25 documents", t, func() {
+		esClient := newScrollClient(25)
+
+		Convey("When the documents are scrolled through with Next", func() {
+			it := client.NewScrollIterator[testScrollDocument](esClient, search, nil)
+			defer it.Close(ctx)
+
+			var sizes []int
+			var last int
+			for {
+				hits, err := it.Next(ctx)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				So(err, ShouldBeNil)
+				sizes = append(sizes, len(hits))
+				last = hits[len(hits)-1].Source.N
+			}
+
+			Convey("Then every document is returned a page at a time", func() {
+				So(sizes, ShouldResemble, []int{10, 10, 5})
+				So(last, ShouldEqual, 25)
+				So(it.Total().Value, ShouldEqual, 25)
+			})
+
+			Convey("Then the scroll is cleared", func() {
+				So(esClient.OpenScrolls(), ShouldBeEmpty)
+			})
+
+			Convey("Then further calls to Next return io.EOF", func() {
+				_, err := it.Next(ctx)
+				So(err, ShouldEqual, io.EOF)
+			})
+		})
+
+		Convey("When the loop over All is broken out of", func() {
+			it := client.NewScrollIterator[testScrollDocument](esClient, search, nil)
+
+			var seen []int
+			for hit, err := range it.All(ctx) {
+				So(err, ShouldBeNil)
+				seen = append(seen, hit.Source.N)
+				if len(seen) == 12 {
+					break
+				}
+			}
+
+			Convey("Then the hits up to the break are returned and the scroll is cleared", func() {
+				So(seen, ShouldHaveLength, 12)
+				So(seen[11], ShouldEqual, 12)
+				So(esClient.OpenScrolls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the context is cancelled part way through", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			it := client.NewScrollIterator[testScrollDocument](esClient, search, nil)
+
+			_, err := it.Next(ctx)
+			So(err, ShouldBeNil)
+			So(esClient.OpenScrolls(), ShouldHaveLength, 1)
+
+			cancel()
+			_, err = it.Next(ctx)
+
+			Convey("Then the context error is returned and the scroll is cleared", func() {
+				So(errors.Is(err, context.Canceled), ShouldBeTrue)
+				So(esClient.OpenScrolls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a scroll that expires between pages", t, func() {
+		errExpired := errors.New("search_context_missing_exception")
+		esClient := &mocks.ClientMock{
+			ScrollFunc: func(context.Context, client.Search, time.Duration) ([]byte, error) {
+				return []byte(`{"_scroll_id":"scroll-1","hits":{"total":{"value":20},"hits":[{"_id":"1","_source":{"n":1}}]}}`), nil
+			},
+			ScrollNextFunc: func(context.Context, string, time.Duration) ([]byte, error) {
+				return nil, errExpired
+			},
+			ClearScrollFunc: func(context.Context, []string) error { return nil },
+		}
+
+		Convey("When the pages are iterated over", func() {
+			it := client.NewScrollIterator[testScrollDocument](esClient, search, &client.ScrollOptions{KeepAlive: 5 * time.Minute})
+
+			var pages int
+			var iterErr error
+			for _, err := range it.Pages(ctx) {
+				if err != nil {
+					iterErr = err
+					continue
+				}
+				pages++
+			}
+
+			Convey("Then the error is yielded after the first page and the scroll is cleared", func() {
+				So(pages, ShouldEqual, 1)
+				So(errors.Is(iterErr, errExpired), ShouldBeTrue)
+				So(esClient.ScrollCalls()[0].KeepAlive, ShouldEqual, 5*time.Minute)
+				So(esClient.ScrollNextCalls()[0].ScrollID, ShouldEqual, "scroll-1")
+				So(esClient.ClearScrollCalls(), ShouldHaveLength, 1)
+				So(esClient.ClearScrollCalls()[0].ScrollIDs, ShouldResemble, []string{"scroll-1"})
+			})
+		})
+	})
+
+	Convey("Given a client returning the error response of an expired scroll as the body", t, func() {
+		esClient := &mocks.ClientMock{
+			ScrollFunc: func(context.Context, client.Search, time.Duration) ([]byte, error) {
+				return []byte(`{"_scroll_id":"scroll-1","hits":{"total":{"value":20},"hits":[{"_id":"1","_source":{"n":1}}]}}`), nil
+			},
+			ScrollNextFunc: func(context.Context, string, time.Duration) ([]byte, error) {
+				return []byte(`{"error":{"root_cause":[{"type":"search_context_missing_exception","reason":"No search context found for id [1]"}],` +
+					`"type":"search_phase_execution_exception","reason":"all shards failed"},"status":404}`), nil
+			},
+			ClearScrollFunc: func(context.Context, []string) error { return nil },
+		}
+
+		Convey("When the second page is requested", func() {
+			it := client.NewScrollIterator[testScrollDocument](esClient, search, nil)
+			_, err := it.Next(ctx)
+			So(err, ShouldBeNil)
+			_, err = it.Next(ctx)
+
+			Convey("Then the error response is returned as a StatusError that can be matched", func() {
+				So(esError.ErrorStatus(err), ShouldEqual, 404)
+				So(errors.Is(err, esError.ErrNotFound), ShouldBeTrue)
+
+				var esErr *esError.ESError
+				So(errors.As(err, &esErr), ShouldBeTrue)
+				So(esErr.RootCause[0].Type, ShouldEqual, "search_context_missing_exception")
+			})
+		})
+	})
+}
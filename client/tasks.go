@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+)
+
+// DefaultTaskPollInterval is how often WaitForTask gets the status of a task by default
+const DefaultTaskPollInterval = time.Second
+
+// ErrTaskFailures is matched, with errors.Is, by the error returned by WaitForTask along with a completed task
+// whose response has failures, such as documents a reindex could not write
+var ErrTaskFailures = errors.New("task completed with failures")
+
+// WaitForTaskOptions are the options for WaitForTask
+type WaitForTaskOptions struct {
+	PollInterval time.Duration        // How often the task is polled, defaults to DefaultTaskPollInterval
+	OnProgress   func(task *TaskInfo) // Called with each status of the task polled before it completes
+}
+
+// WaitForTask polls a task, such as a reindex started without waiting for completion, until it completes or the
+// context is done. The completed task is returned, holding the result of the request in its Response. If the task
+// itself failed it is returned along with an error describing the failure, and if it completed with failures in its
+// Response it is returned along with an error matching ErrTaskFailures.
+func WaitForTask(ctx context.Context, cli Client, taskID string, opts *WaitForTaskOptions) (*TaskResponse, error) {
+	if opts == nil {
+		opts = &WaitForTaskOptions{}
+	}
+
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultTaskPollInterval
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for task %s: %w", taskID, ctx.Err())
+		case <-timer.C:
+		}
+
+		task, err := cli.GetTask(ctx, taskID, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if task.Completed {
+			if task.Error != nil {
+				return task, esError.StatusError{
+					Err: fmt.Errorf("task %s failed: %s: %s", taskID, task.Error.Type, task.Error.Reason),
+				}
+			}
+			if task.Response != nil && len(task.Response.Failures) > 0 {
+				return task, esError.StatusError{
+					Err: fmt.Errorf("task %s: %w", taskID, task.Response.Err()),
+				}
+			}
+			return task, nil
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(&task.Task)
+		}
+
+		timer.Reset(interval)
+	}
+}
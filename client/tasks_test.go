@@ -0,0 +1,161 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/mocks"
+	esError "github.com/ONSdigital/dp-elasticsearch/v5/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWaitForTask(t *testing.T) {
+	ctx := context.Background()
+	opts := &client.WaitForTaskOptions{PollInterval: time.Millisecond}
+
+	Convey("Given a task that completes on the third poll", t, func() {
+		polls := 0
+		esClient := &mocks.ClientMock{
+			GetTaskFunc: func(_ context.Context, taskID string, _ *client.GetTaskOptions) (*client.TaskResponse, error) {
+				polls++
+				task := &client.TaskResponse{
+					Task: client.TaskInfo{Node: "node-1", ID: 7, Status: &client.TaskStatus{Total: 10, Created: int64(polls * 3)}},
+				}
+				if polls == 3 {
+					task.Completed = true
+					task.Response = &client.BulkByScrollResponse{Total: 10, Created: 10}
+				}
+				return task, nil
+			},
+		}
+
+		Convey("When WaitForTask is called with a progress callback", func() {
+			var progress []int64
+			res, err := client.WaitForTask(ctx, esClient, "node-1:7", &client.WaitForTaskOptions{
+				PollInterval: time.Millisecond,
+				OnProgress: func(task *client.TaskInfo) {
+					progress = append(progress, task.Status.Created)
+				},
+			})
+
+			Convey("Then the result of the completed task is returned", func() {
+				So(err, ShouldBeNil)
+				So(res.Response.Created, ShouldEqual, 10)
+				So(esClient.GetTaskCalls()[0].TaskID, ShouldEqual, "node-1:7")
+			})
+
+			Convey("Then the progress of the task is reported until it completes", func() {
+				So(progress, ShouldResemble, []int64{3, 6})
+			})
+		})
+	})
+
+	Convey("Given a task that fails", t, func() {
+		esClient := &mocks.ClientMock{
+			GetTaskFunc: func(context.Context, string, *client.GetTaskOptions) (*client.TaskResponse, error) {
+				return &client.TaskResponse{
+					Completed: true,
+					Error:     &esError.ErrorCause{Type: "index_not_found_exception", Reason: "no such index [old]"},
+				}, nil
+			},
+		}
+
+		Convey("When WaitForTask is called", func() {
+			res, err := client.WaitForTask(ctx, esClient, "node-1:7", opts)
+
+			Convey("Then the task is returned with an error describing the failure", func() {
+				So(res, ShouldNotBeNil)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "index_not_found_exception")
+			})
+		})
+	})
+
+	Convey("Given a task that completes with failures", t, func() {
+		esClient := &mocks.ClientMock{
+			GetTaskFunc: func(context.Context, string, *client.GetTaskOptions) (*client.TaskResponse, error) {
+				return &client.TaskResponse{
+					Completed: true,
+					Response: &client.BulkByScrollResponse{
+						Total:   3,
+						Created: 1,
+						Failures: []client.ByQueryFailure{
+							{Index: "new", ID: "2", Status: 400, Cause: &esError.ErrorCause{Type: "mapper_parsing_exception", Reason: "failed to parse field [year]"}},
+							{Index: "new", ID: "3", Status: 400, Cause: &esError.ErrorCause{Type: "mapper_parsing_exception", Reason: "failed to parse field [year]"}},
+						},
+					},
+				}, nil
+			},
+		}
+
+		Convey("When WaitForTask is called", func() {
+			res, err := client.WaitForTask(ctx, esClient, "node-1:7", opts)
+
+			Convey("Then the task is returned with an error matching ErrTaskFailures that describes the failures", func() {
+				So(res, ShouldNotBeNil)
+				So(res.Response.Failures, ShouldHaveLength, 2)
+				So(errors.Is(err, client.ErrTaskFailures), ShouldBeTrue)
+				So(err.Error(), ShouldContainSubstring, "2 failures")
+				So(err.Error(), ShouldContainSubstring, "document 2 in new with mapper_parsing_exception")
+			})
+		})
+	})
+
+	Convey("Given a task that never completes", t, func() {
+		esClient := &mocks.ClientMock{
+			GetTaskFunc: func(context.Context, string, *client.GetTaskOptions) (*client.TaskResponse, error) {
+				return &client.TaskResponse{}, nil
+			},
+		}
+
+		Convey("When WaitForTask is called with a context that times out", func() {
+			ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer cancel()
+
+			_, err := client.WaitForTask(ctx, esClient, "node-1:7", opts)
+
+			Convey("Then the context error is returned", func() {
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestTasksResponse(t *testing.T) {
+	Convey("Given a tasks response grouped by node", t, func() {
+		body := `{"nodes":{"node-1":{"name":"es-1","tasks":{"node-1:9":{"node":"node-1","id":9,"action":"indices:data/write/reindex"},` +
+			`"node-1:3":{"node":"node-1","id":3,"action":"indices:data/write/reindex"}}}}}`
+
+		Convey("When it is decoded", func() {
+			var res client.TasksResponse
+			err := json.Unmarshal([]byte(body), &res)
+
+			Convey("Then the tasks are listed in order", func() {
+				So(err, ShouldBeNil)
+				So(res.Tasks, ShouldHaveLength, 2)
+				So(res.Tasks[0].TaskID(), ShouldEqual, "node-1:3")
+				So(res.Tasks[1].TaskID(), ShouldEqual, "node-1:9")
+				So(res.Err(), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a tasks response with a task failure", t, func() {
+		body := `{"tasks":[],"task_failures":[{"task_id":3,"node_id":"node-1","status":"INTERNAL_SERVER_ERROR",` +
+			`"reason":{"type":"illegal_argument_exception","reason":"task isn't cancellable"}}]}`
+
+		Convey("When it is decoded", func() {
+			var res client.TasksResponse
+			So(json.Unmarshal([]byte(body), &res), ShouldBeNil)
+
+			Convey("Then Err describes the failure", func() {
+				So(res.Err(), ShouldNotBeNil)
+				So(res.Err().Error(), ShouldContainSubstring, "task node-1:3: illegal_argument_exception")
+			})
+		})
+	})
+}
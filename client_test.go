@@ -3,9 +3,11 @@ package elasticsearch_test
 import (
 	"testing"
 
-	elasticsearch "github.com/ONSdigital/dp-elasticsearch/v4"
-	"github.com/ONSdigital/dp-elasticsearch/v4/client"
-	v710 "github.com/ONSdigital/dp-elasticsearch/v4/client/elasticsearch/v710"
+	elasticsearch "github.com/ONSdigital/dp-elasticsearch/v5"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	v710 "github.com/ONSdigital/dp-elasticsearch/v5/client/elasticsearch/v710"
+	v8 "github.com/ONSdigital/dp-elasticsearch/v5/client/elasticsearch/v8"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/opensearch"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,7 +38,34 @@ func TestNewClient_WhenValidURLIsNotSpecified_ReturnsError(t *testing.T) {
 	assert.Nil(t, cli)
 }
 
-func TestNewClient_WhenOpenSearchClientLibraryIsRequested_ReturnsNotImplemented(t *testing.T) {
+func TestNewClient_ReturnsNewGoElasticClientVersion8(t *testing.T) {
+	t.Parallel()
+	cfg := client.Config{
+		ClientLib: client.GoElasticV8,
+		Address:   "http://some-url.com",
+	}
+
+	cli, err := elasticsearch.NewClient(cfg)
+
+	assert.Nil(t, err)
+	assert.NotNil(t, cli)
+	assert.IsType(t, cli, &v8.ESClient{})
+}
+
+func TestNewClient_WhenValidURLIsNotSpecifiedForVersion8_ReturnsError(t *testing.T) {
+	t.Parallel()
+	cfg := client.Config{
+		ClientLib: client.GoElasticV8,
+		Address:   "invalid-url",
+	}
+
+	cli, err := elasticsearch.NewClient(cfg)
+
+	assert.NotNil(t, err)
+	assert.Nil(t, cli)
+}
+
+func TestNewClient_ReturnsNewOpenSearchClient(t *testing.T) {
 	t.Parallel()
 	cfg := client.Config{
 		ClientLib: client.OpenSearch,
@@ -45,8 +74,21 @@ func TestNewClient_WhenOpenSearchClientLibraryIsRequested_ReturnsNotImplemented(
 
 	cli, err := elasticsearch.NewClient(cfg)
 
+	assert.Nil(t, err)
+	assert.NotNil(t, cli)
+	assert.IsType(t, cli, &opensearch.Client{})
+}
+
+func TestNewClient_WhenValidOpenSearchURLIsNotSpecified_ReturnsError(t *testing.T) {
+	t.Parallel()
+	cfg := client.Config{
+		ClientLib: client.OpenSearch,
+		Address:   "invalid-url",
+	}
+
+	cli, err := elasticsearch.NewClient(cfg)
+
 	assert.NotNil(t, err)
-	assert.Contains(t, err.Error(), "not implemented")
 	assert.Nil(t, cli)
 }
 
@@ -0,0 +1,100 @@
+// Command replay-dead-letters re-submits the bulk indexer items written to a dead letter file by
+// client.FileDeadLetterSink, once the cause of their failure has been fixed.
+//
+//	replay-dead-letters -file dead-letters.ndjson -addr http://localhost:9200 -failed still-failing.ndjson
+//
+// Items that fail again are written to the file given by -failed, if any, and the command exits with a non-zero
+// status.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	dpEs "github.com/ONSdigital/dp-elasticsearch/v5"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "replay-dead-letters:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("replay-dead-letters", flag.ContinueOnError)
+	file := flags.String("file", "", "dead letter file to replay (required)")
+	failed := flags.String("failed", "", "file to write items that fail again to")
+	addr := flags.String("addr", "http://localhost:9200", "address of the cluster")
+	lib := flags.String("lib", string(client.GoElasticV710), "client library: GoElastic_v710, GoElastic_v8 or OpenSearch")
+	workers := flags.Int("workers", client.DefaultBulkIndexerWorkers, "number of workers flushing bulk requests")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *file == "" {
+		flags.Usage()
+		return errors.New("-file is required")
+	}
+
+	if *failed == *file {
+		return errors.New("-failed must not be the file being replayed")
+	}
+
+	input, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	cfg := &client.BulkIndexerConfig{NumWorkers: *workers}
+	if *failed != "" {
+		sink, err := client.NewFileDeadLetterSink(*failed)
+		if err != nil {
+			return err
+		}
+		defer sink.Close()
+		cfg.DeadLetter = sink
+	}
+
+	esClient, err := dpEs.NewClient(client.Config{
+		ClientLib: client.Library(*lib),
+		Address:   *addr,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	indexer, err := esClient.OpenBulkIndexer(ctx, "replay-dead-letters", cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create bulk indexer: %w", err)
+	}
+
+	var failures atomic.Int64
+	onFailure := func(_ context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+		failures.Add(1)
+		if err == nil {
+			err = fmt.Errorf("%s: %s", res.Error.Type, res.Error.Reason)
+		}
+		fmt.Fprintf(os.Stderr, "%s of document %s in %s failed again: %v\n", item.Action, item.DocumentID, item.Index, err)
+	}
+
+	replayed, replayErr := client.ReplayDeadLetters(ctx, indexer, input, nil, onFailure)
+	closeErr := indexer.Close(ctx)
+	if err := errors.Join(replayErr, closeErr); err != nil {
+		return err
+	}
+
+	fmt.Printf("replayed %d items, %d failed\n", replayed, failures.Load())
+	if failures.Load() > 0 {
+		return fmt.Errorf("%d items failed again", failures.Load())
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors matched by ESError, for use with errors.Is. ErrNotFound matches any response with a 404 status,
+// such as a missing index or document.
+var (
+	ErrNotFound              = errors.New("not found")
+	ErrIndexNotFound         = errors.New("index not found")
+	ErrVersionConflict       = errors.New("version conflict")
+	ErrResourceAlreadyExists = errors.New("resource already exists")
+)
+
+// errorTypes maps the error types returned by elasticsearch to the sentinel errors they match
+var errorTypes = map[string]error{
+	"index_not_found_exception":         ErrIndexNotFound,
+	"version_conflict_engine_exception": ErrVersionConflict,
+	"resource_already_exists_exception": ErrResourceAlreadyExists,
+}
+
+// ErrorCause is the cause of an error response, as found in its root_cause and caused_by fields
+type ErrorCause struct {
+	Type     string      `json:"type"`
+	Reason   string      `json:"reason"`
+	Index    string      `json:"index,omitempty"`
+	CausedBy *ErrorCause `json:"caused_by,omitempty"`
+}
+
+// ShardFailure is the failure of a single shard during a search
+type ShardFailure struct {
+	Shard  int        `json:"shard"`
+	Index  string     `json:"index"`
+	Node   string     `json:"node"`
+	Reason ErrorCause `json:"reason"`
+}
+
+// ESError is an error response from elasticsearch, or opensearch, parsed from the response body.
+// Its Error method returns the raw body so that it reads the same as the flattened errors returned previously.
+type ESError struct {
+	StatusCode   int
+	Type         string
+	Reason       string
+	Index        string
+	RootCause    []ErrorCause
+	CausedBy     *ErrorCause
+	FailedShards []ShardFailure
+	Body         []byte
+
+	product string
+}
+
+// NewESError parses the body of an error response from the named product, such as elasticsearch.
+// A body that is not in the usual error format is kept in Body with the other fields left empty.
+func NewESError(product string, statusCode int, body []byte) *ESError {
+	esErr := &ESError{
+		StatusCode: statusCode,
+		Body:       body,
+		product:    product,
+	}
+
+	var res struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil || len(res.Error) == 0 {
+		return esErr
+	}
+
+	// Some errors, such as those from the multi search header, are returned as a plain string
+	var reason string
+	if err := json.Unmarshal(res.Error, &reason); err == nil {
+		esErr.Reason = reason
+		return esErr
+	}
+
+	var details struct {
+		ErrorCause
+		RootCause    []ErrorCause   `json:"root_cause"`
+		FailedShards []ShardFailure `json:"failed_shards"`
+	}
+	if err := json.Unmarshal(res.Error, &details); err != nil {
+		return esErr
+	}
+
+	esErr.Type = details.Type
+	esErr.Reason = details.Reason
+	esErr.Index = details.Index
+	esErr.CausedBy = details.CausedBy
+	esErr.RootCause = details.RootCause
+	esErr.FailedShards = details.FailedShards
+
+	return esErr
+}
+
+// Error returns the response body, prefixed with the product it came from
+func (e *ESError) Error() string {
+	return fmt.Sprintf("error response from %s: %s", e.product, string(e.Body))
+}
+
+// Status returns the HTTP status code of the response
+func (e *ESError) Status() int {
+	return e.StatusCode
+}
+
+// Is reports whether the error, or one of its root causes, is of the type matched by the target sentinel error.
+// ErrNotFound is matched by the status code instead.
+func (e *ESError) Is(target error) bool {
+	if target == ErrNotFound {
+		return e.StatusCode == http.StatusNotFound
+	}
+
+	if errorTypes[e.Type] == target {
+		return target != nil
+	}
+
+	for _, cause := range e.RootCause {
+		if errorTypes[cause.Type] == target {
+			return target != nil
+		}
+	}
+
+	return false
+}
+
+// IsIndexNotFound reports whether err is an error response for a missing index
+func IsIndexNotFound(err error) bool {
+	return errors.Is(err, ErrIndexNotFound)
+}
+
+// IsVersionConflict reports whether err is an error response for a version conflict, such as creating a
+// document that already exists or a failed optimistic concurrency check
+func IsVersionConflict(err error) bool {
+	return errors.Is(err, ErrVersionConflict)
+}
+
+// IsResourceAlreadyExists reports whether err is an error response for creating an index that already exists
+func IsResourceAlreadyExists(err error) bool {
+	return errors.Is(err, ErrResourceAlreadyExists)
+}
@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewESError(t *testing.T) {
+	Convey("Given an index not found error response", t, func() {
+		body := []byte(`{"error":{"root_cause":[{"type":"index_not_found_exception","reason":"no such index [missing]","index":"missing"}],` +
+			`"type":"index_not_found_exception","reason":"no such index [missing]","index":"missing"},"status":404}`)
+
+		Convey("When it is parsed", func() {
+			esErr := NewESError("elasticsearch", 404, body)
+
+			Convey("Then its details are set", func() {
+				So(esErr.Status(), ShouldEqual, 404)
+				So(esErr.Type, ShouldEqual, "index_not_found_exception")
+				So(esErr.Reason, ShouldEqual, "no such index [missing]")
+				So(esErr.Index, ShouldEqual, "missing")
+				So(esErr.RootCause, ShouldResemble, []ErrorCause{{
+					Type:   "index_not_found_exception",
+					Reason: "no such index [missing]",
+					Index:  "missing",
+				}})
+				So(esErr.Error(), ShouldEqual, "error response from elasticsearch: "+string(body))
+			})
+
+			Convey("Then it matches only the not found and index not found sentinels", func() {
+				So(errors.Is(esErr, ErrNotFound), ShouldBeTrue)
+				So(IsIndexNotFound(esErr), ShouldBeTrue)
+				So(IsVersionConflict(esErr), ShouldBeFalse)
+				So(IsResourceAlreadyExists(esErr), ShouldBeFalse)
+			})
+
+			Convey("Then it can be matched when wrapped in a StatusError", func() {
+				err := StatusError{Code: 404, Err: fmt.Errorf("error occured while trying to search documents: %w", esErr)}
+
+				So(IsIndexNotFound(err), ShouldBeTrue)
+
+				var target *ESError
+				So(errors.As(err, &target), ShouldBeTrue)
+				So(target.Index, ShouldEqual, "missing")
+			})
+		})
+	})
+
+	Convey("Given a search error response with shard failures", t, func() {
+		body := []byte(`{"error":{"root_cause":[{"type":"query_shard_exception","reason":"failed to create query","index":"my-index"}],` +
+			`"type":"search_phase_execution_exception","reason":"all shards failed","phase":"query",` +
+			`"failed_shards":[{"shard":0,"index":"my-index","node":"abc","reason":{"type":"query_shard_exception","reason":"failed to create query",` +
+			`"caused_by":{"type":"number_format_exception","reason":"For input string: \"x\""}}}]},"status":400}`)
+
+		Convey("When it is parsed", func() {
+			esErr := NewESError("opensearch", 400, body)
+
+			Convey("Then the shard failures are set", func() {
+				So(esErr.Type, ShouldEqual, "search_phase_execution_exception")
+				So(esErr.FailedShards, ShouldHaveLength, 1)
+				So(esErr.FailedShards[0].Index, ShouldEqual, "my-index")
+				So(esErr.FailedShards[0].Reason.Type, ShouldEqual, "query_shard_exception")
+				So(esErr.FailedShards[0].Reason.CausedBy.Type, ShouldEqual, "number_format_exception")
+				So(esErr.Error(), ShouldStartWith, "error response from opensearch: ")
+			})
+		})
+	})
+
+	Convey("Given a version conflict found only in the root causes", t, func() {
+		body := []byte(`{"error":{"root_cause":[{"type":"version_conflict_engine_exception","reason":"[1]: version conflict"}],` +
+			`"type":"wrapper_exception","reason":"wrapped"},"status":409}`)
+
+		Convey("Then it is matched as a version conflict", func() {
+			So(IsVersionConflict(NewESError("elasticsearch", 409, body)), ShouldBeTrue)
+			So(errors.Is(NewESError("elasticsearch", 409, body), ErrNotFound), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given an error response with a string error", t, func() {
+		esErr := NewESError("elasticsearch", 400, []byte(`{"error":"request body is required","status":400}`))
+
+		Convey("Then the reason is set", func() {
+			So(esErr.Type, ShouldBeEmpty)
+			So(esErr.Reason, ShouldEqual, "request body is required")
+		})
+	})
+
+	Convey("Given a response body that is not an error", t, func() {
+		esErr := NewESError("elasticsearch", 404, []byte(`{"_index":"my-index","_id":"1","result":"not_found"}`))
+
+		Convey("Then only the status and body are set", func() {
+			So(esErr.Status(), ShouldEqual, 404)
+			So(esErr.Type, ShouldBeEmpty)
+			So(IsIndexNotFound(esErr), ShouldBeFalse)
+			So(ErrorStatus(esErr), ShouldEqual, 404)
+		})
+	})
+}
+
+func TestStatusErrorNotFound(t *testing.T) {
+	Convey("Given a StatusError with a 404 status that does not wrap an error response", t, func() {
+		err := fmt.Errorf("failed to get document: %w", StatusError{Code: 404, Err: errors.New("document not found")})
+
+		Convey("Then it matches the not found sentinel only", func() {
+			So(errors.Is(err, ErrNotFound), ShouldBeTrue)
+			So(IsIndexNotFound(err), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given a StatusError with another status", t, func() {
+		err := StatusError{Code: 500, Err: errors.New("failed")}
+
+		Convey("Then it does not match the not found sentinel", func() {
+			So(errors.Is(err, ErrNotFound), ShouldBeFalse)
+		})
+	})
+}
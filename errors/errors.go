@@ -1,6 +1,9 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
 // Error represents a handler error. It provides methods for a HTTP status
 // code and embeds the built-in error interface.
@@ -24,6 +27,17 @@ func (e StatusError) Error() string {
 	return e.Err.Error()
 }
 
+// Unwrap returns the wrapped error, so that errors.Is and errors.As can match it.
+func (e StatusError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error has a 404 status when the target is ErrNotFound, so that errors raised without a
+// response from the cluster, such as by the fake client, match it too.
+func (e StatusError) Is(target error) bool {
+	return target == ErrNotFound && e.Code == http.StatusNotFound
+}
+
 // Returns our HTTP status code.
 func (e StatusError) Status() int {
 	return e.Code
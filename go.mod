@@ -1,4 +1,4 @@
-module github.com/ONSdigital/dp-elasticsearch/v4
+module github.com/ONSdigital/dp-elasticsearch/v5
 
 go 1.24.0
 
@@ -6,6 +6,8 @@ require (
 	github.com/ONSdigital/dp-healthcheck v1.6.4
 	github.com/ONSdigital/log.go/v2 v2.4.5
 	github.com/elastic/go-elasticsearch/v7 v7.10.0
+	github.com/elastic/go-elasticsearch/v8 v8.19.0
+	github.com/opensearch-project/opensearch-go/v2 v2.3.0
 	github.com/smartystreets/goconvey v1.8.1
 	github.com/stretchr/testify v1.10.0
 )
@@ -14,6 +16,7 @@ require (
 	github.com/ONSdigital/dp-api-clients-go/v2 v2.267.0 // indirect
 	github.com/ONSdigital/dp-net/v3 v3.3.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/elastic/elastic-transport-go/v8 v8.7.0 // indirect
 	github.com/fatih/color v1.18.0 // indirect
 	github.com/go-logr/logr v1.4.3 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
@@ -31,8 +34,3 @@ require (
 	golang.org/x/sys v0.39.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
-
-retract (
-	v4.0.0 // Contains invalid module version in package (v3)
-	v4.0.0-alpha // Published accidentally
-)
@@ -0,0 +1,311 @@
+package reindexer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/elastic/go-elasticsearch/v7/esutil"
+)
+
+// timestampFormat is the format of the timestamp suffix of each index created by the reindexer, chosen so that
+// index names sort in the order they were created. It is followed by the nanoseconds of the time, zero padded, so
+// that runs started within the same second are given different indices.
+const timestampFormat = "20060102150405"
+
+// DefaultHealthTimeout is how long the new index is waited on to reach the required health by default
+const DefaultHealthTimeout = time.Minute
+
+// rollbackTimeout bounds how long deleting the new index may take once a run has failed, which may be because
+// its context is done
+const rollbackTimeout = 30 * time.Second
+
+// Config holds the configuration of a Reindexer
+type Config struct {
+	Alias         string               // Alias searched by services, swapped over to each new index
+	IndexPrefix   string               // Prefix of the timestamped index names, defaults to the alias
+	Settings      []byte               // Settings and mappings the new index is created with
+	Retention     int                  // Number of previous indices kept once the alias is swapped. Older indices are deleted
+	WaitForStatus client.ClusterStatus // Health the new index must reach before the swap, defaults to green
+	HealthTimeout time.Duration        // How long to wait for the health, defaults to DefaultHealthTimeout
+	MaxFailures   int64                // Number of documents that may fail to index without the reindex failing
+
+	BulkIndexer *client.BulkIndexerConfig // Config of the bulk indexer documents are streamed through, such as its workers, flush size and dead letter sink. Defaults to the client defaults
+}
+
+// Document is a document to index into the new index
+type Document struct {
+	ID   string
+	Body []byte
+}
+
+// Source streams documents into the new index by calling add for each one. If add returns an error the source
+// must stop and return it.
+type Source func(ctx context.Context, add func(Document) error) error
+
+// Result is the outcome of a reindex
+type Result struct {
+	Index            string   // Index created, which the alias now points to
+	PreviousIndices  []string // Indices the alias pointed to before the swap
+	DeletedIndices   []string // Previous indices deleted as they were beyond the retention count
+	IndexedDocuments int64
+	FailedDocuments  int64
+}
+
+// Reindexer rebuilds the index behind an alias without downtime. Each run creates a new timestamped index,
+// streams documents into it and only swaps the alias over once the index is searchable and healthy, so that
+// services searching the alias never see a partly built index.
+type Reindexer struct {
+	client client.Client
+	cfg    Config
+	now    func() time.Time
+}
+
+// New returns a Reindexer for the alias in cfg
+func New(esClient client.Client, cfg Config) (*Reindexer, error) {
+	if esClient == nil {
+		return nil, errors.New("elasticsearch client should not be nil")
+	}
+
+	if cfg.Alias == "" {
+		return nil, errors.New("alias should not be empty")
+	}
+
+	if cfg.Retention < 0 {
+		return nil, errors.New("retention should not be negative")
+	}
+
+	if cfg.IndexPrefix == "" {
+		cfg.IndexPrefix = cfg.Alias
+	}
+
+	if cfg.WaitForStatus == "" {
+		cfg.WaitForStatus = client.ClusterStatusGreen
+	}
+
+	if cfg.HealthTimeout == 0 {
+		cfg.HealthTimeout = DefaultHealthTimeout
+	}
+
+	return &Reindexer{
+		client: esClient,
+		cfg:    cfg,
+		now:    time.Now,
+	}, nil
+}
+
+// Reindex creates a new index, indexes every document from source into it, waits for it to be refreshed and
+// healthy and then atomically swaps the alias over to it. Previous indices beyond the retention count are
+// then deleted.
+//
+// If any step up to and including the swap fails, the new index is deleted and the alias is left as it was.
+// A failure to delete previous indices does not undo the swap: the result is returned along with the error.
+func (r *Reindexer) Reindex(ctx context.Context, source Source) (*Result, error) {
+	res := &Result{Index: r.indexName(r.now())}
+	logData := log.Data{"alias": r.cfg.Alias, "index": res.Index}
+
+	if err := r.client.CreateIndex(ctx, res.Index, r.cfg.Settings); err != nil {
+		return nil, fmt.Errorf("failed to create index %s: %w", res.Index, err)
+	}
+	log.Info(ctx, "reindex: index created", logData)
+
+	if err := r.build(ctx, source, res); err != nil {
+		return nil, r.rollback(ctx, res.Index, err)
+	}
+
+	logData["indexed_documents"], logData["failed_documents"] = res.IndexedDocuments, res.FailedDocuments
+	log.Info(ctx, "reindex: documents indexed", logData)
+
+	previous, err := r.aliasedIndices(ctx)
+	if err != nil {
+		return nil, r.rollback(ctx, res.Index, err)
+	}
+	res.PreviousIndices = previous
+
+	if err := r.client.UpdateAliases(ctx, r.cfg.Alias, previous, []string{res.Index}); err != nil {
+		return nil, r.rollback(ctx, res.Index, fmt.Errorf("failed to swap alias %s: %w", r.cfg.Alias, err))
+	}
+
+	logData["previous_indices"] = previous
+	log.Info(ctx, "reindex: alias swapped", logData)
+
+	deleted, err := r.deleteExpired(ctx, res.Index)
+	res.DeletedIndices = deleted
+	if err != nil {
+		return res, fmt.Errorf("alias %s swapped to %s but previous indices could not be deleted: %w", r.cfg.Alias, res.Index, err)
+	}
+
+	return res, nil
+}
+
+// build indexes the documents from source into the new index, then refreshes it and waits for its health
+func (r *Reindexer) build(ctx context.Context, source Source, res *Result) error {
+	// The indexer is named after the new index, so that it is independent of any other indexer of the client
+	indexer, err := r.client.OpenBulkIndexer(ctx, "reindex-"+res.Index, r.cfg.BulkIndexer)
+	if err != nil {
+		return fmt.Errorf("failed to create bulk indexer: %w", err)
+	}
+
+	var indexed, failed atomic.Int64
+	var firstFailure error
+	var once sync.Once
+
+	onSuccess := func(context.Context, esutil.BulkIndexerItem, esutil.BulkIndexerResponseItem) {
+		indexed.Add(1)
+	}
+	onFailure := func(_ context.Context, item esutil.BulkIndexerItem, resItem esutil.BulkIndexerResponseItem, err error) {
+		failed.Add(1)
+		once.Do(func() {
+			if err == nil {
+				err = fmt.Errorf("%s: %s", resItem.Error.Type, resItem.Error.Reason)
+			}
+			firstFailure = fmt.Errorf("document %s: %w", item.DocumentID, err)
+		})
+	}
+
+	sourceErr := source(ctx, func(doc Document) error {
+		return indexer.Add(ctx, client.BulkIndexerAction("index"), res.Index, doc.ID, doc.Body, onSuccess, onFailure)
+	})
+
+	// The indexer is always closed, so that nothing is still being written when the index is rolled back
+	closeErr := indexer.Close(ctx)
+	res.IndexedDocuments, res.FailedDocuments = indexed.Load(), failed.Load()
+
+	if sourceErr != nil {
+		return fmt.Errorf("failed to read documents from source: %w", sourceErr)
+	}
+
+	if closeErr != nil {
+		return fmt.Errorf("failed to flush bulk indexer: %w", closeErr)
+	}
+
+	if res.FailedDocuments > r.cfg.MaxFailures {
+		return fmt.Errorf("%d documents failed to index, first failure: %w", res.FailedDocuments, firstFailure)
+	}
+
+	if err := r.client.RefreshIndices(ctx, []string{res.Index}); err != nil {
+		return fmt.Errorf("failed to refresh index %s: %w", res.Index, err)
+	}
+
+	health, err := r.client.ClusterHealth(ctx, []string{res.Index}, &client.ClusterHealthOptions{
+		WaitForStatus: r.cfg.WaitForStatus,
+		Timeout:       r.cfg.HealthTimeout,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get health of index %s: %w", res.Index, err)
+	}
+
+	if health.TimedOut {
+		return fmt.Errorf("index %s did not reach %s health within %s, health is %s",
+			res.Index, r.cfg.WaitForStatus, r.cfg.HealthTimeout, health.Status)
+	}
+
+	return nil
+}
+
+// aliasedIndices returns the indices the alias currently points to
+func (r *Reindexer) aliasedIndices(ctx context.Context) ([]string, error) {
+	body, err := r.client.GetAlias(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aliases: %w", err)
+	}
+
+	var indices map[string]struct {
+		Aliases map[string]json.RawMessage `json:"aliases"`
+	}
+	if err := json.Unmarshal(body, &indices); err != nil {
+		return nil, fmt.Errorf("failed to decode get aliases response: %w", err)
+	}
+
+	var aliased []string
+	for name, index := range indices {
+		if _, ok := index.Aliases[r.cfg.Alias]; ok {
+			aliased = append(aliased, name)
+		}
+	}
+	sort.Strings(aliased)
+
+	return aliased, nil
+}
+
+// deleteExpired deletes the indices created by the reindexer, other than the current index, beyond the retention count
+func (r *Reindexer) deleteExpired(ctx context.Context, current string) ([]string, error) {
+	body, err := r.client.GetIndices(ctx, []string{r.cfg.IndexPrefix + "-*"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get indices: %w", err)
+	}
+
+	var indices map[string]json.RawMessage
+	if err := json.Unmarshal(body, &indices); err != nil {
+		return nil, fmt.Errorf("failed to decode get indices response: %w", err)
+	}
+
+	var previous []string
+	for name := range indices {
+		if name != current && r.isReindexed(name) {
+			previous = append(previous, name)
+		}
+	}
+
+	// Timestamps sort in creation order, so the newest indices are kept
+	sort.Sort(sort.Reverse(sort.StringSlice(previous)))
+	if len(previous) <= r.cfg.Retention {
+		return nil, nil
+	}
+
+	expired := previous[r.cfg.Retention:]
+	if err := r.client.DeleteIndices(ctx, expired); err != nil {
+		return nil, fmt.Errorf("failed to delete indices %s: %w", strings.Join(expired, ", "), err)
+	}
+
+	log.Info(ctx, "reindex: expired indices deleted", log.Data{"alias": r.cfg.Alias, "indices": expired})
+
+	return expired, nil
+}
+
+// indexName returns the name of the index created by a run started at t
+func (r *Reindexer) indexName(t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("%s-%s-%09d", r.cfg.IndexPrefix, t.Format(timestampFormat), t.Nanosecond())
+}
+
+// isReindexed reports whether the index name is the prefix followed by a timestamp, so that other indices which
+// happen to share the prefix are never deleted. Indices named without the nanoseconds, by earlier versions, are
+// still recognised.
+func (r *Reindexer) isReindexed(name string) bool {
+	suffix, ok := strings.CutPrefix(name, r.cfg.IndexPrefix+"-")
+	if !ok {
+		return false
+	}
+
+	timestamp, nanos, hasNanos := strings.Cut(suffix, "-")
+	if hasNanos && (len(nanos) != 9 || strings.Trim(nanos, "0123456789") != "") {
+		return false
+	}
+
+	_, err := time.Parse(timestampFormat, timestamp)
+	return err == nil
+}
+
+// rollback deletes the new index, returning the error that caused the rollback along with any failure to delete it.
+// The index is deleted even if ctx is done, as that may be why the run failed.
+func (r *Reindexer) rollback(ctx context.Context, index string, cause error) error {
+	log.Error(ctx, "reindex: failed, deleting new index", cause, log.Data{"alias": r.cfg.Alias, "index": index})
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	if err := r.client.DeleteIndices(ctx, []string{index}); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to roll back, index %s could not be deleted: %w", index, err))
+	}
+
+	return cause
+}
@@ -0,0 +1,268 @@
+package reindexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-elasticsearch/v5/client"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/fake"
+	"github.com/ONSdigital/dp-elasticsearch/v5/client/mocks"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var testCtx = context.Background()
+
+// documents returns a source of n documents, with IDs 1 to n
+func documents(n int) Source {
+	return func(_ context.Context, add func(Document) error) error {
+		for i := 1; i <= n; i++ {
+			if err := add(Document{ID: fmt.Sprint(i), Body: []byte(fmt.Sprintf(`{"n":%d}`, i))}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// newTestReindexer returns a reindexer whose clock starts at 2024-01-02 03:04:05 and moves on a minute per run
+func newTestReindexer(esClient client.Client, cfg Config) *Reindexer {
+	r, err := New(esClient, cfg)
+	So(err, ShouldBeNil)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+
+	return r
+}
+
+func TestNew(t *testing.T) {
+	Convey("Given an empty alias", t, func() {
+		Convey("Then New returns an error", func() {
+			_, err := New(fake.NewClient(), Config{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a negative retention", t, func() {
+		Convey("Then New returns an error", func() {
+			_, err := New(fake.NewClient(), Config{Alias: "search", Retention: -1})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestReindex(t *testing.T) {
+	Convey("Given an alias pointing to an index built by a previous run", t, func() {
+		esClient := fake.NewClient()
+		r := newTestReindexer(esClient, Config{Alias: "search", Retention: 1})
+
+		first, err := r.Reindex(testCtx, documents(2))
+		So(err, ShouldBeNil)
+		So(first.Index, ShouldEqual, "search-20240102030505-000000000")
+		So(esClient.Aliases("search"), ShouldResemble, []string{first.Index})
+
+		Convey("When the index is rebuilt", func() {
+			second, err := r.Reindex(testCtx, documents(3))
+
+			Convey("Then the documents are indexed into a new index", func() {
+				So(err, ShouldBeNil)
+				So(second.Index, ShouldEqual, "search-20240102030605-000000000")
+				So(second.IndexedDocuments, ShouldEqual, 3)
+				So(esClient.Documents(second.Index), ShouldHaveLength, 3)
+			})
+
+			Convey("Then the alias is swapped to the new index and the previous index is kept", func() {
+				So(second.PreviousIndices, ShouldResemble, []string{first.Index})
+				So(esClient.Aliases("search"), ShouldResemble, []string{second.Index})
+				So(second.DeletedIndices, ShouldBeEmpty)
+				So(esClient.Documents(first.Index), ShouldHaveLength, 2)
+			})
+
+			Convey("Then a third rebuild deletes the index beyond the retention count", func() {
+				third, err := r.Reindex(testCtx, documents(1))
+				So(err, ShouldBeNil)
+				So(third.DeletedIndices, ShouldResemble, []string{first.Index})
+				So(esClient.Documents(first.Index), ShouldBeNil)
+				So(esClient.Documents(second.Index), ShouldHaveLength, 3)
+			})
+		})
+
+		Convey("When an unrelated index shares the prefix", func() {
+			So(esClient.CreateIndex(testCtx, "search-archive", nil), ShouldBeNil)
+			r.cfg.Retention = 0
+
+			_, err := r.Reindex(testCtx, documents(1))
+
+			Convey("Then it is not deleted", func() {
+				So(err, ShouldBeNil)
+				So(esClient.Documents("search-archive"), ShouldNotBeNil)
+				So(esClient.Documents(first.Index), ShouldBeNil)
+			})
+		})
+
+		Convey("When an index named without nanoseconds by an earlier version is beyond the retention count", func() {
+			So(esClient.CreateIndex(testCtx, "search-20240101000000", nil), ShouldBeNil)
+			r.cfg.Retention = 0
+
+			res, err := r.Reindex(testCtx, documents(1))
+
+			Convey("Then it is deleted along with the other previous indices", func() {
+				So(err, ShouldBeNil)
+				So(res.DeletedIndices, ShouldResemble, []string{first.Index, "search-20240101000000"})
+			})
+		})
+
+		Convey("When the source fails part way through", func() {
+			errSource := errors.New("source failed")
+			_, err := r.Reindex(testCtx, func(ctx context.Context, add func(Document) error) error {
+				if err := add(Document{ID: "1", Body: []byte(`{}`)}); err != nil {
+					return err
+				}
+				return errSource
+			})
+
+			Convey("Then the new index is deleted and the alias is unchanged", func() {
+				So(errors.Is(err, errSource), ShouldBeTrue)
+				So(esClient.Aliases("search"), ShouldResemble, []string{first.Index})
+				So(esClient.Documents("search-20240102030605-000000000"), ShouldBeNil)
+			})
+		})
+
+		Convey("When more documents fail to index than are allowed", func() {
+			_, err := r.Reindex(testCtx, func(ctx context.Context, add func(Document) error) error {
+				return add(Document{ID: "1", Body: []byte(`not json`)})
+			})
+
+			Convey("Then the new index is deleted and the alias is unchanged", func() {
+				So(err, ShouldNotBeNil)
+				So(esClient.Aliases("search"), ShouldResemble, []string{first.Index})
+				So(esClient.Documents("search-20240102030605-000000000"), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a reindexer whose runs start within the same second", t, func() {
+		esClient := fake.NewClient()
+		r := newTestReindexer(esClient, Config{Alias: "search", Retention: 1})
+		start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		r.now = func() time.Time {
+			start = start.Add(250 * time.Millisecond)
+			return start
+		}
+
+		Convey("When the index is rebuilt twice", func() {
+			first, err := r.Reindex(testCtx, documents(1))
+			So(err, ShouldBeNil)
+			second, err := r.Reindex(testCtx, documents(2))
+
+			Convey("Then each run creates its own index, named in the order they were created", func() {
+				So(err, ShouldBeNil)
+				So(first.Index, ShouldEqual, "search-20240102030405-250000000")
+				So(second.Index, ShouldEqual, "search-20240102030405-500000000")
+				So(second.Index, ShouldBeGreaterThan, first.Index)
+				So(second.PreviousIndices, ShouldResemble, []string{first.Index})
+				So(esClient.Aliases("search"), ShouldResemble, []string{second.Index})
+			})
+		})
+	})
+
+	Convey("Given a reindexer configured with a bulk indexer config", t, func() {
+		esClient := fake.NewClient()
+		bulkCfg := &client.BulkIndexerConfig{NumWorkers: 2, FlushBytes: 1 << 20, MaxBufferedBytes: 4 << 20}
+		var onOpen *client.BulkIndexerConfig
+		mockClient := &mocks.ClientMock{
+			CreateIndexFunc: esClient.CreateIndex,
+			OpenBulkIndexerFunc: func(ctx context.Context, name string, cfg *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+				onOpen = cfg
+				return esClient.OpenBulkIndexer(ctx, name, cfg)
+			},
+			RefreshIndicesFunc: esClient.RefreshIndices,
+			ClusterHealthFunc:  esClient.ClusterHealth,
+			GetAliasFunc:       esClient.GetAlias,
+			UpdateAliasesFunc:  esClient.UpdateAliases,
+			GetIndicesFunc:     esClient.GetIndices,
+			DeleteIndicesFunc:  esClient.DeleteIndices,
+		}
+		r := newTestReindexer(mockClient, Config{Alias: "search", BulkIndexer: bulkCfg})
+
+		Convey("When the index is rebuilt", func() {
+			res, err := r.Reindex(testCtx, documents(3))
+
+			Convey("Then the documents are streamed through a bulk indexer opened with the config", func() {
+				So(err, ShouldBeNil)
+				So(res.IndexedDocuments, ShouldEqual, 3)
+				So(onOpen, ShouldEqual, bulkCfg)
+			})
+		})
+	})
+
+	Convey("Given a new index that does not become healthy", t, func() {
+		var deleted []string
+		esClient := &mocks.ClientMock{
+			CreateIndexFunc: func(context.Context, string, []byte) error { return nil },
+			OpenBulkIndexerFunc: func(context.Context, string, *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+				return &mocks.BulkIndexerMock{
+					CloseFunc: func(context.Context) error { return nil },
+				}, nil
+			},
+			RefreshIndicesFunc: func(context.Context, []string) error { return nil },
+			ClusterHealthFunc: func(context.Context, []string, *client.ClusterHealthOptions) (*client.ClusterHealthResponse, error) {
+				return &client.ClusterHealthResponse{Status: client.ClusterStatusYellow, TimedOut: true}, nil
+			},
+			DeleteIndicesFunc: func(_ context.Context, indices []string) error {
+				deleted = indices
+				return nil
+			},
+		}
+		r := newTestReindexer(esClient, Config{Alias: "search", HealthTimeout: time.Second})
+
+		Convey("When the index is rebuilt", func() {
+			_, err := r.Reindex(testCtx, documents(0))
+
+			Convey("Then the new index is deleted without the alias being swapped", func() {
+				So(err, ShouldNotBeNil)
+				So(esClient.ClusterHealthCalls()[0].Opts.WaitForStatus, ShouldEqual, client.ClusterStatusGreen)
+				So(deleted, ShouldResemble, []string{"search-20240102030505-000000000"})
+				So(esClient.UpdateAliasesCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a reindex whose context is cancelled part way through", t, func() {
+		var deleteCtxErr error
+		var deleted []string
+		esClient := &mocks.ClientMock{
+			CreateIndexFunc: func(context.Context, string, []byte) error { return nil },
+			OpenBulkIndexerFunc: func(context.Context, string, *client.BulkIndexerConfig) (client.BulkIndexer, error) {
+				return &mocks.BulkIndexerMock{
+					CloseFunc: func(context.Context) error { return nil },
+				}, nil
+			},
+			DeleteIndicesFunc: func(ctx context.Context, indices []string) error {
+				deleteCtxErr, deleted = ctx.Err(), indices
+				return nil
+			},
+		}
+		r := newTestReindexer(esClient, Config{Alias: "search"})
+
+		Convey("When the index is rebuilt", func() {
+			ctx, cancel := context.WithCancel(testCtx)
+			_, err := r.Reindex(ctx, func(ctx context.Context, _ func(Document) error) error {
+				cancel()
+				return ctx.Err()
+			})
+
+			Convey("Then the new index is still deleted", func() {
+				So(errors.Is(err, context.Canceled), ShouldBeTrue)
+				So(deleted, ShouldResemble, []string{"search-20240102030505-000000000"})
+				So(deleteCtxErr, ShouldBeNil)
+			})
+		})
+	})
+}